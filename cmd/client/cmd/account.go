@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// exportTimeout limits the whole account export stream.
+const exportTimeout = time.Minute
+
+// AccountExport is the document written by the "account export" command.
+type AccountExport struct {
+	User              ExportedUser           `json:"user"`
+	Keys              *ExportedKeys          `json:"keys,omitempty"`
+	Secrets           []ExportedSecret       `json:"secrets"`
+	Shares            []ExportedShare        `json:"shares,omitempty"`
+	Organizations     []ExportedOrganization `json:"organizations,omitempty"`
+	Collections       []ExportedCollection   `json:"collections,omitempty"`
+	CollectionSecrets []ExportedSecret       `json:"collection_secrets,omitempty"`
+}
+
+// ExportedUser is the user's account row in the export document.
+type ExportedUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// ExportedKeys is the user's sharing keypair, the private key is encrypted with the encryption key.
+type ExportedKeys struct {
+	PublicKey           string `json:"public_key"`
+	EncryptedPrivateKey string `json:"encrypted_private_key"`
+}
+
+// ExportedSecret is a personal or collection secret in the export document.
+// Encrypted secrets keep data and meta as stored on the server.
+type ExportedSecret struct {
+	Id           string     `json:"id"`
+	CollectionID int64      `json:"collection_id,omitempty"`
+	Data         string     `json:"data"`
+	Meta         string     `json:"meta"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Encrypted    bool       `json:"encrypted,omitempty"`
+}
+
+// ExportedShare is a secret shared with the user in the export document.
+// Encrypted shares keep data and meta as stored on the server along with the wrapped data key.
+type ExportedShare struct {
+	SecretID   string `json:"secret_id"`
+	Owner      string `json:"owner"`
+	Data       string `json:"data"`
+	Meta       string `json:"meta"`
+	WrappedKey string `json:"wrapped_key,omitempty"`
+	Encrypted  bool   `json:"encrypted,omitempty"`
+}
+
+// ExportedOrganization is the user's membership in an organization in the export document.
+type ExportedOrganization struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// ExportedCollection is a collection the user has access to with its key wrapped for him.
+type ExportedCollection struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	OrgID      int64  `json:"org_id"`
+	WrappedKey string `json:"wrapped_key"`
+}
+
+// exportedSecrets converts secrets of the export for the document.
+func exportedSecrets(secrets []client.ExportedSecret) []ExportedSecret {
+	exported := make([]ExportedSecret, 0, len(secrets))
+	for _, s := range secrets {
+		exported = append(exported, ExportedSecret{
+			Id:           s.ID,
+			CollectionID: s.CollectionID,
+			Data:         s.Data,
+			Meta:         s.Note,
+			ExpiresAt:    localTime(s.ExpiresAt),
+			Encrypted:    s.Encrypted,
+		})
+	}
+	return exported
+}
+
+// newAccountExport converts the export for the document.
+func newAccountExport(exported *client.Export) AccountExport {
+	export := AccountExport{
+		User:              ExportedUser{ID: exported.UserID, Username: exported.Username},
+		Secrets:           exportedSecrets(exported.Secrets),
+		CollectionSecrets: exportedSecrets(exported.CollectionSecrets),
+	}
+	if exported.Keys != nil {
+		export.Keys = &ExportedKeys{
+			PublicKey:           exported.Keys.PublicKey,
+			EncryptedPrivateKey: exported.Keys.EncryptedPrivateKey,
+		}
+	}
+	for _, sh := range exported.Shares {
+		share := ExportedShare{
+			SecretID:  sh.SecretID,
+			Owner:     sh.Owner,
+			Data:      sh.Data,
+			Meta:      sh.Note,
+			Encrypted: sh.Encrypted,
+		}
+		if sh.Encrypted {
+			share.WrappedKey = sh.WrappedKey
+		}
+		export.Shares = append(export.Shares, share)
+	}
+	for _, o := range exported.Organizations {
+		export.Organizations = append(export.Organizations, ExportedOrganization{ID: o.Id, Name: o.Name, Role: o.Role})
+	}
+	for _, k := range exported.Collections {
+		export.Collections = append(export.Collections, ExportedCollection{
+			ID:         k.CollectionId,
+			Name:       k.CollectionName,
+			OrgID:      k.OrgId,
+			WrappedKey: k.WrappedKey,
+		})
+	}
+
+	return export
+}
+
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Manage your account",
+	Long:  "Show storage usage, export all your data or delete your account from the GophKeeper service.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// accountExportCmd represents the "account export" command.
+var accountExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export all account data",
+	Long: `Downloads the account data of the authenticated user and writes it as JSON: the user row,
+sharing keypair, personal secrets, secrets shared with the user, organization memberships,
+collections the user has access to with their secrets.
+Secrets are decrypted with the encryption key unless --raw is set. Secrets failed to decrypt
+are exported as stored on the server and marked "encrypted".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		raw, _ := cmd.Flags().GetBool("raw")
+
+		c := newClient()
+		defer c.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+
+		// Secrets failed to decrypt are logged and exported as stored on the server.
+		exported, err := c.ExportAccount(ctx, raw)
+		if err = skipUndecrypted(err); err != nil {
+			logging.Sugar.Fatalf("Failed to export account: %s", rpcError(err))
+		}
+		export := newAccountExport(exported)
+
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to marshal export: %v", err)
+		}
+
+		if output == "" {
+			fmt.Println(string(data))
+			return
+		}
+
+		if err := os.WriteFile(output, data, 0o600); err != nil {
+			logging.Sugar.Fatalf("Failed to write export file: %v", err)
+		}
+		fmt.Printf("Exported %d secrets to %s\n", len(export.Secrets), output)
+	},
+}
+
+// accountDeleteCmd represents the "account delete" command.
+var accountDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete account with all secrets",
+	Long: `Permanently deletes the authenticated user and all his secrets. Requires the current password.
+Collection secrets created by the user stay in their collections. The last owner of an organization
+with other members must hand the owner role over first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		password, _ := cmd.Flags().GetString("password")
+		if password == "" {
+			logging.Sugar.Fatal("Password (--password) must be provided to delete account")
+		}
+
+		confirmed, _ := cmd.Flags().GetBool("yes")
+		if !confirmed {
+			fmt.Println("Account deletion is permanent, run the command with --yes to confirm")
+			return
+		}
+
+		c := newClient()
+		defer c.Close()
+
+		// Token of the deleted user is cleared as it is useless now.
+		if err := c.DeleteAccount(context.Background(), password); err != nil {
+			logging.Sugar.Fatalf("Failed to delete account: %s", rpcError(err))
+		}
+
+		fmt.Println("Account deleted successfully")
+	},
+}
+
+// accountUsageCmd represents the "account usage" command.
+var accountUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show storage usage and quota",
+	Long:  "Prints the number and the total size of your secrets along with the limits set by the server.",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := newClient()
+		defer c.Close()
+
+		resp, err := c.Usage(context.Background())
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to get usage: %s", rpcError(err))
+		}
+
+		formatCount := func(n int64) string { return strconv.FormatInt(n, 10) }
+		fmt.Printf("Secrets:         %d / %s\n", resp.Secrets, formatLimit(resp.MaxSecrets, formatCount))
+		fmt.Printf("Total size:      %s / %s\n", formatBytes(resp.Bytes), formatLimit(resp.MaxTotalBytes, formatBytes))
+		fmt.Printf("Max secret size: %s\n", formatLimit(resp.MaxSecretBytes, formatBytes))
+	},
+}
+
+// formatLimit formats the quota limit, zero limit means unlimited.
+func formatLimit(limit int64, format func(int64) string) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return format(limit)
+}
+
+// formatBytes formats size in bytes with binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	rootCmd.AddCommand(accountCmd)
+	accountCmd.AddCommand(accountExportCmd)
+	accountCmd.AddCommand(accountDeleteCmd)
+	accountCmd.AddCommand(accountUsageCmd)
+
+	accountExportCmd.Flags().StringP("output", "o", "", "File to write the export to, default is stdout")
+	accountExportCmd.Flags().Bool("raw", false, "Export secrets encrypted as stored on the server")
+
+	accountDeleteCmd.Flags().Bool("yes", false, "Confirm account deletion")
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// auditCmd represents the "audit" command.
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log of your account",
+	Long: `Prints security-relevant events of your account: logins, token operations and secret changes
+with client IP address and user agent. Use --since or --from/--to to select the time range.
+Time is accepted in RFC3339 (2006-01-02T15:04:05Z07:00) or date (2006-01-02) format.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, _ := cmd.Flags().GetDuration("since")
+		fromStr, _ := cmd.Flags().GetString("from")
+		toStr, _ := cmd.Flags().GetString("to")
+		limit, _ := cmd.Flags().GetInt32("limit")
+
+		var from, to time.Time
+		if since > 0 {
+			from = time.Now().Add(-since)
+		}
+		if fromStr != "" {
+			from = parseTime(fromStr)
+		}
+		if toStr != "" {
+			to = parseTime(toStr)
+		}
+
+		c := newClient()
+		defer c.Close()
+
+		events, err := c.AuditEvents(context.Background(), from, to, limit)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to list audit events: %s", rpcError(err))
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tEVENT\tRESULT\tIP\tUSER AGENT\tDETAILS")
+		for _, e := range events {
+			result := "ok"
+			if !e.Success {
+				result = "failed"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.CreatedAt.AsTime().Local().Format(time.DateTime),
+				e.Type, result, e.PeerIp, e.UserAgent, e.Details)
+		}
+		w.Flush()
+	},
+}
+
+// parseTime parses time in RFC3339 or date format.
+func parseTime(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		logging.Sugar.Fatalf("Invalid time %q: expected RFC3339 or YYYY-MM-DD", value)
+	}
+
+	return t
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().Duration("since", 0, "Show events of the last period, e.g. 24h")
+	auditCmd.Flags().String("from", "", "Show events since the time")
+	auditCmd.Flags().String("to", "", "Show events before the time")
+	auditCmd.Flags().Int32("limit", 100, "Maximum number of events, 0 means no limit")
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/status"
+)
+
+// Versions kept when a conflict is resolved.
+const (
+	keepServer = "server"
+	keepLocal  = "local"
+	keepBoth   = "both"
+)
+
+// ConflictOutput is a structure for outputing a conflict of the secret changed both locally and on the server.
+type ConflictOutput struct {
+	SecretID   string          `json:"secret_id"`
+	CopyID     string          `json:"copy_id"`
+	DetectedAt time.Time       `json:"detected_at"`
+	Fields     []ConflictField `json:"fields"`
+}
+
+// ConflictField is a field of the secret changed differently on the server and locally.
+type ConflictField struct {
+	Name   string `json:"name"`
+	Server string `json:"server"`
+	Local  string `json:"local"`
+}
+
+// secretFields splits the cached secret into fields.
+func secretFields(secret vault.Secret) vault.Fields {
+	return vault.ParseFields(secret.Data, secret.Meta)
+}
+
+// withFields sets the fields as the content of the change.
+func withFields(change vault.Change, fields vault.Fields) vault.Change {
+	change.Secret.Data, change.Secret.Meta = fields.Format()
+	return change
+}
+
+// mergeChange merges the queued update with the version of the secret changed on the server since the update's base.
+// Fields changed differently on both sides keep the server values in the update,
+// the local values are kept in a copy of the secret created with the returned change.
+func mergeChange(change vault.Change, remote vault.Secret) (update vault.Change, conflictCopy *vault.Change, conflicts []string) {
+	merged, mergedLocal, conflicts := vault.Merge(secretFields(*change.Base), secretFields(change.Secret), secretFields(remote))
+	update = withFields(change, merged)
+	if len(conflicts) == 0 {
+		return update, nil, nil
+	}
+
+	// The copy has the type and the expiration time the secret had locally.
+	copyChange := withFields(vault.Change{Op: vault.OpCreate, Secret: change.Apply(remote)}, mergedLocal)
+	copyChange.Secret.ID = uuid.New().String()
+
+	return update, &copyChange, conflicts
+}
+
+// findSecret returns the secret of the local vault by id.
+func findSecret(secrets []vault.Secret, id string) (vault.Secret, bool) {
+	for _, s := range secrets {
+		if s.ID == id {
+			return s, true
+		}
+	}
+
+	return vault.Secret{}, false
+}
+
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "List and resolve sync conflicts",
+	Long: `Secrets changed differently offline and on the server in the same fields are kept in two copies during sync:
+the secret keeps the server values and its copy keeps the local ones until the conflict is resolved.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// conflictsListCmd represents the "conflicts list" command.
+var conflictsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List unresolved conflicts with the server and local values of the conflicting fields",
+	Run: func(cmd *cobra.Command, args []string) {
+		v := openVault(encryptionKey())
+		secrets := v.Secrets()
+
+		conflicts := make([]ConflictOutput, 0, len(v.Conflicts()))
+		for _, c := range v.Conflicts() {
+			server, local := make(vault.Fields), make(vault.Fields)
+			if s, ok := findSecret(secrets, c.SecretID); ok {
+				server = secretFields(s)
+			}
+			if s, ok := findSecret(secrets, c.CopyID); ok {
+				local = secretFields(s)
+			}
+
+			output := ConflictOutput{SecretID: c.SecretID, CopyID: c.CopyID, DetectedAt: c.DetectedAt.Local()}
+			for _, name := range c.Fields {
+				output.Fields = append(output.Fields, ConflictField{Name: name, Server: server[name], Local: local[name]})
+			}
+			conflicts = append(conflicts, output)
+		}
+
+		// Translate result to JSON and output.
+		output, err := json.MarshalIndent(conflicts, "", "  ")
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to marshal conflicts: %v", err)
+		}
+
+		fmt.Println("Unresolved conflicts:")
+		fmt.Println(string(output))
+	},
+}
+
+// conflictsResolveCmd represents the "conflicts resolve" command.
+var conflictsResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Resolve a conflict keeping the server, the local or both versions of the secret",
+	Long: `Resolves the conflict of the secret:
+  server - deletes the copy with the local values,
+  local  - replaces the secret with the copy and deletes the copy,
+  both   - keeps the secret and the copy as separate secrets.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		secretID, _ := cmd.Flags().GetString("id")
+		keep, _ := cmd.Flags().GetString("keep")
+		if keep != keepServer && keep != keepLocal && keep != keepBoth {
+			logging.Sugar.Fatalf("Invalid --keep %q, expected server, local or both", keep)
+		}
+
+		v := openVault(encryptionKey())
+		conflict, ok := v.ResolveConflict(secretID)
+		if !ok {
+			logging.Sugar.Fatalf("No conflict of secret %s", secretID)
+		}
+
+		var mutations []client.Mutation
+		if keep == keepLocal {
+			copySecret, ok := findSecret(v.Secrets(), conflict.CopyID)
+			if !ok {
+				logging.Sugar.Fatalf("Copy %s of the secret is not found in the local vault, run \"sync\" first", conflict.CopyID)
+			}
+			update := vault.Change{Op: vault.OpUpdate, Secret: copySecret, ClearExpiresAt: copySecret.ExpiresAt == nil}
+			update.Secret.ID = conflict.SecretID
+			mutations = append(mutations, mutation(update))
+		}
+		if keep != keepBoth {
+			mutations = append(mutations, client.Mutation{Op: client.MutationDelete, Secret: client.Secret{ID: conflict.CopyID}})
+		}
+
+		c := newClient()
+		defer c.Close()
+
+		if len(mutations) > 0 {
+			// Replacing the secret and deleting the copy are applied together.
+			results, committed, err := c.MutateSecrets(context.Background(), mutations, false)
+			if err != nil {
+				logging.Sugar.Fatalf("Failed to resolve conflict: %s", rpcError(err))
+			}
+			if !committed {
+				for _, result := range results {
+					if result.Err != nil {
+						logging.Sugar.Errorf("Failed to resolve conflict: %s", status.Convert(result.Err).Message())
+					}
+				}
+				logging.Sugar.Fatal("Conflict is not resolved")
+			}
+		}
+
+		if err := v.Save(); err != nil {
+			logging.Sugar.Fatalf("Failed to save local vault: %v", err)
+		}
+
+		syncedAt := time.Now()
+		secrets, err := listAllSecrets(c, client.Filter{})
+		if err != nil {
+			logging.Sugar.Errorf("Failed to refresh local vault: %s", rpcError(err))
+		} else {
+			refreshVault(secrets, syncedAt)
+		}
+
+		fmt.Printf("Conflict of secret %s resolved, kept versions: %s\n", secretID, keep)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(conflictsCmd)
+	conflictsCmd.AddCommand(conflictsListCmd)
+	conflictsCmd.AddCommand(conflictsResolveCmd)
+
+	conflictsResolveCmd.Flags().StringP("id", "i", "", "Identifier (id) of the secret in conflict")
+	conflictsResolveCmd.MarkFlagRequired("id")
+	conflictsResolveCmd.Flags().String("keep", "", "Version to keep: server, local or both")
+	conflictsResolveCmd.MarkFlagRequired("keep")
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// userAgent identifies the client in the server's audit log.
+const userAgent = "gophkeeper-cli"
+
+// newClient connects to the GophKeeper server from the configuration. Caller must close the client.
+// With username and password in the configuration the client logs in again when the token expires.
+func newClient() *client.Client {
+	c, err := client.New(
+		viper.GetString("grpc_address"),
+		client.WithTokenStorage(tokenStorage),
+		client.WithEncryptionKey(viper.GetString("encryption_key")),
+		client.WithCredentials(viper.GetString("username"), viper.GetString("password")),
+		client.WithUserAgent(userAgent))
+	if err != nil {
+		logging.Sugar.Fatalf("Failed to connect gRPC server: %v", err)
+	}
+
+	return c
+}
+
+// skipUndecrypted logs secrets which failed to decrypt and returns other errors of the request.
+func skipUndecrypted(err error) error {
+	var decryptErr *client.DecryptError
+	if !errors.As(err, &decryptErr) {
+		return err
+	}
+
+	ids := make([]string, 0, len(decryptErr.Failed))
+	for id := range decryptErr.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		logging.Sugar.Errorf("Failed to decrypt secret (id: %s): %v", id, decryptErr.Failed[id])
+	}
+
+	return nil
+}
+
+// rpcError describes a failed request to the server in a user-friendly way.
+// Server messages of domain errors are kept, internal ones carry no useful details.
+func rpcError(err error) string {
+	switch {
+	case errors.Is(err, client.ErrNoToken):
+		return "please login first: no token"
+	case errors.Is(err, client.ErrNoEncryptionKey):
+		return "encryption key (encryption_key) is not set in configuration"
+	case errors.Is(err, client.ErrNoKeys):
+		return "no sharing keys found, run \"keys init\" first"
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	switch st.Code() {
+	case codes.NotFound, codes.AlreadyExists, codes.InvalidArgument, codes.FailedPrecondition:
+		return st.Message()
+	case codes.PermissionDenied:
+		return "you don't have access to this: " + st.Message()
+	case codes.ResourceExhausted:
+		return "storage limit reached: " + st.Message()
+	case codes.Unauthenticated:
+		return "authentication failed, please login again: " + st.Message()
+	case codes.DeadlineExceeded:
+		return "the server didn't respond in time, please try again later"
+	case codes.Unavailable:
+		return fmt.Sprintf("the server at %s is unavailable, check the address and your connection", viper.GetString("grpc_address"))
+	case codes.Internal, codes.Unknown:
+		return "the server failed to process the request, please try again later"
+	default:
+		return st.Message()
+	}
+}
+
+// encryptionKey reads the secret encryption key from the configuration.
+func encryptionKey() string {
+	key := viper.GetString("encryption_key")
+	if key == "" {
+		logging.Sugar.Fatal("Encryption key (encryption_key) is not set in configuration")
+	}
+
+	return key
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// Fields organizing secrets. They precede the type specific data in the secret plaintext,
+// so folders and tags are encrypted together with the secret.
+const (
+	folderField = "folder:"
+	tagsField   = "tags:"
+)
+
+// organizeFlags reads and validates --folder and --tag flags.
+func organizeFlags(cmd *cobra.Command) (string, []string) {
+	folder, _ := cmd.Flags().GetString("folder")
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+
+	folder = cleanFolder(folder)
+	if strings.Contains(folder, ";") {
+		logging.Sugar.Fatal("Folder must not contain ';'")
+	}
+	for i, tag := range tags {
+		tags[i] = strings.TrimSpace(tag)
+		if tags[i] == "" || strings.Contains(tags[i], ";") {
+			logging.Sugar.Fatalf("Invalid tag: %q", tag)
+		}
+	}
+
+	return folder, tags
+}
+
+// cleanFolder returns folder path without leading and trailing slashes, empty for the root folder.
+func cleanFolder(folder string) string {
+	return strings.Trim(path.Clean("/"+strings.TrimSpace(folder)), "/")
+}
+
+// withFolderAndTags prepends folder and tags fields to the secret plaintext.
+func withFolderAndTags(rawData, folder string, tags []string) string {
+	var sb strings.Builder
+	if folder != "" {
+		sb.WriteString(folderField + folder + ";")
+	}
+	if len(tags) > 0 {
+		sb.WriteString(tagsField + strings.Join(tags, ",") + ";")
+	}
+	sb.WriteString(rawData)
+
+	return sb.String()
+}
+
+// splitFolderAndTags extracts folder and tags fields from the secret plaintext.
+// Secrets created without them are returned as is.
+func splitFolderAndTags(data string) (rest, folder string, tags []string) {
+	rest = data
+	if value, tail, ok := cutField(rest, folderField); ok {
+		folder, rest = value, tail
+	}
+	if value, tail, ok := cutField(rest, tagsField); ok {
+		tags, rest = strings.Split(value, ","), tail
+	}
+
+	return rest, folder, tags
+}
+
+// cutField cuts the leading "name:value;" field of the plaintext.
+func cutField(data, name string) (value, rest string, ok bool) {
+	tail, ok := strings.CutPrefix(data, name)
+	if !ok {
+		return "", data, false
+	}
+	value, rest, ok = strings.Cut(tail, ";")
+	if !ok {
+		return "", data, false
+	}
+
+	return value, rest, true
+}
+
+// newDecryptedSecret creates an output structure of the decrypted secret plaintext.
+func newDecryptedSecret(id, plaintext, meta string) DecryptedSecret {
+	data, folder, tags := splitFolderAndTags(plaintext)
+	return DecryptedSecret{
+		Id:     id,
+		Data:   data,
+		Meta:   meta,
+		Folder: folder,
+		Tags:   tags,
+	}
+}
+
+// filterSecrets returns secrets inside the folder (including subfolders) having all the tags.
+func filterSecrets(secrets []DecryptedSecret, folder string, tags []string) []DecryptedSecret {
+	filtered := make([]DecryptedSecret, 0, len(secrets))
+	for _, s := range secrets {
+		if folder != "" && s.Folder != folder && !strings.HasPrefix(s.Folder, folder+"/") {
+			continue
+		}
+		if !hasTags(s.Tags, tags) {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+
+	return filtered
+}
+
+// hasTags checks that secretTags contain all the tags.
+func hasTags(secretTags, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, st := range secretTags {
+			if st == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// folderNode is a folder of the secrets tree.
+type folderNode struct {
+	folders map[string]*folderNode
+	secrets []DecryptedSecret
+}
+
+// printTree outputs secrets grouped by folders as a tree.
+func printTree(secrets []DecryptedSecret) {
+	root := &folderNode{folders: make(map[string]*folderNode)}
+	for _, s := range secrets {
+		node := root
+		if s.Folder != "" {
+			for _, name := range strings.Split(s.Folder, "/") {
+				child, ok := node.folders[name]
+				if !ok {
+					child = &folderNode{folders: make(map[string]*folderNode)}
+					node.folders[name] = child
+				}
+				node = child
+			}
+		}
+		node.secrets = append(node.secrets, s)
+	}
+
+	fmt.Println("/")
+	root.print("  ")
+}
+
+// print outputs subfolders and secrets of the folder with the indent.
+func (n *folderNode) print(indent string) {
+	names := make([]string, 0, len(n.folders))
+	for name := range n.folders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s%s/\n", indent, name)
+		n.folders[name].print(indent + "  ")
+	}
+
+	sort.Slice(n.secrets, func(i, j int) bool { return n.secrets[i].Id < n.secrets[j].Id })
+	for _, s := range n.secrets {
+		line := fmt.Sprintf("%s[%s] %s", indent, s.Id, secretType(s.Data))
+		if s.Meta != "" {
+			line += " - " + s.Meta
+		}
+		if len(s.Tags) > 0 {
+			line += " #" + strings.Join(s.Tags, " #")
+		}
+		fmt.Println(line)
+	}
+}
+
+// secretType returns the type of the secret by its plaintext.
+func secretType(data string) string {
+	field, _, _ := strings.Cut(data, ":")
+	switch field {
+	case "number":
+		return "card"
+	case "login":
+		return "credentials"
+	default:
+		return field
+	}
+}
+
+// addOrganizeFlags adds --folder and --tag flags to the command.
+func addOrganizeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("folder", "", "Folder path of the secret, e.g. work/aws")
+	cmd.Flags().StringSlice("tag", nil, "Tag of the secret, can be repeated or comma separated")
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/passgen"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Config key of the named password policies and the name of the policy used without --policy.
+const (
+	policiesKey   = "password_policies"
+	defaultPolicy = "default"
+)
+
+// passwordPolicy returns the default policy overridden by the policy of the config
+// selected with --policy and then by the policy flags set for the command.
+func passwordPolicy(cmd *cobra.Command) passgen.Policy {
+	p := passgen.DefaultPolicy()
+
+	name, _ := cmd.Flags().GetString("policy")
+	if name == "" {
+		name = defaultPolicy
+	}
+	key := policiesKey + "." + strings.ToLower(name)
+	switch {
+	case viper.IsSet(key):
+		// Lists of the config replace the default ones, decoding would merge them element by element.
+		if viper.IsSet(key + ".classes") {
+			p.Classes = nil
+		}
+		if viper.IsSet(key + ".require") {
+			p.Require = nil
+		}
+		if err := viper.UnmarshalKey(key, &p); err != nil {
+			logging.Sugar.Fatalf("Failed to read password policy %s: %v", name, err)
+		}
+		if !viper.IsSet(key + ".require") {
+			p.Require = requiredOf(p)
+		}
+	case cmd.Flags().Changed("policy"):
+		logging.Sugar.Fatalf("Password policy %s is not found in the config", name)
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("length") {
+		p.Length, _ = flags.GetInt("length")
+	}
+	if flags.Changed("classes") {
+		p.Classes, _ = flags.GetStringSlice("classes")
+		p.Require = requiredOf(p)
+	}
+	if flags.Changed("require") {
+		p.Require, _ = flags.GetStringSlice("require")
+	}
+	if flags.Changed("exclude-ambiguous") {
+		p.ExcludeAmbiguous, _ = flags.GetBool("exclude-ambiguous")
+	}
+	if flags.Changed("passphrase") {
+		p.Passphrase, _ = flags.GetBool("passphrase")
+	}
+	if flags.Changed("words") {
+		p.Words, _ = flags.GetInt("words")
+	}
+	if flags.Changed("separator") {
+		p.Separator, _ = flags.GetString("separator")
+	}
+
+	return p
+}
+
+// requiredOf returns the required classes of the policy left after its classes are replaced:
+// classes left out are no longer required.
+func requiredOf(p passgen.Policy) []string {
+	return slices.DeleteFunc(slices.Clone(p.Require), func(class string) bool {
+		return !slices.Contains(p.Classes, class)
+	})
+}
+
+// generatePassword returns a new password of the policy selected for the command.
+func generatePassword(cmd *cobra.Command) string {
+	password, err := passgen.Generate(passwordPolicy(cmd))
+	if err != nil {
+		logging.Sugar.Fatalf("Failed to generate password: %v", err)
+	}
+
+	return password
+}
+
+// savePolicy writes the policy under the name to the client config file, $HOME/.gophkeeper.yaml if none is used.
+// The file is read anew so that flags bound to the global config are not written to it.
+func savePolicy(name string, p passgen.Policy) (string, error) {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, ".gophkeeper.yaml")
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if filepath.Ext(path) == "" {
+		v.SetConfigType("yaml")
+	}
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	v.Set(policiesKey+"."+strings.ToLower(name), map[string]any{
+		"length":            p.Length,
+		"classes":           p.Classes,
+		"require":           p.Require,
+		"exclude_ambiguous": p.ExcludeAmbiguous,
+		"passphrase":        p.Passphrase,
+		"words":             p.Words,
+		"separator":         p.Separator,
+	})
+
+	return path, v.WriteConfig()
+}
+
+// generateCmd represents the generate command.
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a random password or passphrase",
+	Long: `Generates a password of the chosen length and character classes or a diceware passphrase
+of words from the EFF large wordlist, using the cryptographically secure random generator.
+Settings are taken from the named policy of the client config ("default" if --policy is not set),
+flags override the policy. With --save-policy the resulting settings are saved to the config under the name.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		p := passwordPolicy(cmd)
+
+		if name, _ := cmd.Flags().GetString("save-policy"); name != "" {
+			if strings.Contains(name, ".") {
+				logging.Sugar.Fatal("Policy name must not contain '.'")
+			}
+			if err := p.Validate(); err != nil {
+				logging.Sugar.Fatalf("Invalid password policy: %v", err)
+			}
+			path, err := savePolicy(name, p)
+			if err != nil {
+				logging.Sugar.Fatalf("Failed to save password policy: %v", err)
+			}
+			fmt.Printf("Password policy %s saved to %s\n", name, path)
+			return
+		}
+
+		password, err := passgen.Generate(p)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to generate password: %v", err)
+		}
+		fmt.Println(password)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+
+	generateCmd.Flags().String("policy", "", "Name of the password policy of the config")
+	generateCmd.Flags().String("save-policy", "", "Save the resulting policy to the config under the name instead of generating")
+
+	defaults := passgen.DefaultPolicy()
+	generateCmd.Flags().IntP("length", "l", defaults.Length, "Password length")
+	generateCmd.Flags().StringSlice("classes", defaults.Classes, "Character classes of the password: "+strings.Join(passgen.Classes, ", "))
+	generateCmd.Flags().StringSlice("require", defaults.Require, "Classes present in the password at least once")
+	generateCmd.Flags().Bool("exclude-ambiguous", false, "Leave out characters easily confused such as I, l, 1, O and 0")
+
+	generateCmd.Flags().Bool("passphrase", false, "Generate a passphrase of dictionary words")
+	generateCmd.Flags().Int("words", defaults.Words, "Number of words of the passphrase")
+	generateCmd.Flags().String("separator", defaults.Separator, "Separator of the passphrase words")
+}
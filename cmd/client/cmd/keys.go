@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage sharing keys",
+	Long:  "Generate the keypair used to share secrets with other GophKeeper users.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// keysInitCmd represents the "keys init" command.
+var keysInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate sharing keypair",
+	Long: `Generates X25519 keypair and uploads it to the server.
+The private key is encrypted with the encryption key before upload.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+
+		c := newClient()
+		defer c.Close()
+
+		err := c.InitKeys(context.Background(), force)
+		if errors.Is(err, client.ErrKeysExist) {
+			fmt.Println("Sharing keys already exist, use --force to replace them")
+			return
+		}
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to generate keys: %s", rpcError(err))
+		}
+
+		fmt.Println("Sharing keys generated successfully")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+	keysCmd.AddCommand(keysInitCmd)
+
+	keysInitCmd.Flags().Bool("force", false, "Replace existing keys, secrets shared with you earlier become unreadable")
+}
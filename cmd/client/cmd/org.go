@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+var orgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Manage organizations and shared vaults",
+	Long: `Create organizations, invite members with owner, admin, member or read-only roles
+and keep secrets in organization collections.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// orgCreateCmd represents the "org create" command.
+var orgCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new organization",
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		id, err := c.CreateOrganization(ctx, name)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to create organization: %s", rpcError(err))
+		}
+
+		fmt.Printf("Organization created with id: %d\n", id)
+	},
+}
+
+// orgListCmd represents the "org list" command.
+var orgListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your organizations",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		orgs, err := c.ListOrganizations(ctx)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to list organizations: %s", rpcError(err))
+		}
+
+		for _, org := range orgs {
+			fmt.Printf("%d\t%s\t%s\n", org.Id, org.Name, org.Role)
+		}
+	},
+}
+
+// orgMembersCmd represents the "org members" command.
+var orgMembersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "List organization members",
+	Run: func(cmd *cobra.Command, args []string) {
+		orgID, _ := cmd.Flags().GetInt64("org")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		members, err := c.ListMembers(ctx, orgID)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to list members: %s", rpcError(err))
+		}
+
+		for _, m := range members {
+			fmt.Printf("%s\t%s\n", m.Username, m.Role)
+		}
+	},
+}
+
+// orgInviteCmd represents the "org invite" command.
+var orgInviteCmd = &cobra.Command{
+	Use:   "invite",
+	Short: "Invite a user to organization",
+	Long:  "Invites a user and wraps keys of all organization collections you have access to with his public key.",
+	Run: func(cmd *cobra.Command, args []string) {
+		orgID, _ := cmd.Flags().GetInt64("org")
+		username, _ := cmd.Flags().GetString("user")
+		role, _ := cmd.Flags().GetString("role")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		id, err := c.InviteMember(ctx, orgID, username, role)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to invite member: %s", rpcError(err))
+		}
+
+		fmt.Printf("Invitation sent with id: %d\n", id)
+	},
+}
+
+// orgInvitationsCmd represents the "org invitations" command.
+var orgInvitationsCmd = &cobra.Command{
+	Use:   "invitations",
+	Short: "List your pending invitations",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		invitations, err := c.ListInvitations(ctx)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to list invitations: %s", rpcError(err))
+		}
+
+		for _, inv := range invitations {
+			fmt.Printf("%d\t%s (id: %d)\t%s\tinvited by %s\n", inv.Id, inv.OrgName, inv.OrgId, inv.Role, inv.InvitedBy)
+		}
+	},
+}
+
+// orgAcceptCmd represents the "org accept" command.
+var orgAcceptCmd = &cobra.Command{
+	Use:   "accept",
+	Short: "Accept an invitation to organization",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetInt64("id")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		if err := c.AcceptInvitation(ctx, id); err != nil {
+			logging.Sugar.Fatalf("Failed to accept invitation: %s", rpcError(err))
+		}
+
+		fmt.Println("Invitation accepted")
+	},
+}
+
+// orgRemoveCmd represents the "org remove" command.
+var orgRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a member from organization",
+	Run: func(cmd *cobra.Command, args []string) {
+		orgID, _ := cmd.Flags().GetInt64("org")
+		username, _ := cmd.Flags().GetString("user")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		if err := c.RemoveMember(ctx, orgID, username); err != nil {
+			logging.Sugar.Fatalf("Failed to remove member: %s", rpcError(err))
+		}
+
+		fmt.Printf("%s removed from organization %d\n", username, orgID)
+	},
+}
+
+// orgRoleCmd represents the "org role" command.
+var orgRoleCmd = &cobra.Command{
+	Use:   "role",
+	Short: "Change role of organization member",
+	Run: func(cmd *cobra.Command, args []string) {
+		orgID, _ := cmd.Flags().GetInt64("org")
+		username, _ := cmd.Flags().GetString("user")
+		role, _ := cmd.Flags().GetString("role")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		if err := c.SetMemberRole(ctx, orgID, username, role); err != nil {
+			logging.Sugar.Fatalf("Failed to set member role: %s", rpcError(err))
+		}
+
+		fmt.Printf("%s is now %s in organization %d\n", username, role, orgID)
+	},
+}
+
+var orgCollectionCmd = &cobra.Command{
+	Use:   "collection",
+	Short: "Manage organization collections",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// orgCollectionCreateCmd represents the "org collection create" command.
+var orgCollectionCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a collection in organization",
+	Long:  "Generates a collection key and wraps it for every organization member having sharing keys.",
+	Run: func(cmd *cobra.Command, args []string) {
+		orgID, _ := cmd.Flags().GetInt64("org")
+		name, _ := cmd.Flags().GetString("name")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		id, skipped, err := c.CreateCollection(ctx, orgID, name)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to create collection: %s", rpcError(err))
+		}
+
+		for _, username := range skipped {
+			fmt.Printf("Member %s has no sharing keys and won't get access to the collection\n", username)
+		}
+		fmt.Printf("Collection created with id: %d\n", id)
+	},
+}
+
+// orgCollectionListCmd represents the "org collection list" command.
+var orgCollectionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List collections you have access to",
+	Run: func(cmd *cobra.Command, args []string) {
+		orgID, _ := cmd.Flags().GetInt64("org")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		collections, err := c.ListCollections(ctx, orgID)
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to list collections: %s", rpcError(err))
+		}
+
+		for _, k := range collections {
+			fmt.Printf("%d\t%s\torganization %d\n", k.CollectionId, k.CollectionName, k.OrgId)
+		}
+	},
+}
+
+// orgSecretsCmd represents the "org secrets" command.
+var orgSecretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Get all secrets of a collection",
+	Run: func(cmd *cobra.Command, args []string) {
+		collectionID, _ := cmd.Flags().GetInt64("collection")
+
+		c := newClient()
+		defer c.Close()
+		ctx := context.Background()
+
+		collectionSecrets, err := c.CollectionSecrets(ctx, collectionID)
+		if err = skipUndecrypted(err); err != nil {
+			logging.Sugar.Fatalf("Failed to get collection secrets: %s", rpcError(err))
+		}
+
+		var secrets []DecryptedSecret
+		for _, s := range collectionSecrets {
+			secrets = append(secrets, outputSecret(s))
+		}
+
+		// Translate result to JSON and output.
+		output, err := json.MarshalIndent(secrets, "", "  ")
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to marshal secrets: %v", err)
+		}
+
+		fmt.Println("Collection secrets:")
+		fmt.Println(string(output))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(orgCmd)
+	orgCmd.AddCommand(orgCreateCmd, orgListCmd, orgMembersCmd, orgInviteCmd, orgInvitationsCmd,
+		orgAcceptCmd, orgRemoveCmd, orgRoleCmd, orgCollectionCmd, orgSecretsCmd)
+	orgCollectionCmd.AddCommand(orgCollectionCreateCmd, orgCollectionListCmd)
+
+	orgCreateCmd.Flags().String("name", "", "Organization name")
+	orgCreateCmd.MarkFlagRequired("name")
+
+	for _, c := range []*cobra.Command{orgMembersCmd, orgInviteCmd, orgRemoveCmd, orgRoleCmd, orgCollectionCreateCmd} {
+		c.Flags().Int64("org", 0, "Organization id")
+		c.MarkFlagRequired("org")
+	}
+	orgCollectionListCmd.Flags().Int64("org", 0, "Organization id, all organizations by default")
+
+	for _, c := range []*cobra.Command{orgInviteCmd, orgRemoveCmd, orgRoleCmd} {
+		c.Flags().String("user", "", "Username of the member")
+		c.MarkFlagRequired("user")
+	}
+	orgInviteCmd.Flags().String("role", "member", "Member role: owner, admin, member or read-only")
+	orgRoleCmd.Flags().String("role", "", "Member role: owner, admin, member or read-only")
+	orgRoleCmd.MarkFlagRequired("role")
+
+	orgAcceptCmd.Flags().Int64("id", 0, "Invitation id")
+	orgAcceptCmd.MarkFlagRequired("id")
+
+	orgCollectionCreateCmd.Flags().String("name", "", "Collection name")
+	orgCollectionCreateCmd.MarkFlagRequired("name")
+
+	orgSecretsCmd.Flags().Int64("collection", 0, "Collection id")
+	orgSecretsCmd.MarkFlagRequired("collection")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/pkg/otp"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// otpField is the field of the otp secret plaintext holding the otpauth URI of the key.
+const otpField = "otp"
+
+// otpData reads the key of the otp secret from the --otp flag, an otpauth URI or a raw base32 seed,
+// with the parameters set by flags, and returns the secret plaintext with the normalized URI.
+func otpData(cmd *cobra.Command) string {
+	value, _ := cmd.Flags().GetString("otp")
+	key, err := otp.Parse(value)
+	if err != nil {
+		logging.Sugar.Fatalf("Invalid OTP key: %v", err)
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("algorithm") {
+		algorithm, _ := flags.GetString("algorithm")
+		key.Algorithm = strings.ToUpper(algorithm)
+	}
+	if flags.Changed("digits") {
+		key.Digits, _ = flags.GetInt("digits")
+	}
+	if flags.Changed("period") {
+		key.Period, _ = flags.GetInt("period")
+	}
+	if flags.Changed("issuer") {
+		key.Issuer, _ = flags.GetString("issuer")
+	}
+	if flags.Changed("account") {
+		key.Account, _ = flags.GetString("account")
+	}
+	if err := key.Validate(); err != nil {
+		logging.Sugar.Fatalf("Invalid OTP key: %v", err)
+	}
+
+	return otpField + ":" + key.URI()
+}
+
+// addOTPFlags adds the flags of the otp secret key to the command.
+func addOTPFlags(cmd *cobra.Command) {
+	cmd.Flags().String("otp", "", "OTP key: otpauth://totp/ URI or base32 seed")
+	cmd.Flags().String("algorithm", otp.DefaultAlgorithm, "OTP algorithm: SHA1, SHA256 or SHA512")
+	cmd.Flags().Int("digits", otp.DefaultDigits, "Number of digits of OTP codes: 6 or 8")
+	cmd.Flags().Int("period", otp.DefaultPeriod, "Seconds an OTP code is valid for")
+	cmd.Flags().String("issuer", "", "Provider of the OTP account")
+	cmd.Flags().String("account", "", "Name of the OTP account")
+}
+
+// otpCmd represents the otp command.
+var otpCmd = &cobra.Command{
+	Use:   "otp [id]",
+	Short: "Print the current code of an otp secret",
+	Long: `Prints the current one-time code of the otp secret and the seconds it remains valid.
+The code is computed locally from the decrypted key, the server never sees the seed.
+If the server is unavailable, the key is read from the local vault.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id := args[0]
+		collectionID, _ := cmd.Flags().GetInt64("collection")
+
+		c := newClient()
+		defer c.Close()
+
+		var secrets []client.Secret
+		var err error
+		if collectionID != 0 {
+			secrets, err = c.CollectionSecrets(context.Background(), collectionID)
+			err = skipUndecrypted(err)
+		} else {
+			// All personal secrets are listed to keep the local vault fresh for codes computed offline.
+			syncedAt := time.Now()
+			secrets, err = listAllSecrets(c, client.Filter{})
+			switch {
+			case client.Offline(err):
+				secrets, err = cachedSecrets(), nil
+			case err == nil:
+				refreshVault(secrets, syncedAt)
+			}
+		}
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to get secrets: %s", rpcError(err))
+		}
+
+		var uri string
+		found := false
+		for _, s := range secrets {
+			if s.ID == id {
+				uri, found = vault.ParseFields(s.Data, s.Note)[otpField]
+				if !found {
+					logging.Sugar.Fatalf("Secret %s is not an otp secret", id)
+				}
+				break
+			}
+		}
+		if !found {
+			logging.Sugar.Fatalf("OTP secret %s is not found", id)
+		}
+
+		key, err := otp.Parse(uri)
+		if err != nil {
+			logging.Sugar.Fatalf("Invalid OTP key of secret %s: %v", id, err)
+		}
+		// Codes change on whole seconds, the remaining time is rounded up.
+		code, remaining := key.Code(time.Now().Truncate(time.Second))
+		fmt.Printf("%s (%d seconds remaining)\n", code, int(remaining.Seconds()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(otpCmd)
+
+	otpCmd.Flags().Int64("collection", 0, "Organization collection id of the secret")
+}
@@ -5,92 +5,91 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/logging"
-	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
-	"github.com/KirillZiborov/GophKeeper/proto"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/metadata"
 )
 
 // DecryptedSecret is a structure for outputing user's saved secrets.
 type DecryptedSecret struct {
-	Id   int64  `json:"id"`
-	Data string `json:"data"`
-	Meta string `json:"meta"`
+	Id        string     `json:"id"`
+	Data      string     `json:"data"`
+	Meta      string     `json:"meta"`
+	Folder    string     `json:"folder,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// localTime returns the time in the local time zone for output, nil if it is not set.
+func localTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.Local()
+	return &local
+}
+
+// outputSecret converts the decrypted secret for output.
+func outputSecret(s client.Secret) DecryptedSecret {
+	secret := newDecryptedSecret(s.ID, s.Data, s.Note)
+	secret.ExpiresAt = localTime(s.ExpiresAt)
+	return secret
 }
 
 // secretAllCmd represents the "secret all" command.
 var secretAllCmd = &cobra.Command{
 	Use:   "all",
 	Short: "Get all secrets for the authenticated user",
-	Long:  "Retrieves and displays a list of all secret data belonging to the authenticated user.",
+	Long: `Retrieves and displays a list of all secret data belonging to the authenticated user.
+Secrets can be filtered by folder (including subfolders) and tags and displayed as a folder tree.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Read token from file (token.txt).
-		tokenBytes, err := os.ReadFile("token.txt")
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to read token file: %v", err)
-		}
-		token := strings.TrimSpace(string(tokenBytes))
-		if token == "" {
-			logging.Sugar.Fatal("Token is empty; please login first")
-		}
-
-		conn, err := grpc.NewClient(
-			viper.GetString("grpc_address"),
-			grpc.WithTransportCredentials(insecure.NewCredentials()))
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to connect gRPC server: %v", err)
+		var filter client.Filter
+		filter.Type, _ = cmd.Flags().GetString("type")
+		if updatedAfter, _ := cmd.Flags().GetString("updated-after"); updatedAfter != "" {
+			t, err := time.Parse(time.RFC3339, updatedAfter)
+			if err != nil {
+				logging.Sugar.Fatalf("Invalid --updated-after time, expected RFC3339: %v", err)
+			}
+			filter.UpdatedAfter = t
 		}
-		defer conn.Close()
-
-		client := proto.NewKeeperClient(conn)
 
-		// Create context with token in metadata.
-		md := metadata.Pairs("token", token)
-		ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), 5*time.Second)
-		defer cancel()
+		// Only the whole vault replaces its local copy.
+		unfiltered := filter == client.Filter{}
 
-		req := &proto.GetSecretRequest{}
+		c := newClient()
+		defer c.Close()
 
-		resp, err := client.GetSecret(ctx, req)
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to get secrets: %v", err)
+		// Print secrets one by one as they arrive instead of collecting all pages.
+		if stream, _ := cmd.Flags().GetBool("stream"); stream {
+			streamSecrets(cmd, c, filter, unfiltered)
+			return
 		}
 
-		// Read encryption key from config.
-		encryptionKey := viper.GetString("encryption_key")
-		if encryptionKey == "" {
-			logging.Sugar.Fatal("Encryption key (encryption_key) is not set in configuration")
+		syncedAt := time.Now()
+		decrypted, err := listAllSecrets(c, filter)
+		switch {
+		case client.Offline(err):
+			decrypted = filterCached(filter)
+		case err != nil:
+			logging.Sugar.Fatalf("Failed to get secrets: %s", rpcError(err))
+		case unfiltered:
+			refreshVault(decrypted, syncedAt)
 		}
 
-		var secrets []DecryptedSecret
+		secrets := make([]DecryptedSecret, 0, len(decrypted))
+		for _, s := range decrypted {
+			secrets = append(secrets, outputSecret(s))
+		}
 
-		for _, cred := range resp.Secret {
-			encryptedData := cred.Secret.Data
-			encryptedMeta := cred.Secret.Meta
-			data, err := encryption.DecryptWithKey(encryptedData, encryptionKey)
-			if err != nil {
-				logging.Sugar.Errorf("Failed to decrypt secret (id: %d): %v", cred.Id, err)
-				continue
-			}
-			meta, err := encryption.DecryptWithKey(encryptedMeta, encryptionKey)
-			if err != nil {
-				logging.Sugar.Errorf("Failed to decrypt secret (id: %d): %v", cred.Id, err)
-				continue
-			}
+		// Filter secrets after decryption so the server learns nothing about folders and tags.
+		folder, tags := organizeFlags(cmd)
+		secrets = filterSecrets(secrets, folder, tags)
 
-			secret := DecryptedSecret{
-				Id:   cred.Id,
-				Data: data,
-				Meta: meta,
-			}
-			secrets = append(secrets, secret)
+		if tree, _ := cmd.Flags().GetBool("tree"); tree {
+			printTree(secrets)
+			return
 		}
 
 		// Translate result to JSON and output.
@@ -104,6 +103,69 @@ var secretAllCmd = &cobra.Command{
 	},
 }
 
+// streamSecrets filters and prints every streamed secret as a JSON line as soon as it arrives.
+// Streamed secrets of the whole vault replace its local copy, cached secrets are printed if the server is unavailable.
+func streamSecrets(cmd *cobra.Command, c *client.Client, filter client.Filter, unfiltered bool) {
+	folder, tags := organizeFlags(cmd)
+	encoder := json.NewEncoder(os.Stdout)
+	printSecret := func(s client.Secret) error {
+		secret := outputSecret(s)
+		if len(filterSecrets([]DecryptedSecret{secret}, folder, tags)) == 0 {
+			return nil
+		}
+		return encoder.Encode(secret)
+	}
+
+	syncedAt := time.Now()
+	var streamed []client.Secret
+	received := false
+	err := c.StreamSecrets(context.Background(), filter, func(s client.Secret) error {
+		received = true
+		if unfiltered {
+			streamed = append(streamed, s)
+		}
+		return printSecret(s)
+	})
+	err = skipUndecrypted(err)
+
+	// Streams fail on the first receive if the server can't be reached.
+	if client.Offline(err) && !received {
+		for _, s := range filterCached(filter) {
+			if err := printSecret(s); err != nil {
+				logging.Sugar.Fatalf("Failed to marshal secret: %v", err)
+			}
+		}
+		return
+	}
+	if err != nil {
+		logging.Sugar.Fatalf("Failed to get secrets: %s", rpcError(err))
+	}
+	if unfiltered {
+		refreshVault(streamed, syncedAt)
+	}
+}
+
+// filterCached returns cached secrets matching the filter the server applies otherwise.
+func filterCached(filter client.Filter) []client.Secret {
+	cached := cachedSecrets()
+	filtered := make([]client.Secret, 0, len(cached))
+	for _, s := range cached {
+		if filter.Match(s) {
+			filtered = append(filtered, s)
+		}
+	}
+
+	return filtered
+}
+
 func init() {
 	secretCmd.AddCommand(secretAllCmd)
+
+	secretAllCmd.Flags().String("folder", "", "Show only secrets of the folder and its subfolders")
+	secretAllCmd.Flags().StringSlice("tag", nil, "Show only secrets with the tag, can be repeated")
+	secretAllCmd.Flags().Bool("tree", false, "Display secrets as a folder tree")
+	secretAllCmd.Flags().String("type", "", "Show only secrets of the type: card, credentials, text, bin or otp")
+	secretAllCmd.Flags().Bool("stream", false, "Print secrets as JSON lines while they are streamed from the server")
+	secretAllCmd.MarkFlagsMutuallyExclusive("stream", "tree")
+	secretAllCmd.Flags().String("updated-after", "", "Show only secrets updated after the time in RFC3339, e.g. 2025-01-01T00:00:00Z")
 }
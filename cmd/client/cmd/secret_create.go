@@ -4,17 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/logging"
-	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
-	"github.com/KirillZiborov/GophKeeper/proto"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/metadata"
 )
 
 var secretCmd = &cobra.Command{
@@ -32,9 +28,14 @@ var secretCreateCmd = &cobra.Command{
 	Short: "Create a new secret",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		secretType := args[0] // secret type: card, credentials, text, bin.
+		secretType := args[0] // secret type: card, credentials, text, bin, otp.
 		note, _ := cmd.Flags().GetString("note")
 
+		generate, _ := cmd.Flags().GetBool("generate")
+		if generate && secretType != "credentials" {
+			logging.Sugar.Fatal("Password can be generated only for credentials")
+		}
+
 		var rawData string
 		switch secretType {
 		case "card":
@@ -46,10 +47,15 @@ var secretCreateCmd = &cobra.Command{
 		case "credentials":
 			login, _ := cmd.Flags().GetString("login")
 			password, _ := cmd.Flags().GetString("password")
+			if generate {
+				password = generatePassword(cmd)
+			}
 			rawData = fmt.Sprintf("login:%s;password:%s", login, password)
 		case "text":
 			data, _ := cmd.Flags().GetString("text")
 			rawData = fmt.Sprintf("text:%s", data)
+		case "otp":
+			rawData = otpData(cmd)
 		case "bin":
 			filePath, _ := cmd.Flags().GetString("file")
 			content, err := os.ReadFile(filePath)
@@ -60,64 +66,38 @@ var secretCreateCmd = &cobra.Command{
 		default:
 			logging.Sugar.Fatalf("Unknown secret type: %s", secretType)
 		}
-
-		// Read encryption key from config.
-		encryptionKey := viper.GetString("encryption_key")
-		if encryptionKey == "" {
-			logging.Sugar.Fatal("Encryption key is not set in configuration")
+		folder, tags := organizeFlags(cmd)
+		rawData = withFolderAndTags(rawData, folder, tags)
+
+		// The id is generated here so that the secret can be referred to before the server answers.
+		// Collection secrets are encrypted with the collection key.
+		secret := client.Secret{
+			ID:   uuid.New().String(),
+			Type: secretType,
+			Data: rawData,
+			Note: note,
 		}
-		// Encrypt data using encryptionKey.
-		encryptedData, err := encryption.EncryptWithKey(rawData, encryptionKey)
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to encrypt data: %v", err)
+		secret.CollectionID, _ = cmd.Flags().GetInt64("collection")
+		if expiresIn, _ := cmd.Flags().GetDuration("expires-in"); expiresIn > 0 {
+			expiresAt := time.Now().Add(expiresIn)
+			secret.ExpiresAt = &expiresAt
 		}
 
-		// Encrypt metadata using encryptionKey.
-		encryptedMeta, err := encryption.EncryptWithKey(note, encryptionKey)
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to encrypt metadata: %v", err)
-		}
+		c := newClient()
+		defer c.Close()
 
-		// Read token from file (token.txt).
-		tokenBytes, err := os.ReadFile("token.txt")
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to read token file: %v", err)
-		}
-		token := strings.TrimSpace(string(tokenBytes))
-		if token == "" {
-			logging.Sugar.Fatal("Please login first: no token")
+		id, err := c.CreateSecret(context.Background(), secret)
+		// Personal secrets created offline are sent later by the sync command.
+		if client.Offline(err) && secret.CollectionID == 0 {
+			queueChange(vault.Change{Op: vault.OpCreate, Secret: vaultSecret(secret)})
+			fmt.Printf("The server is unavailable, secret %s is saved to the local vault, run \"sync\" to send it\n", secret.ID)
+			return
 		}
-
-		conn, err := grpc.NewClient(
-			viper.GetString("grpc_address"),
-			grpc.WithTransportCredentials(insecure.NewCredentials()))
 		if err != nil {
-			logging.Sugar.Fatalf("Failed to connect gRPC server: %v", err)
+			logging.Sugar.Fatalf("Failed to add secret: %s", rpcError(err))
 		}
-		defer conn.Close()
-
-		client := proto.NewKeeperClient(conn)
 
-		secretData := &proto.Secret{
-			Data: encryptedData,
-			Meta: encryptedMeta,
-		}
-
-		req := &proto.AddSecretRequest{
-			Secret: secretData,
-		}
-
-		// Create context with token in metadata.
-		md := metadata.Pairs("token", token)
-		ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), 5*time.Second)
-		defer cancel()
-
-		resp, err := client.AddSecret(ctx, req)
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to add secret: %v", err)
-		}
-
-		fmt.Printf("Secret created with id: %d\n", resp.Id)
+		fmt.Printf("Secret created with id: %s\n", id)
 	},
 }
 
@@ -127,6 +107,9 @@ func init() {
 
 	// Flags for all types.
 	secretCreateCmd.Flags().StringP("note", "n", "", "Optional note for the secret")
+	secretCreateCmd.Flags().Int64("collection", 0, "Organization collection id of the secret")
+	addOrganizeFlags(secretCreateCmd)
+	secretCreateCmd.Flags().Duration("expires-in", 0, "Delete the secret after the period, e.g. 24h")
 
 	// Type card.
 	secretCreateCmd.Flags().String("number", "", "Card number")
@@ -137,10 +120,18 @@ func init() {
 	// Type credentials.
 	secretCreateCmd.Flags().String("login", "", "Login for credentials")
 	secretCreateCmd.Flags().String("password", "", "Password for credentials")
+	secretCreateCmd.Flags().Bool("generate", false, "Generate the password of credentials")
+	secretCreateCmd.Flags().String("policy", "", "Name of the password policy of the config used with --generate")
 
 	// Type text.
 	secretCreateCmd.Flags().String("text", "", "Text data")
 
 	// Type bin.
 	secretCreateCmd.Flags().StringP("file", "f", "", "File path for binary data")
+
+	// Type otp.
+	addOTPFlags(secretCreateCmd)
+
+	// Marking flags merges persistent flags of the root command, so it must follow the flag definitions.
+	secretCreateCmd.MarkFlagsMutuallyExclusive("password", "generate")
 }
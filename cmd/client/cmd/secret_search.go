@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// secretLogin returns login of the credentials plaintext, empty for other secret types.
+func secretLogin(data string) string {
+	login, _, _ := cutField(data, "login:")
+	return login
+}
+
+// secretSearchCmd represents the "secret search" command.
+var secretSearchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search secrets by login, folder and tags",
+	Long: `Finds secrets having all the given login, folder and tags.
+The server matches keyed blind index tokens and never sees the searched values.
+Only secrets created or updated with blind indexes can be found.
+Search by site is not supported: credentials have no site field, only login, folder and tags are indexed.
+If the server is unavailable, secrets of the local vault are searched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		login, _ := cmd.Flags().GetString("login")
+		folder, tags := organizeFlags(cmd)
+		if login == "" && folder == "" && len(tags) == 0 {
+			logging.Sugar.Fatal("At least one of --login, --folder or --tag must be provided")
+		}
+
+		c := newClient()
+		defer c.Close()
+
+		// Without the server cached secrets are matched locally.
+		found, err := c.SearchSecrets(context.Background(), login, folder, tags)
+		cached := client.Offline(err)
+		if cached {
+			found, err = cachedSecrets(), nil
+		}
+		if err = skipUndecrypted(err); err != nil {
+			logging.Sugar.Fatalf("Failed to search secrets: %s", rpcError(err))
+		}
+
+		secrets := make([]DecryptedSecret, 0, len(found))
+		for _, s := range found {
+			secret := outputSecret(s)
+			if cached && (login != "" && secretLogin(secret.Data) != login || len(filterSecrets([]DecryptedSecret{secret}, folder, tags)) == 0) {
+				continue
+			}
+			secrets = append(secrets, secret)
+		}
+
+		// Translate result to JSON and output.
+		output, err := json.MarshalIndent(secrets, "", "  ")
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to marshal secrets: %v", err)
+		}
+
+		fmt.Println("Found secrets:")
+		fmt.Println(string(output))
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretSearchCmd)
+
+	secretSearchCmd.Flags().String("login", "", "Login of credentials")
+	secretSearchCmd.Flags().String("folder", "", "Folder of secrets, including its subfolders")
+	secretSearchCmd.Flags().StringSlice("tag", nil, "Tag of secrets, can be repeated")
+}
@@ -4,18 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/logging"
-	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
-	"github.com/KirillZiborov/GophKeeper/proto"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/metadata"
 )
 
 // secretUpdateCmd represents the "secret update" command.
@@ -24,7 +18,7 @@ var secretUpdateCmd = &cobra.Command{
 	Short: "Update an existing secret",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		secretType := args[0] // card, credentials, text, bin
+		secretType := args[0] // card, credentials, text, bin, otp
 
 		// Need secret id for update.
 		secretID, err := cmd.Flags().GetString("id")
@@ -34,6 +28,11 @@ var secretUpdateCmd = &cobra.Command{
 
 		note, _ := cmd.Flags().GetString("note")
 
+		generate, _ := cmd.Flags().GetBool("generate")
+		if generate && secretType != "credentials" {
+			logging.Sugar.Fatal("Password can be generated only for credentials")
+		}
+
 		var rawData string
 		switch secretType {
 		case "card":
@@ -45,10 +44,15 @@ var secretUpdateCmd = &cobra.Command{
 		case "credentials":
 			login, _ := cmd.Flags().GetString("login")
 			pass, _ := cmd.Flags().GetString("password")
+			if generate {
+				pass = generatePassword(cmd)
+			}
 			rawData = fmt.Sprintf("login:%s;password:%s", login, pass)
 		case "text":
 			data, _ := cmd.Flags().GetString("text")
 			rawData = fmt.Sprintf("text:%s", data)
+		case "otp":
+			rawData = otpData(cmd)
 		case "bin":
 			filePath, _ := cmd.Flags().GetString("file")
 			content, err := os.ReadFile(filePath)
@@ -59,67 +63,39 @@ var secretUpdateCmd = &cobra.Command{
 		default:
 			logging.Sugar.Fatalf("Unknown secret type: %s", secretType)
 		}
-
-		// Read encryption key from config.
-		encryptionKey := viper.GetString("encryption_key")
-		if encryptionKey == "" {
-			logging.Sugar.Fatal("Encryption key (encryption_key) is not set in configuration")
+		folder, tags := organizeFlags(cmd)
+		rawData = withFolderAndTags(rawData, folder, tags)
+
+		// Collection secrets are encrypted with the collection key.
+		secret := client.Secret{
+			ID:   secretID,
+			Type: secretType,
+			Data: rawData,
+			Note: note,
 		}
-		// Encrypt data using encryptionKey.
-		encryptedData, err := encryption.EncryptWithKey(rawData, encryptionKey)
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to encrypt data: %v", err)
+		secret.CollectionID, _ = cmd.Flags().GetInt64("collection")
+		// Without expiry flags the server keeps the current expiration time.
+		if expiresIn, _ := cmd.Flags().GetDuration("expires-in"); expiresIn > 0 {
+			expiresAt := time.Now().Add(expiresIn)
+			secret.ExpiresAt = &expiresAt
 		}
+		clearExpiresAt, _ := cmd.Flags().GetBool("no-expiry")
 
-		// Encrypt metadata using encryptionKey.
-		encryptedMeta, err := encryption.EncryptWithKey(note, encryptionKey)
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to encrypt metadata: %v", err)
-		}
+		c := newClient()
+		defer c.Close()
 
-		// Read token from file (token.txt).
-		tokenBytes, err := os.ReadFile("token.txt")
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to read token file: %v", err)
-		}
-		token := strings.TrimSpace(string(tokenBytes))
-		if token == "" {
-			logging.Sugar.Fatal("Please login first: no token")
+		err = c.UpdateSecret(context.Background(), secret, clearExpiresAt)
+		// Personal secrets updated offline are sent later by the sync command.
+		if client.Offline(err) && secret.CollectionID == 0 {
+			queueChange(vault.Change{Op: vault.OpUpdate, Secret: vaultSecret(secret), ClearExpiresAt: clearExpiresAt})
+			fmt.Printf("The server is unavailable, update of secret %s is saved to the local vault, run \"sync\" to send it\n", secretID)
+			return
 		}
-
-		conn, err := grpc.NewClient(
-			viper.GetString("grpc_address"),
-			grpc.WithTransportCredentials(insecure.NewCredentials()))
 		if err != nil {
-			logging.Sugar.Fatalf("Failed to connect gRPC server: %v", err)
+			logging.Sugar.Fatalf("Failed to update secret: %s", rpcError(err))
 		}
-		defer conn.Close()
-
-		client := proto.NewKeeperClient(conn)
 
-		id, err := strconv.ParseInt(secretID, 10, 64)
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to parse id: %v", err)
-		}
-		req := &proto.EditSecretRequest{
-			Id: id,
-			Secret: &proto.Secret{
-				Data: encryptedData,
-				Meta: encryptedMeta,
-			},
-		}
-
-		// Create context with token in metadata.
-		md := metadata.Pairs("token", token)
-		ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), 5*time.Second)
-		defer cancel()
-
-		_, err = client.EditSecret(ctx, req)
-		if err != nil {
-			logging.Sugar.Fatalf("Failed to update secret: %v", err)
-		}
-
-		fmt.Printf("Secret updated successfully (id: %d)\n", id)
+		fmt.Printf("Secret updated successfully (id: %s)\n", secretID)
 	},
 }
 
@@ -127,10 +103,14 @@ func init() {
 	secretCmd.AddCommand(secretUpdateCmd)
 
 	// Flags for all types.
-	secretUpdateCmd.Flags().StringP("id", "i", "", "Secret identifier (id) to update")
+	secretUpdateCmd.Flags().StringP("id", "i", "", "Secret identifier (id) to update, a UUID or a legacy integer id")
 	secretUpdateCmd.MarkFlagRequired("id")
+	secretUpdateCmd.Flags().Duration("expires-in", 0, "Delete the secret after the period from now, e.g. 24h")
+	secretUpdateCmd.Flags().Bool("no-expiry", false, "Remove the expiration time of the secret")
 
 	secretUpdateCmd.Flags().StringP("note", "n", "", "Optional note for the secret")
+	secretUpdateCmd.Flags().Int64("collection", 0, "Organization collection id of the secret")
+	addOrganizeFlags(secretUpdateCmd)
 
 	// Type card.
 	secretUpdateCmd.Flags().String("number", "", "Card number")
@@ -141,10 +121,19 @@ func init() {
 	// Type credentials.
 	secretUpdateCmd.Flags().String("login", "", "Login for credentials")
 	secretUpdateCmd.Flags().String("password", "", "Password for credentials")
+	secretUpdateCmd.Flags().Bool("generate", false, "Generate the password of credentials")
+	secretUpdateCmd.Flags().String("policy", "", "Name of the password policy of the config used with --generate")
 
 	// Type text.
 	secretUpdateCmd.Flags().String("text", "", "Text data")
 
 	// Type bin.
 	secretUpdateCmd.Flags().StringP("file", "f", "", "File path for binary data")
+
+	// Type otp.
+	addOTPFlags(secretUpdateCmd)
+
+	// Marking flags merges persistent flags of the root command, so it must follow the flag definitions.
+	secretUpdateCmd.MarkFlagsMutuallyExclusive("expires-in", "no-expiry")
+	secretUpdateCmd.MarkFlagsMutuallyExclusive("password", "generate")
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var sendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Share one-time secrets by link",
+	Long: `Create ephemeral encrypted sends for people without an account and open them.
+The payload is encrypted with a random key which is kept in the link fragment and never sent to the server.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// sendCreateCmd represents the "send create" command.
+var sendCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a send and print its link",
+	Run: func(cmd *cobra.Command, args []string) {
+		text, _ := cmd.Flags().GetString("text")
+		filePath, _ := cmd.Flags().GetString("file")
+		views, _ := cmd.Flags().GetInt32("views")
+		expiresIn, _ := cmd.Flags().GetDuration("expires-in")
+
+		payload := text
+		if filePath != "" {
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				logging.Sugar.Fatalf("Failed to read file: %v", err)
+			}
+			payload = string(content)
+		}
+		if payload == "" {
+			logging.Sugar.Fatal("Payload (--text or --file) must be provided")
+		}
+
+		c := newClient()
+		defer c.Close()
+
+		// The payload is encrypted with a random key which never leaves the client.
+		link, err := c.CreateSend(context.Background(), payload, views, time.Now().Add(expiresIn))
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to create send: %s", rpcError(err))
+		}
+		fmt.Println(link)
+	},
+}
+
+// sendOpenCmd represents the "send open" command.
+var sendOpenCmd = &cobra.Command{
+	Use:   "open [link]",
+	Short: "Open a send by its link",
+	Long:  "Retrieves and decrypts the send. Every opening burns one view of the send.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Opening doesn't require an account, the client connects to the server from the link.
+		payload, viewsLeft, err := client.OpenSend(context.Background(), args[0], client.WithUserAgent(userAgent))
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to open send: %s", rpcError(err))
+		}
+
+		if out, _ := cmd.Flags().GetString("out"); out != "" {
+			if err := os.WriteFile(out, []byte(payload), 0600); err != nil {
+				logging.Sugar.Fatalf("Failed to write file: %v", err)
+			}
+		} else {
+			fmt.Println(payload)
+		}
+		fmt.Fprintf(os.Stderr, "Views left: %d\n", viewsLeft)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sendCmd)
+	sendCmd.AddCommand(sendCreateCmd)
+	sendCmd.AddCommand(sendOpenCmd)
+
+	sendCreateCmd.Flags().String("text", "", "Text to send")
+	sendCreateCmd.Flags().String("file", "", "Path to a file to send")
+	sendCreateCmd.Flags().Int32("views", 1, "Maximum number of views")
+	sendCreateCmd.Flags().Duration("expires-in", 24*time.Hour, "Delete the send after the period, e.g. 1h")
+
+	sendOpenCmd.Flags().String("out", "", "Write the payload to the file instead of stdout")
+}
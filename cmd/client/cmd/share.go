@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/spf13/cobra"
+)
+
+// DecryptedShare is a structure for outputing secrets shared with user.
+type DecryptedShare struct {
+	SecretID string `json:"secret_id"`
+	Owner    string `json:"owner"`
+	Data     string `json:"data"`
+	Meta     string `json:"meta"`
+}
+
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Share secrets with other users",
+	Long:  "Share your secrets read-only with other users, list secrets shared with you and revoke shares.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// shareCreateCmd represents the "share create" command.
+var shareCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Share a secret with another user",
+	Long: `Re-encrypts the secret with a random data key and wraps the data key with recipient's public key.
+Sharing the secret again updates the recipient's copy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		secretID, _ := cmd.Flags().GetString("id")
+		recipient, _ := cmd.Flags().GetString("to")
+		if recipient == "" {
+			logging.Sugar.Fatal("Recipient (--to) must be provided")
+		}
+
+		c := newClient()
+		defer c.Close()
+
+		if err := c.ShareSecret(context.Background(), secretID, recipient); err != nil {
+			logging.Sugar.Fatalf("Failed to share secret: %s", rpcError(err))
+		}
+
+		fmt.Printf("Secret %s shared with %s\n", secretID, recipient)
+	},
+}
+
+// shareListCmd represents the "share list" command.
+var shareListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List secrets shared with you",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := newClient()
+		defer c.Close()
+
+		received, err := c.ListSharedWithMe(context.Background())
+		if err = skipUndecrypted(err); err != nil {
+			logging.Sugar.Fatalf("Failed to list shared secrets: %s", rpcError(err))
+		}
+
+		shares := make([]DecryptedShare, 0, len(received))
+		for _, sh := range received {
+			// Folders and tags organize the owner's vault only.
+			data, _, _ := splitFolderAndTags(sh.Data)
+			shares = append(shares, DecryptedShare{
+				SecretID: sh.SecretID,
+				Owner:    sh.Owner,
+				Data:     data,
+				Meta:     sh.Note,
+			})
+		}
+
+		// Translate result to JSON and output.
+		output, err := json.MarshalIndent(shares, "", "  ")
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to marshal shared secrets: %v", err)
+		}
+
+		fmt.Println("Secrets shared with you:")
+		fmt.Println(string(output))
+	},
+}
+
+// shareRevokeCmd represents the "share revoke" command.
+var shareRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke access to a shared secret",
+	Run: func(cmd *cobra.Command, args []string) {
+		secretID, _ := cmd.Flags().GetString("id")
+		recipient, _ := cmd.Flags().GetString("to")
+		if recipient == "" {
+			logging.Sugar.Fatal("Recipient (--to) must be provided")
+		}
+
+		c := newClient()
+		defer c.Close()
+
+		if err := c.RevokeShare(context.Background(), secretID, recipient); err != nil {
+			logging.Sugar.Fatalf("Failed to revoke share: %s", rpcError(err))
+		}
+
+		fmt.Printf("Access of %s to secret %s revoked\n", recipient, secretID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shareCmd)
+	shareCmd.AddCommand(shareCreateCmd)
+	shareCmd.AddCommand(shareListCmd)
+	shareCmd.AddCommand(shareRevokeCmd)
+
+	for _, c := range []*cobra.Command{shareCreateCmd, shareRevokeCmd} {
+		c.Flags().StringP("id", "i", "", "Secret identifier (id) to share")
+		c.MarkFlagRequired("id")
+		c.Flags().String("to", "", "Username of the recipient")
+		c.MarkFlagRequired("to")
+	}
+}
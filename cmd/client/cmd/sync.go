@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// vaultFile is the name of the local vault file in the client config directory.
+const vaultFile = ".gophkeeper.vault"
+
+// syncBatchSize is the maximum number of queued changes sent in one batch, limited by the server.
+const syncBatchSize = 1000
+
+// vaultPath returns path of the local vault file: vault_path from the configuration
+// or the file next to the config file, in the home directory if there is no config file.
+func vaultPath() string {
+	if path := viper.GetString("vault_path"); path != "" {
+		return path
+	}
+	if cfg := viper.ConfigFileUsed(); cfg != "" {
+		return filepath.Join(filepath.Dir(cfg), vaultFile)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		logging.Sugar.Fatalf("Failed to find home directory: %v", err)
+	}
+
+	return filepath.Join(home, vaultFile)
+}
+
+// openVault opens the local vault encrypted with the encryption key.
+func openVault(key string) *vault.Vault {
+	v, err := vault.Open(vaultPath(), key)
+	if err != nil {
+		logging.Sugar.Fatalf("Failed to open local vault: %v", err)
+	}
+
+	return v
+}
+
+// vaultSecret converts a decrypted secret to the cached one.
+func vaultSecret(secret client.Secret) vault.Secret {
+	return vault.Secret{
+		ID:        secret.ID,
+		Data:      secret.Data,
+		Meta:      secret.Note,
+		Type:      secret.Type,
+		ExpiresAt: secret.ExpiresAt,
+		UpdatedAt: secret.UpdatedAt,
+	}
+}
+
+// clientSecret converts a cached secret to the decrypted one.
+func clientSecret(secret vault.Secret) client.Secret {
+	return client.Secret{
+		ID:        secret.ID,
+		Type:      secret.Type,
+		Data:      secret.Data,
+		Note:      secret.Meta,
+		ExpiresAt: secret.ExpiresAt,
+		UpdatedAt: secret.UpdatedAt,
+	}
+}
+
+// errNoCache is returned when the local vault has never been synced with the server.
+var errNoCache = errors.New("the server is unavailable and there is no local copy of the vault yet")
+
+// readCache returns secrets of the local vault with the time of the last sync.
+func readCache() ([]client.Secret, time.Time, error) {
+	v, err := vault.Open(vaultPath(), encryptionKey())
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if v.SyncedAt().IsZero() && len(v.Outbox()) == 0 {
+		return nil, time.Time{}, errNoCache
+	}
+
+	var secrets []client.Secret
+	for _, s := range v.Secrets() {
+		secrets = append(secrets, clientSecret(s))
+	}
+
+	return secrets, v.SyncedAt(), nil
+}
+
+// cachedSecrets returns secrets of the local vault when the server is unavailable.
+func cachedSecrets() []client.Secret {
+	secrets, syncedAt, err := readCache()
+	if errors.Is(err, errNoCache) {
+		logging.Sugar.Fatal("The server is unavailable and there is no local copy of the vault yet")
+	}
+	if err != nil {
+		logging.Sugar.Fatalf("Failed to open local vault: %v", err)
+	}
+	logging.Sugar.Warnf("The server is unavailable, showing secrets cached at %s", syncedAt.Local().Format(time.RFC3339))
+
+	return secrets
+}
+
+// saveCache replaces the local copy of the vault with all secrets received from the server.
+func saveCache(secrets []client.Secret, syncedAt time.Time) error {
+	v, err := vault.Open(vaultPath(), encryptionKey())
+	if err != nil {
+		return err
+	}
+
+	cached := make([]vault.Secret, 0, len(secrets))
+	for _, s := range secrets {
+		cached = append(cached, vaultSecret(s))
+	}
+	v.Refresh(cached, syncedAt)
+
+	return v.Save()
+}
+
+// refreshVault replaces the local copy of the vault with all secrets received from the server.
+// Failure to save the copy doesn't fail the command.
+func refreshVault(secrets []client.Secret, syncedAt time.Time) {
+	if err := saveCache(secrets, syncedAt); err != nil {
+		logging.Sugar.Warnf("Failed to update local vault: %v", err)
+	}
+}
+
+// enqueue saves a change made while the server is unavailable to the outbox of the local vault.
+func enqueue(change vault.Change) error {
+	change.QueuedAt = time.Now()
+	change.Secret.UpdatedAt = change.QueuedAt
+
+	v, err := vault.Open(vaultPath(), encryptionKey())
+	if err != nil {
+		return err
+	}
+	v.Enqueue(change)
+
+	return v.Save()
+}
+
+// queueChange saves a change made while the server is unavailable to the outbox of the local vault.
+func queueChange(change vault.Change) {
+	if err := enqueue(change); err != nil {
+		logging.Sugar.Fatalf("Failed to save change to local vault: %v", err)
+	}
+}
+
+// listAllSecrets returns all personal secrets, the ones failed to decrypt are logged and skipped.
+func listAllSecrets(c *client.Client, f client.Filter) ([]client.Secret, error) {
+	secrets, err := c.ListSecrets(context.Background(), f)
+	return secrets, skipUndecrypted(err)
+}
+
+// mutation converts a queued change to the batch mutation.
+func mutation(change vault.Change) client.Mutation {
+	m := client.Mutation{
+		Op:             client.MutationCreate,
+		Secret:         clientSecret(change.Secret),
+		ClearExpiresAt: change.ClearExpiresAt,
+	}
+	if change.Op == vault.OpUpdate {
+		m.Op = client.MutationUpdate
+	}
+
+	return m
+}
+
+// rejected reports whether the server rejected the mutation and logs the reason.
+// A create failed because the secret exists was applied by an earlier attempt which response was lost.
+func rejected(m client.Mutation, err error) bool {
+	if err == nil || status.Code(err) == codes.AlreadyExists && m.Op == client.MutationCreate {
+		return false
+	}
+	op := vault.OpUpdate
+	if m.Op == client.MutationCreate {
+		op = vault.OpCreate
+	}
+	logging.Sugar.Errorf("Server rejected %s of secret %s: %s", op, m.Secret.ID, status.Convert(err).Message())
+
+	return true
+}
+
+// syncCmd represents the "sync" command.
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Send changes made offline and refresh the local vault",
+	Long: `Sends secrets created and updated while the server was unavailable in the order they were made,
+then replaces the local copy of the vault with the secrets stored on the server.
+Updates of secrets changed on the server since the last sync are merged field by field,
+fields changed on both sides are kept in a copy of the secret until the conflict is resolved.
+The merged update and the copy are saved together or not at all.
+Changes rejected by the server are reported and kept in the outbox to be sent again by the next sync.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		v := openVault(encryptionKey())
+
+		c := newClient()
+		defer c.Close()
+
+		// Updated secrets changed on the server since their last synced versions are merged with the server versions.
+		remote := make(map[string]vault.Secret)
+		if slices.ContainsFunc(v.Outbox(), func(c vault.Change) bool { return c.Base != nil }) {
+			secrets, err := listAllSecrets(c, client.Filter{})
+			if err != nil {
+				logging.Sugar.Fatalf("Failed to get secrets: %s", rpcError(err))
+			}
+			for _, s := range secrets {
+				remote[s.ID] = vaultSecret(s)
+			}
+		}
+
+		// Changes rejected by the server stay at the head of the outbox, every batch starts after them.
+		sent, kept := 0, 0
+		for kept < len(v.Outbox()) {
+			offset := kept
+			queued := v.Outbox()[offset:]
+			batch := queued[:min(syncBatchSize, len(queued))]
+
+			// Changes without conflicts are applied independently in one best-effort batch.
+			// A merged update and the copy of its conflicting fields are sent in an atomic batch of their own,
+			// so that neither is saved without the other.
+			var mutations []client.Mutation
+			var changes []int
+			pairs := make(map[int][]client.Mutation)
+			conflicts := make(map[int]vault.Conflict)
+			for i, change := range batch {
+				if server, ok := remote[change.Secret.ID]; ok && change.Base != nil && !server.UpdatedAt.Equal(change.Base.UpdatedAt) {
+					update, conflictCopy, fields := mergeChange(change, server)
+					if conflictCopy != nil {
+						pairs[i] = []client.Mutation{mutation(update), mutation(*conflictCopy)}
+						conflicts[i] = vault.Conflict{SecretID: update.Secret.ID, CopyID: conflictCopy.Secret.ID, Fields: fields}
+						continue
+					}
+					change = update
+				}
+				mutations = append(mutations, mutation(change))
+				changes = append(changes, i)
+			}
+
+			failed := make(map[int]bool)
+			if len(mutations) > 0 {
+				results, _, err := c.MutateSecrets(context.Background(), mutations, true)
+				if err != nil {
+					logging.Sugar.Fatalf("Failed to send changes, %d left in the outbox: %s", len(v.Outbox()), rpcError(err))
+				}
+				for j, result := range results {
+					if rejected(mutations[j], result.Err) {
+						failed[changes[j]] = true
+					}
+				}
+			}
+
+			var applied []int
+			for i := range batch {
+				if failed[i] {
+					kept++
+					continue
+				}
+				if pair, ok := pairs[i]; ok {
+					results, committed, err := c.MutateSecrets(context.Background(), pair, false)
+					if err != nil {
+						// Changes applied before the failure are removed from the outbox.
+						v.Dequeue(applied)
+						if saveErr := v.Save(); saveErr != nil {
+							logging.Sugar.Errorf("Failed to save local vault: %v", saveErr)
+						}
+						logging.Sugar.Fatalf("Failed to send changes, %d left in the outbox: %s", len(v.Outbox()), rpcError(err))
+					}
+					if !committed {
+						for j, result := range results {
+							rejected(pair[j], result.Err)
+						}
+						kept++
+						continue
+					}
+
+					conflict := conflicts[i]
+					conflict.DetectedAt = time.Now()
+					v.AddConflict(conflict)
+					logging.Sugar.Warnf("Secret %s was changed on the server, local values of %s are kept in secret %s",
+						conflict.SecretID, strings.Join(conflict.Fields, ", "), conflict.CopyID)
+				}
+				applied = append(applied, offset+i)
+				sent++
+			}
+
+			v.Dequeue(applied)
+			if err := v.Save(); err != nil {
+				logging.Sugar.Fatalf("Failed to save local vault: %v", err)
+			}
+		}
+
+		syncedAt := time.Now()
+		secrets, err := listAllSecrets(c, client.Filter{})
+		if err != nil {
+			logging.Sugar.Fatalf("Failed to get secrets: %s", rpcError(err))
+		}
+		refreshVault(secrets, syncedAt)
+
+		fmt.Printf("Sent %d changes, %d secrets saved to the local vault\n", sent, len(secrets))
+		if kept > 0 {
+			fmt.Printf("%d changes rejected by the server are kept in the outbox\n", kept)
+		}
+		if n := len(v.Conflicts()); n > 0 {
+			fmt.Printf("%d conflicts to resolve, see \"conflicts list\"\n", n)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+}
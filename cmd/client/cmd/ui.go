@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/tui"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+// uiStore provides the secrets of the terminal UI. Like the other commands it falls back
+// to the local vault when the server is unavailable and queues personal secrets saved offline.
+// Nothing is logged as the UI occupies the terminal, errors are shown in its status line.
+type uiStore struct {
+	c *client.Client
+}
+
+// List returns all personal secrets refreshing the local vault, cached ones if the server is unavailable.
+// Secrets failed to decrypt are not shown.
+func (s uiStore) List(ctx context.Context) ([]client.Secret, time.Time, error) {
+	syncedAt := time.Now()
+	secrets, err := s.c.ListSecrets(ctx, client.Filter{})
+	var decryptErr *client.DecryptError
+	if errors.As(err, &decryptErr) {
+		err = nil
+	}
+	if client.Offline(err) {
+		secrets, cachedAt, err := readCache()
+		if err != nil && !errors.Is(err, errNoCache) {
+			err = fmt.Errorf("failed to open local vault: %w", err)
+		}
+		return secrets, cachedAt, err
+	}
+	if err != nil {
+		return nil, time.Time{}, errors.New(rpcError(err))
+	}
+
+	// The UI works without the local copy, failure to save it is not shown.
+	_ = saveCache(secrets, syncedAt)
+
+	return secrets, time.Time{}, nil
+}
+
+// Create saves a new secret, to the outbox of the local vault if the server is unavailable.
+func (s uiStore) Create(ctx context.Context, secret client.Secret) error {
+	_, err := s.c.CreateSecret(ctx, secret)
+	if client.Offline(err) && secret.CollectionID == 0 {
+		return enqueue(vault.Change{Op: vault.OpCreate, Secret: vaultSecret(secret)})
+	}
+	if err != nil {
+		return errors.New(rpcError(err))
+	}
+
+	return nil
+}
+
+// Update saves the new content of the secret, to the outbox of the local vault if the server is unavailable.
+func (s uiStore) Update(ctx context.Context, secret client.Secret) error {
+	err := s.c.UpdateSecret(ctx, secret, false)
+	if client.Offline(err) && secret.CollectionID == 0 {
+		return enqueue(vault.Change{Op: vault.OpUpdate, Secret: vaultSecret(secret)})
+	}
+	if err != nil {
+		return errors.New(rpcError(err))
+	}
+
+	return nil
+}
+
+// Delete deletes the secret, deletion requires the server.
+func (s uiStore) Delete(ctx context.Context, id string) error {
+	if err := s.c.DeleteSecret(ctx, id); err != nil {
+		return errors.New(rpcError(err))
+	}
+
+	return nil
+}
+
+// uiCmd represents the ui command.
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse and edit secrets in an interactive terminal UI",
+	Long: `Opens a full-screen terminal UI with a searchable list of decrypted secrets,
+a detail pane rendered by secret type and forms to create, edit and delete secrets.
+Sensitive fields are masked until revealed, the list is refreshed from the server in the background.
+If the server is unavailable, secrets of the local vault are shown and saved changes are queued for "sync".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		interval, _ := cmd.Flags().GetDuration("refresh")
+		if interval <= 0 {
+			logging.Sugar.Fatal("Refresh interval must be positive")
+		}
+		// Configuration errors are reported before the UI takes the terminal.
+		encryptionKey()
+
+		c := newClient()
+		defer c.Close()
+
+		if err := tui.Run(uiStore{c: c}, interval); err != nil {
+			logging.Sugar.Fatalf("Failed to run UI: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+
+	uiCmd.Flags().Duration("refresh", tui.DefaultRefreshInterval, "Period of refreshing secrets from the server")
+}
@@ -9,24 +9,24 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
 	"github.com/KirillZiborov/GophKeeper/internal/auth"
 	"github.com/KirillZiborov/GophKeeper/internal/config"
 	"github.com/KirillZiborov/GophKeeper/internal/grpcapi"
 	"github.com/KirillZiborov/GophKeeper/internal/logging"
 	"github.com/KirillZiborov/GophKeeper/internal/storage"
 	"github.com/KirillZiborov/GophKeeper/proto"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
 var (
-	db    *pgxpool.Pool
-	store storage.Storage
+	store storage.Backend
 
 	// Use go run -ldflags to set up build variables while compiling.
 	buildVersion = "N/A" // Build version
@@ -55,36 +55,78 @@ func main() {
 	}
 	logging.Sugar.Infow("Loaded config", "server_address", cfg.Server.Address, "db", cfg.Storage.ConnectionString)
 
-	// Initialize storage based on the configuration.
-	if cfg.Storage.ConnectionString != "" {
-		// Establish a connection to the PostgreSQL database with a timeout.
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	// Initialize storage selected by the connection string scheme with a timeout.
+	if cfg.Storage.ConnectionString == "" {
+		logging.Sugar.Errorw("No database path specified")
+		return
+	}
+	queryTimeout, err := time.ParseDuration(cfg.Storage.QueryTimeout)
+	if err != nil || queryTimeout < 0 {
+		logging.Sugar.Fatalw("Invalid storage query timeout", "timeout", cfg.Storage.QueryTimeout, "error", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-		db, err = pgxpool.New(ctx, cfg.Storage.ConnectionString)
-		if err != nil {
-			logging.Sugar.Errorw("Unable to connect to database", "error", err)
-			return
+	var closeStore func()
+	store, closeStore, err = storage.Open(ctx, cfg.Storage.ConnectionString, queryTimeout)
+	if err != nil {
+		logging.Sugar.Errorw("Unable to open storage", "error", err)
+		return
+	}
+	defer closeStore()
+
+	// Apply pending schema migrations unless they are managed by the migrate command.
+	// The audit command only reads the database, so it requires the schema to be up to date instead.
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "migrate":
+	case len(os.Args) > 1 && os.Args[1] == "audit":
+		if err := checkSchema(); err != nil {
+			logging.Sugar.Errorw("Database schema is not up to date", "error", err)
+			closeStore()
+			os.Exit(1)
 		}
-
-		// Create users and secrets table in the database if it doesn't exist.
-		err = storage.CreateTables(ctx, db)
+	default:
+		applied, err := store.MigrateUp(context.Background())
 		if err != nil {
-			logging.Sugar.Errorw("Failed to create table", "error", err)
+			logging.Sugar.Errorw("Failed to migrate database schema", "error", err)
 			return
 		}
-		defer db.Close()
+		for _, m := range applied {
+			logging.Sugar.Infow("Applied schema migration", "version", m.Version, "name", m.Name)
+		}
+	}
 
-		// Use the database storage.
-		store = storage.NewDBStore(db)
-	} else {
-		logging.Sugar.Errorw("No database path specified", "error", err)
+	// Run administrative command instead of the server if requested.
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, os.Args[1:]); err != nil {
+			logging.Sugar.Errorw("Command failed", "error", err)
+			closeStore()
+			os.Exit(1)
+		}
 		return
 	}
 
+	// Write security-relevant events to the audit log with signed checkpoints.
+	signingKey, err := audit.ParseSigningKey(cfg.Audit.SigningKey)
+	if err != nil {
+		logging.Sugar.Fatalw("Failed to load audit signing key", "error", err)
+	}
+	if signingKey == nil {
+		logging.Sugar.Warnw("No audit signing key configured: audit log checkpoints are disabled " +
+			"and the log can't be verified, set audit.signing_key")
+	}
+	auditLogger := audit.NewLogger(store, signingKey, cfg.Audit.CheckpointInterval)
+
+	idempotencyWindow, err := time.ParseDuration(cfg.Server.IdempotencyWindow)
+	if err != nil || idempotencyWindow <= 0 {
+		logging.Sugar.Fatalw("Invalid idempotency window", "window", cfg.Server.IdempotencyWindow, "error", err)
+	}
+
 	service := app.KeeperService{
-		Store: store,
-		Cfg:   cfg,
+		Store:             store,
+		Cfg:               cfg,
+		Audit:             auditLogger,
+		IdempotencyWindow: idempotencyWindow,
 	}
 
 	auth.SetTokenConfig(cfg.Security.JWTKey, cfg.Security.ExpirationTime)
@@ -96,14 +138,39 @@ func main() {
 	}
 
 	grpcServer := grpc.NewServer(
-		// Add authentificatrion interceptor.
-		grpc.ChainUnaryInterceptor(auth.AuthInterceptor()),
+		// Add error translation, audit, authentificatrion and idempotency interceptors.
+		grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), audit.UnaryInterceptor(auditLogger), auth.AuthInterceptor(),
+			grpcapi.IdempotencyInterceptor(&service)),
+		grpc.ChainStreamInterceptor(grpcapi.StreamErrorInterceptor(), audit.StreamInterceptor(auditLogger),
+			auth.StreamAuthInterceptor()),
 	)
 	// Register the gRPC service.
 	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&service))
 	// Register reflection for grpcurl.
 	reflection.Register(grpcServer)
 
+	// Start deleting expired secrets in background.
+	reaperInterval, err := time.ParseDuration(cfg.Server.ReaperInterval)
+	if err != nil || reaperInterval <= 0 {
+		logging.Sugar.Fatalw("Invalid reaper interval", "interval", cfg.Server.ReaperInterval, "error", err)
+	}
+	reaperCtx, stopReaper := context.WithCancel(context.Background())
+	var reaperWg sync.WaitGroup
+	reaperWg.Add(1)
+	go func() {
+		defer reaperWg.Done()
+		service.RunReaper(reaperCtx, reaperInterval)
+	}()
+
+	// Write audit events of reads in background.
+	auditCtx, stopAudit := context.WithCancel(context.Background())
+	var auditWg sync.WaitGroup
+	auditWg.Add(1)
+	go func() {
+		defer auditWg.Done()
+		auditLogger.Run(auditCtx)
+	}()
+
 	// Start gRPC server in goroutine.
 	go func() {
 		logging.Sugar.Infow("Starting gRPC server", "address", cfg.Server.Address)
@@ -123,5 +190,9 @@ func main() {
 	defer shutdownCancel()
 
 	grpcServer.GracefulStop()
+	stopReaper()
+	reaperWg.Wait()
+	stopAudit()
+	auditWg.Wait()
 	logging.Sugar.Infow("Server shutdown complete")
 }
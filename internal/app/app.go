@@ -5,7 +5,10 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
 	"github.com/KirillZiborov/GophKeeper/internal/auth"
 	"github.com/KirillZiborov/GophKeeper/internal/config"
 	"github.com/KirillZiborov/GophKeeper/internal/models"
@@ -15,15 +18,18 @@ import (
 )
 
 // ErrUserNotFound is login error.
-var ErrUserNotFound = errors.New("invalid username or password")
+var ErrUserNotFound = storage.NewError(storage.KindUnauthenticated, "invalid username or password")
 
 // ErrAccessDenied is returned when user try to approach not his secret.
-var ErrAccessDenied = errors.New("access denied: secret doesn't belong to user")
+var ErrAccessDenied = storage.NewError(storage.KindPermissionDenied, "access denied: secret doesn't belong to user")
 
 // KeeperService is a facade of GophKeeper business logic.
 type KeeperService struct {
 	Store storage.Storage // Using database storage.
 	Cfg   *config.Config  // Using configuration.
+	Audit *audit.Logger   // Audit log of security-relevant events, nil disables auditing.
+
+	IdempotencyWindow time.Duration // How long responses are replayed for retries, zero uses DefaultIdempotencyWindow.
 }
 
 // Register adds new user to GophKeeper saving it username and hashed password.
@@ -45,75 +51,277 @@ func (ks *KeeperService) Register(ctx context.Context, username, password string
 	}
 
 	// Save user in the database.
-	if err = ks.Store.RegisterUser(user); err != nil {
+	if err = ks.Store.RegisterUser(ctx, user); err != nil {
 		return "", err
 	}
+	ks.Audit.Record(ctx, userUuid, audit.EventRegister, true, "username: "+username)
 
 	// Generates token for a new user.
 	token, err := auth.GenerateToken(userUuid)
 	if err != nil {
 		return "", err
 	}
+	ks.Audit.Record(ctx, userUuid, audit.EventTokenIssued, true, "")
 
 	return token, nil
 }
 
 // Login authentificates user with username and password provided.
 func (ks *KeeperService) Login(ctx context.Context, username, password string) (string, error) {
-	user, err := ks.Store.GetUser(username)
+	user, err := ks.Store.GetUser(ctx, username)
 	if err != nil {
+		ks.Audit.Record(ctx, "", audit.EventLoginFailure, false, "unknown username: "+username)
 		return "", err
 	}
 
 	if err := encryption.CheckPasswordHash(password, user.Password); err != nil {
+		ks.Audit.Record(ctx, user.ID, audit.EventLoginFailure, false, "invalid password")
 		return "", ErrUserNotFound
 	}
+	ks.Audit.Record(ctx, user.ID, audit.EventLoginSuccess, true, "")
 
 	// Generates token for user.
 	token, err := auth.GenerateToken(user.ID)
 	if err != nil {
 		return "", err
 	}
+	ks.Audit.Record(ctx, user.ID, audit.EventTokenIssued, true, "")
 
 	return token, nil
 }
 
 // AddSecret adds secret data to user's list of credentials.
 // Parameter token is a JWT which is used for etracting userID.
-func (ks *KeeperService) AddSecret(ctx context.Context, userID, data, meta string) (int64, error) {
+// The secret is deleted after expiresAt, zero expiresAt means it never expires.
+// Search tokens are blind indexes of the secret fields used by SearchSecrets.
+// Secret ID is a UUID generated by the client so that it can refer to the secret before it reaches
+// the server, empty secretID makes the server generate one.
+func (ks *KeeperService) AddSecret(ctx context.Context, userID, secretID, secretType, data, meta string, expiresAt time.Time, searchTokens []string) (string, error) {
+	secretID, err := checkSecretID(secretID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkSecretType(secretType); err != nil {
+		return "", err
+	}
+	if err := checkExpiry(expiresAt); err != nil {
+		return "", err
+	}
+	if err := checkSearchTokens(searchTokens); err != nil {
+		return "", err
+	}
+
 	creds := &models.Secret{
-		Data:   data,
-		Meta:   meta,
-		UserID: userID,
+		ID:           secretID,
+		Data:         data,
+		Meta:         meta,
+		UserID:       userID,
+		ExpiresAt:    expiresAt,
+		SearchTokens: searchTokens,
+		Type:         secretType,
 	}
 
-	return ks.Store.AddSecret(creds)
+	id, err := ks.Store.AddSecret(ctx, creds, ks.quota())
+	ks.Audit.Record(ctx, userID, audit.EventSecretCreate, err == nil, fmt.Sprintf("secret id: %s", creds.ID))
+
+	return id, err
 }
 
-// EditSecret updates secret data using its id.
-func (ks *KeeperService) EditSecret(ctx context.Context, id int64, userID, data, meta string) error {
-	secret, err := ks.Store.GetSecretByID(id)
+// EditSecret updates secret data using its id, either a UUID or a legacy integer id.
+// Nil expiresAt keeps the current expiration time, pointer to zero time removes it.
+// Search tokens replace the current ones, they are ignored for collection secrets.
+// Empty secretType keeps the current type.
+// Shares are copies of the secret re-encrypted for every user it is shared with, identified by RecipientName.
+// They replace the current copies along with the edit, so recipients never read stale data.
+func (ks *KeeperService) EditSecret(ctx context.Context, id, userID, secretType, data, meta string, expiresAt *time.Time, searchTokens []string, shares []models.Share) error {
+	if err := checkSecretType(secretType); err != nil {
+		return err
+	}
+	if expiresAt != nil {
+		if err := checkExpiry(*expiresAt); err != nil {
+			return err
+		}
+	}
+	if err := checkSearchTokens(searchTokens); err != nil {
+		return err
+	}
+
+	secret, err := ks.getSecret(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if secret.UserID != userID {
-		return ErrAccessDenied
+	if err := ks.checkWriteAccess(ctx, secret, userID); err != nil {
+		ks.Audit.Record(ctx, userID, audit.EventSecretEdit, false, fmt.Sprintf("secret id: %s: %v", secret.ID, err))
+		return err
 	}
 
 	secret.Data = data
 	secret.Meta = meta
+	if secretType != "" {
+		secret.Type = secretType
+	}
+	secret.SearchTokens = nil
+	if secret.CollectionID == 0 {
+		secret.SearchTokens = searchTokens
+	}
+	if expiresAt != nil {
+		secret.ExpiresAt = *expiresAt
+	}
+
+	// Collection secrets are shared through organization membership only.
+	var copies []models.Share
+	if secret.CollectionID == 0 {
+		if copies, err = ks.shareCopies(ctx, shares); err != nil {
+			return err
+		}
+	}
+
+	err = ks.Store.EditSecret(ctx, secret, copies, ks.quota())
+	ks.Audit.Record(ctx, userID, audit.EventSecretEdit, err == nil, fmt.Sprintf("secret id: %s", secret.ID))
 
-	return ks.Store.EditSecret(secret)
+	return err
 }
 
-// GetSecret retrieves all user's credentials.
-// Parameter token is a JWT which is used for etracting userID.
+// GetSecret returns the secret by its id, either a UUID or a legacy integer id.
+// Personal secrets are available to their owner, collection secrets to members of the organization.
+// Expired secrets waiting for deletion are not found.
+func (ks *KeeperService) GetSecret(ctx context.Context, userID, id string) (*models.Secret, error) {
+	secret, err := ks.getSecret(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !secret.ExpiresAt.IsZero() && !secret.ExpiresAt.After(time.Now()) {
+		return nil, storage.ErrSecretNotFound
+	}
+
+	if secret.CollectionID != 0 {
+		if _, err := ks.collectionRole(ctx, secret.CollectionID, userID); err != nil {
+			return nil, err
+		}
+	} else if secret.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+	ks.Audit.RecordRead(ctx, userID, audit.EventSecretRead, true, fmt.Sprintf("secret id: %s", secret.ID))
+
+	return secret, nil
+}
+
+// GetSecrets retrieves all user's credentials.
 func (ks *KeeperService) GetSecrets(ctx context.Context, userID string) ([]models.Secret, error) {
 	// Retrieve all user's secrets from storage.
-	creds, err := ks.Store.GetSecrets(userID)
+	creds, err := ks.Store.GetSecrets(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	ks.Audit.RecordRead(ctx, userID, audit.EventSecretList, true, fmt.Sprintf("secrets: %d", len(creds)))
+
 	return creds, nil
 }
+
+// DeleteAccount removes user with all his secrets.
+// Deletion requires re-authentication with the current user's password.
+func (ks *KeeperService) DeleteAccount(ctx context.Context, userID, password string) error {
+	user, err := ks.Store.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := encryption.CheckPasswordHash(password, user.Password); err != nil {
+		ks.Audit.Record(ctx, userID, audit.EventAccountDelete, false, "invalid password")
+		return ErrUserNotFound
+	}
+
+	err = ks.Store.DeleteUser(ctx, userID)
+	ks.Audit.Record(ctx, userID, audit.EventAccountDelete, err == nil, "")
+
+	return err
+}
+
+// ExportItem is a single record of user's account export, exactly one of its fields is set.
+type ExportItem struct {
+	User             *models.User
+	Keys             *models.UserKeys      // User's sharing keypair
+	Secret           *models.Secret        // User's personal secret
+	Share            *models.Share         // Secret shared with user
+	Membership       *models.Member        // User's membership in organization
+	CollectionKey    *models.CollectionKey // Collection key wrapped for user
+	CollectionSecret *models.Secret        // Secret of collection user has key of
+}
+
+// ExportAccount calls fn for every record of user's account data in the order: the user row, sharing keypair,
+// personal secrets, secrets shared with him, organization memberships, collection keys and secrets of these collections.
+// Secrets are passed one by one as they are read from the storage cursors, stopping on the first error of fn.
+func (ks *KeeperService) ExportAccount(ctx context.Context, userID string, fn func(item ExportItem) error) error {
+	user, err := ks.Store.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := fn(ExportItem{User: &user}); err != nil {
+		return err
+	}
+
+	keys, err := ks.Store.GetUserKeys(ctx, userID)
+	switch {
+	case errors.Is(err, storage.ErrKeyNotFound):
+	case err != nil:
+		return err
+	default:
+		if err := fn(ExportItem{Keys: keys}); err != nil {
+			return err
+		}
+	}
+
+	var secrets, collectionSecrets int
+	err = ks.Store.ScanSecrets(ctx, userID, models.SecretFilter{OrderBy: models.SecretOrderID}, func(secret models.Secret) error {
+		secrets++
+		return fn(ExportItem{Secret: &secret})
+	})
+	if err != nil {
+		return err
+	}
+
+	shares, err := ks.Store.GetSharesForRecipient(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range shares {
+		if err := fn(ExportItem{Share: &shares[i]}); err != nil {
+			return err
+		}
+	}
+
+	memberships, err := ks.Store.GetMemberships(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range memberships {
+		if err := fn(ExportItem{Membership: &memberships[i]}); err != nil {
+			return err
+		}
+	}
+
+	collectionKeys, err := ks.GetCollectionKeys(ctx, userID, 0)
+	if err != nil {
+		return err
+	}
+	for i := range collectionKeys {
+		if err := fn(ExportItem{CollectionKey: &collectionKeys[i]}); err != nil {
+			return err
+		}
+	}
+	for _, key := range collectionKeys {
+		err := ks.Store.ScanCollectionSecrets(ctx, key.CollectionID, func(secret models.Secret) error {
+			collectionSecrets++
+			return fn(ExportItem{CollectionSecret: &secret})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	ks.Audit.Record(ctx, userID, audit.EventAccountExport, true,
+		fmt.Sprintf("secrets: %d, shares: %d, collection secrets: %d", secrets, len(shares), collectionSecrets))
+
+	return nil
+}
@@ -5,11 +5,13 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/app"
 	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
 	"github.com/KirillZiborov/GophKeeper/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -66,7 +68,7 @@ func TestWrongPassword(t *testing.T) {
 	assert.NotEmpty(t, token, "Token should not be empty")
 
 	// Check that a user is saved in the storage.
-	user, err := fakeStore.GetUser(username)
+	user, err := fakeStore.GetUser(ctx, username)
 	assert.NoError(t, err, "User should be found after registration")
 	assert.Equal(t, username, user.Username)
 
@@ -99,7 +101,7 @@ func TestKeeperServiceIntegration(t *testing.T) {
 	assert.NotEmpty(t, token, "Token should not be empty")
 
 	// Check that a user is saved in the database.
-	user, err := fakeStore.GetUser(username)
+	user, err := fakeStore.GetUser(ctx, username)
 	assert.NoError(t, err, "User should be found after registration")
 	assert.Equal(t, username, user.Username)
 
@@ -115,7 +117,7 @@ func TestKeeperServiceIntegration(t *testing.T) {
 	// AddSecret test.
 	data := "encrypted_data_example"
 	meta := "some metadata"
-	id, err := svc.AddSecret(ctx, userID, data, meta)
+	id, err := svc.AddSecret(ctx, userID, "", "", data, meta, time.Time{}, nil)
 	assert.NoError(t, err, "AddSecret should succeed")
 
 	// Get credentials and check that it is saved in the storage.
@@ -126,7 +128,7 @@ func TestKeeperServiceIntegration(t *testing.T) {
 	// EditSecret test.
 	newData := "updated_encrypted_data"
 	newMeta := "updated metadata"
-	err = svc.EditSecret(ctx, id, userID, newData, newMeta)
+	err = svc.EditSecret(ctx, id, userID, "", newData, newMeta, nil, nil, nil)
 	assert.NoError(t, err, "EditSecret should succeed")
 
 	// Check that the secret is updated successfully.
@@ -162,11 +164,11 @@ func TestUpdateSecretAccessDenied(t *testing.T) {
 	// user1 adds a secret with some generated id.
 	data := "encrypted_data_example"
 	meta := "some metadata"
-	id, err := svc.AddSecret(ctx, userID, data, meta)
+	id, err := svc.AddSecret(ctx, userID, "", "", data, meta, time.Time{}, nil)
 	assert.NoError(t, err, "AddSecret should succeed")
 
 	// user2 try to update user1's secret by id.
-	err = svc.EditSecret(context.Background(), id, "user2", "new data", "new meta")
+	err = svc.EditSecret(context.Background(), id, "user2", "", "new data", "new meta", nil, nil, nil)
 	// Expect error.
 	require.Error(t, err)
 	assert.Equal(t, "access denied: secret doesn't belong to user", err.Error())
@@ -199,3 +201,257 @@ func TestDoubleRegister(t *testing.T) {
 	require.Error(t, err)
 	assert.Equal(t, "user already exists", err.Error())
 }
+
+// Test case: user deletes his account with wrong and then with correct password.
+func TestDeleteAccount(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	username := "user"
+	password := "securePassword"
+	token, err := svc.Register(ctx, username, password)
+	require.NoError(t, err, "Registration should succeed")
+	userID := auth.GetUserID(token)
+
+	_, err = svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Time{}, nil)
+	require.NoError(t, err, "AddSecret should succeed")
+
+	// Deletion with wrong password.
+	err = svc.DeleteAccount(ctx, userID, "wrong")
+	require.Error(t, err)
+	assert.Equal(t, "invalid username or password", err.Error())
+
+	// Deletion with correct password.
+	err = svc.DeleteAccount(ctx, userID, password)
+	require.NoError(t, err, "DeleteAccount should succeed")
+
+	// User and his secrets are removed.
+	_, err = fakeStore.GetUser(ctx, username)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	creds, err := svc.GetSecrets(ctx, userID)
+	assert.NoError(t, err)
+	assert.Empty(t, creds)
+}
+
+// accountExport is the account export collected from the records passed by ExportAccount.
+type accountExport struct {
+	User              models.User
+	Keys              *models.UserKeys
+	Secrets           []models.Secret
+	Shares            []models.Share
+	Memberships       []models.Member
+	CollectionKeys    []models.CollectionKey
+	CollectionSecrets []models.Secret
+}
+
+// exportAccount collects the account export of user.
+func exportAccount(ctx context.Context, svc *app.KeeperService, userID string) (accountExport, error) {
+	var export accountExport
+	err := svc.ExportAccount(ctx, userID, func(item app.ExportItem) error {
+		switch {
+		case item.User != nil:
+			export.User = *item.User
+		case item.Keys != nil:
+			export.Keys = item.Keys
+		case item.Secret != nil:
+			export.Secrets = append(export.Secrets, *item.Secret)
+		case item.Share != nil:
+			export.Shares = append(export.Shares, *item.Share)
+		case item.Membership != nil:
+			export.Memberships = append(export.Memberships, *item.Membership)
+		case item.CollectionKey != nil:
+			export.CollectionKeys = append(export.CollectionKeys, *item.CollectionKey)
+		case item.CollectionSecret != nil:
+			export.CollectionSecrets = append(export.CollectionSecrets, *item.CollectionSecret)
+		}
+		return nil
+	})
+
+	return export, err
+}
+
+// Test case: account export contains the user, his keys, secrets, shares and organization collections.
+func TestExportAccount(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID := registerWithKeys(t, svc, "user")
+	ownerID := registerWithKeys(t, svc, "owner")
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.AddSecret(ctx, userID, "", "", generateStr(), generateStr(), time.Time{}, nil)
+		require.NoError(t, err, "AddSecret should succeed")
+	}
+
+	// Owner shares his secret with the user and makes him a member of the organization.
+	sharedID, err := svc.AddSecret(ctx, ownerID, "", "", "data", "meta", time.Time{}, nil)
+	require.NoError(t, err)
+	_, err = svc.ShareSecret(ctx, ownerID, sharedID, "user", "wrapped", "shared data", "shared meta")
+	require.NoError(t, err)
+
+	orgID, err := svc.CreateOrganization(ctx, ownerID, "team")
+	require.NoError(t, err)
+	collectionID, err := svc.CreateCollection(ctx, ownerID, orgID, "servers", []app.CollectionKeyGrant{
+		{Username: "owner", WrappedKey: "key-owner"},
+	})
+	require.NoError(t, err)
+	collectionSecretID, err := svc.AddCollectionSecret(ctx, ownerID, collectionID, "", "", "collection data", "collection meta", time.Time{})
+	require.NoError(t, err)
+
+	// Collections of pending invitations are not exported.
+	export, err := exportAccount(ctx, svc, userID)
+	require.NoError(t, err, "ExportAccount should succeed")
+	assert.Empty(t, export.Memberships)
+	assert.Empty(t, export.CollectionKeys)
+	assert.Empty(t, export.CollectionSecrets)
+
+	invitationID, err := svc.InviteMember(ctx, ownerID, orgID, "user", models.RoleMember, []app.CollectionKeyGrant{
+		{CollectionID: collectionID, Username: "user", WrappedKey: "key-user"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, svc.AcceptInvitation(ctx, userID, invitationID))
+
+	export, err = exportAccount(ctx, svc, userID)
+	require.NoError(t, err, "ExportAccount should succeed")
+	assert.Equal(t, userID, export.User.ID)
+	assert.Equal(t, "user", export.User.Username)
+	require.NotNil(t, export.Keys)
+	assert.Equal(t, "private-user", export.Keys.EncryptedPrivateKey)
+	assert.Len(t, export.Secrets, 3)
+
+	require.Len(t, export.Shares, 1)
+	assert.Equal(t, sharedID, export.Shares[0].SecretID)
+	assert.Equal(t, "shared data", export.Shares[0].Data)
+
+	require.Len(t, export.Memberships, 1)
+	assert.Equal(t, models.RoleMember, export.Memberships[0].Role)
+	require.Len(t, export.CollectionKeys, 1)
+	assert.Equal(t, "key-user", export.CollectionKeys[0].WrappedKey)
+	require.Len(t, export.CollectionSecrets, 1)
+	assert.Equal(t, collectionSecretID, export.CollectionSecrets[0].ID)
+	assert.Equal(t, collectionID, export.CollectionSecrets[0].CollectionID)
+
+	// Account without sharing keys is exported too.
+	token, err := svc.Register(ctx, "nokeys", "password")
+	require.NoError(t, err)
+	export, err = exportAccount(ctx, svc, auth.GetUserID(token))
+	require.NoError(t, err)
+	assert.Nil(t, export.Keys)
+	assert.Empty(t, export.Secrets)
+
+	// Export stops on the first error of the consumer.
+	stop := errors.New("stop")
+	var items int
+	err = svc.ExportAccount(ctx, userID, func(item app.ExportItem) error {
+		items++
+		if item.Secret != nil {
+			return stop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stop)
+	assert.Equal(t, 3, items)
+}
+
+// Test case: expired secrets are hidden and deleted by the reaper.
+func TestSecretExpiry(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+
+	// Expiration time must be in the future.
+	_, err = svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Now().Add(-time.Minute), nil)
+	require.ErrorIs(t, err, app.ErrInvalidExpiry)
+
+	expiresAt := time.Now().Add(time.Hour)
+	tempID, err := svc.AddSecret(ctx, userID, "", "", "temporary", "meta", expiresAt, nil)
+	require.NoError(t, err)
+	keepID, err := svc.AddSecret(ctx, userID, "", "", "permanent", "meta", time.Time{}, nil)
+	require.NoError(t, err)
+
+	// Editing without expiration time keeps the current one.
+	require.NoError(t, svc.EditSecret(ctx, tempID, userID, "", "temporary2", "meta", nil, nil, nil))
+	secret, err := fakeStore.GetSecretByID(ctx, tempID)
+	require.NoError(t, err)
+	assert.True(t, secret.ExpiresAt.Equal(expiresAt))
+
+	// Nothing is expired yet.
+	n, err := svc.DeleteExpiredSecrets(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	n, err = svc.DeleteExpiredSecrets(ctx, expiresAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	secrets, err := svc.GetSecrets(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, keepID, secrets[0].ID)
+}
+
+// Test case: cancelled requests are not executed by the storage.
+func TestCancelledRequest(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+	for i := 0; i < 3; i++ {
+		_, err = svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Time{}, nil)
+		require.NoError(t, err)
+	}
+
+	// Streaming stops as soon as the client goes away.
+	var streamed int
+	err = svc.StreamSecrets(ctx, userID, "", time.Time{}, func(models.Secret) error {
+		streamed++
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, streamed)
+
+	_, err = svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Time{}, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	secrets, err := svc.GetSecrets(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Len(t, secrets, 3)
+}
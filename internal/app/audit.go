@@ -0,0 +1,22 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// ErrInvalidTimeRange is returned when the start of the time range is after its end.
+var ErrInvalidTimeRange = storage.NewError(storage.KindInvalidArgument, "invalid time range: from is after to")
+
+// ListAuditEvents retrieves user's audit events created in [from, to).
+// Zero from or to means no bound, limit <= 0 means no limit.
+func (ks *KeeperService) ListAuditEvents(ctx context.Context, userID string, from, to time.Time, limit int) ([]models.AuditEvent, error) {
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	return ks.Store.GetAuditEvents(ctx, userID, from, to, limit)
+}
@@ -0,0 +1,73 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: security-relevant operations are written to user's audit log.
+func TestAuditEvents(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+		Audit: audit.NewLogger(fakeStore, nil, 0),
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	username, password := generateStr(), generateStr()
+	token, err := svc.Register(ctx, username, password)
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+	require.NotEmpty(t, userID)
+
+	_, err = svc.Login(ctx, username, "wrong")
+	require.Error(t, err)
+	_, err = svc.Login(ctx, username, password)
+	require.NoError(t, err)
+
+	id, err := svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Time{}, nil)
+	require.NoError(t, err)
+	require.NoError(t, svc.EditSecret(ctx, id, userID, "", "data2", "meta2", nil, nil, nil))
+	_, err = svc.GetSecrets(ctx, userID)
+	require.NoError(t, err)
+
+	events, err := svc.ListAuditEvents(ctx, userID, time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{
+		audit.EventRegister, audit.EventTokenIssued,
+		audit.EventLoginFailure,
+		audit.EventLoginSuccess, audit.EventTokenIssued,
+		audit.EventSecretCreate, audit.EventSecretEdit, audit.EventSecretList,
+	}, types)
+	assert.False(t, events[2].Success)
+
+	// Time range and limit filters.
+	events, err = svc.ListAuditEvents(ctx, userID, time.Now().Add(time.Hour), time.Time{}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	events, err = svc.ListAuditEvents(ctx, userID, time.Time{}, time.Time{}, 3)
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	_, err = svc.ListAuditEvents(ctx, userID, time.Now(), time.Now().Add(-time.Hour), 0)
+	assert.ErrorIs(t, err, app.ErrInvalidTimeRange)
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// MaxBatchMutations is the maximum number of mutations in a batch.
+const MaxBatchMutations = 1000
+
+// ErrInvalidBatch is returned when a batch is empty or too large.
+var ErrInvalidBatch = storage.NewError(storage.KindInvalidArgument, "invalid batch: expected 1-1000 mutations")
+
+// ErrEmptySecret is returned when a created or updated secret has no data.
+var ErrEmptySecret = storage.NewError(storage.KindInvalidArgument, "secret data must be provided")
+
+// checkMutation validates a mutation before it reaches the storage.
+func checkMutation(m models.SecretMutation) error {
+	switch m.Op {
+	case models.MutationDelete:
+		return nil
+	case models.MutationCreate, models.MutationUpdate:
+	default:
+		return storage.ErrInvalidMutation
+	}
+	if m.Secret.Data == "" {
+		return ErrEmptySecret
+	}
+	if err := checkSecretType(m.Secret.Type); err != nil {
+		return err
+	}
+	if !m.KeepExpiresAt {
+		if err := checkExpiry(m.Secret.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	return checkSearchTokens(m.Secret.SearchTokens)
+}
+
+// mutationSecretID returns the canonical id of the secret of a mutation.
+// Created secrets may have ids generated by the client, others may be selected by legacy integer ids.
+func (ks *KeeperService) mutationSecretID(ctx context.Context, m models.SecretMutation) (string, error) {
+	if m.Op == models.MutationCreate {
+		return checkSecretID(m.Secret.ID)
+	}
+	return ks.resolveSecretID(ctx, m.Secret.ID)
+}
+
+// MutateSecrets applies a batch of create, update and delete operations to user's personal secrets.
+// Atomic batch is applied only if every mutation succeeds, otherwise mutations are applied independently.
+// Returns per-mutation results and whether the changes were committed.
+func (ks *KeeperService) MutateSecrets(ctx context.Context, userID string, mutations []models.SecretMutation, atomic bool) ([]models.MutationResult, bool, error) {
+	if len(mutations) == 0 || len(mutations) > MaxBatchMutations {
+		return nil, false, ErrInvalidBatch
+	}
+
+	// Invalid mutations fail without reaching the storage, the valid ones keep their positions in results.
+	results := make([]models.MutationResult, len(mutations))
+	valid := make([]models.SecretMutation, 0, len(mutations))
+	positions := make([]int, 0, len(mutations))
+	for i, m := range mutations {
+		err := checkMutation(m)
+		if err == nil {
+			m.Secret.ID, err = ks.mutationSecretID(ctx, m)
+		}
+		if err == nil {
+			m.Shares, err = ks.shareCopies(ctx, m.Shares)
+		}
+		if err != nil && storage.KindOf(err) == storage.KindInternal {
+			return nil, false, err
+		}
+		if err != nil {
+			results[i] = models.MutationResult{ID: mutations[i].Secret.ID, Err: err}
+			continue
+		}
+		valid = append(valid, m)
+		positions = append(positions, i)
+	}
+
+	if len(valid) < len(mutations) && atomic {
+		for _, i := range positions {
+			results[i] = models.MutationResult{ID: mutations[i].Secret.ID, Err: storage.ErrBatchAborted}
+		}
+		ks.recordBatch(ctx, userID, results, false)
+		return results, false, nil
+	}
+
+	if len(valid) > 0 {
+		applied, err := ks.Store.MutateSecrets(ctx, userID, valid, ks.quota(), atomic)
+		if err != nil {
+			ks.Audit.Record(ctx, userID, audit.EventSecretBatch, false, fmt.Sprintf("mutations: %d", len(mutations)))
+			return nil, false, err
+		}
+		for j, result := range applied {
+			results[positions[j]] = result
+		}
+	}
+
+	committed := !atomic || succeeded(results) == len(results)
+	ks.recordBatch(ctx, userID, results, committed)
+	return results, committed, nil
+}
+
+// recordBatch records the audit event of a batch with the numbers of applied and failed mutations.
+func (ks *KeeperService) recordBatch(ctx context.Context, userID string, results []models.MutationResult, committed bool) {
+	applied := 0
+	if committed {
+		applied = succeeded(results)
+	}
+	ks.Audit.Record(ctx, userID, audit.EventSecretBatch, committed,
+		fmt.Sprintf("mutations: %d, applied: %d", len(results), applied))
+}
+
+// succeeded counts mutations without errors.
+func succeeded(results []models.MutationResult) int {
+	n := 0
+	for _, result := range results {
+		if result.Err == nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,79 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: invalid mutations fail an atomic batch before reaching the storage and are skipped in best-effort mode.
+func TestMutateSecrets(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+		Audit: audit.NewLogger(fakeStore, nil, 0),
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+
+	_, _, err = svc.MutateSecrets(ctx, userID, nil, true)
+	require.ErrorIs(t, err, app.ErrInvalidBatch)
+	_, _, err = svc.MutateSecrets(ctx, userID, make([]models.SecretMutation, app.MaxBatchMutations+1), true)
+	require.ErrorIs(t, err, app.ErrInvalidBatch)
+
+	mutations := []models.SecretMutation{
+		{Op: models.MutationCreate, Secret: models.Secret{Data: "data", Type: models.SecretTypeText}},
+		{Op: models.MutationCreate, Secret: models.Secret{Data: "data", Type: "unknown"}},
+		{Op: models.MutationCreate},
+		{Op: models.MutationCreate, Secret: models.Secret{Data: "data", ExpiresAt: time.Now().Add(-time.Hour)}},
+	}
+
+	results, committed, err := svc.MutateSecrets(ctx, userID, mutations, true)
+	require.NoError(t, err)
+	assert.False(t, committed)
+	assert.ErrorIs(t, results[0].Err, storage.ErrBatchAborted)
+	assert.ErrorIs(t, results[1].Err, app.ErrInvalidType)
+	assert.ErrorIs(t, results[2].Err, app.ErrEmptySecret)
+	assert.ErrorIs(t, results[3].Err, app.ErrInvalidExpiry)
+	secrets, err := svc.GetSecrets(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	results, committed, err = svc.MutateSecrets(ctx, userID, mutations, false)
+	require.NoError(t, err)
+	assert.True(t, committed)
+	require.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, app.ErrInvalidType)
+	secrets, err = svc.GetSecrets(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, results[0].ID, secrets[0].ID)
+
+	events, err := svc.ListAuditEvents(ctx, userID, time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	var batches []models.AuditEvent
+	for _, e := range events {
+		if e.Type == audit.EventSecretBatch {
+			batches = append(batches, e)
+		}
+	}
+	require.Len(t, batches, 2)
+	assert.False(t, batches[0].Success)
+	assert.True(t, batches[1].Success)
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// ErrInvalidExpiry is returned when the secret expiration time is in the past.
+var ErrInvalidExpiry = storage.NewError(storage.KindInvalidArgument, "invalid expiration time: must be in the future")
+
+// checkExpiry checks that the expiration time is either zero or in the future.
+func checkExpiry(expiresAt time.Time) error {
+	if !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return ErrInvalidExpiry
+	}
+	return nil
+}
+
+// DeleteExpiredSecrets deletes all secrets expired at the moment now and returns their number.
+func (ks *KeeperService) DeleteExpiredSecrets(ctx context.Context, now time.Time) (int, error) {
+	deleted, err := ks.Store.DeleteExpiredSecrets(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, secret := range deleted {
+		ks.Audit.Record(ctx, secret.UserID, audit.EventSecretDelete, true,
+			fmt.Sprintf("secret id: %s expired at %s", secret.ID, secret.ExpiresAt.Format(time.RFC3339)))
+	}
+
+	return len(deleted), nil
+}
+
+// RunReaper deletes expired secrets, sends and idempotency keys every interval until ctx is done.
+func (ks *KeeperService) RunReaper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := ks.DeleteExpiredSecrets(ctx, now)
+			if err != nil {
+				logging.Sugar.Errorw("Failed to delete expired secrets", "error", err)
+			} else if n > 0 {
+				logging.Sugar.Infow("Deleted expired secrets", "count", n)
+			}
+
+			sends, err := ks.Store.DeleteExpiredSends(ctx, now)
+			if err != nil {
+				logging.Sugar.Errorw("Failed to delete expired sends", "error", err)
+			} else if sends > 0 {
+				logging.Sugar.Infow("Deleted expired sends", "count", sends)
+			}
+
+			keys, err := ks.Store.DeleteExpiredIdempotencyKeys(ctx, now)
+			if err != nil {
+				logging.Sugar.Errorw("Failed to delete expired idempotency keys", "error", err)
+			} else if keys > 0 {
+				logging.Sugar.Infow("Deleted expired idempotency keys", "count", keys)
+			}
+		}
+	}
+}
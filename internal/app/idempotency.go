@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// DefaultIdempotencyWindow is how long responses are kept for retries without configuration.
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+// idempotencyLease is how long the key of a request in progress is reserved.
+// If the server dies while handling the request, a retry takes the key over after the lease
+// instead of getting ErrRequestInProgress for the whole idempotency window.
+const idempotencyLease = 5 * time.Minute
+
+// maxIdempotencyKeyLength is the maximum length of an idempotency key.
+const maxIdempotencyKeyLength = 128
+
+// ErrInvalidIdempotencyKey is returned when an idempotency key is too long or has non-printable characters.
+var ErrInvalidIdempotencyKey = storage.NewError(storage.KindInvalidArgument, "invalid idempotency key: expected 1-128 printable ASCII characters")
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent with a different request.
+var ErrIdempotencyKeyReused = storage.NewError(storage.KindInvalidArgument, "idempotency key was used for another request")
+
+// ErrRequestInProgress is returned for a retry arriving while the request with the same idempotency key is handled.
+var ErrRequestInProgress = storage.NewError(storage.KindAborted, "request with the same idempotency key is in progress")
+
+// idempotencyWindow returns how long responses of requests are kept for retries.
+func (ks *KeeperService) idempotencyWindow() time.Duration {
+	if ks.IdempotencyWindow <= 0 {
+		return DefaultIdempotencyWindow
+	}
+	return ks.IdempotencyWindow
+}
+
+// checkIdempotencyKey checks the length and characters of an idempotency key.
+func checkIdempotencyKey(key string) error {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return ErrInvalidIdempotencyKey
+	}
+	for _, c := range key {
+		if c < ' ' || c > '~' {
+			return ErrInvalidIdempotencyKey
+		}
+	}
+	return nil
+}
+
+// BeginIdempotentRequest reserves the idempotency key of user's request to method.
+// requestHash identifies the request so that a key can't be reused for another one.
+// Returns the saved response of a completed request with the same key, nil if the request must be handled.
+func (ks *KeeperService) BeginIdempotentRequest(ctx context.Context, userID, key, method, requestHash string) ([]byte, error) {
+	if err := checkIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	existing, reserved, err := ks.Store.ReserveIdempotencyKey(ctx, &models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Method:      method,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(idempotencyLease),
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if existing.Method != method || existing.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if existing.Response == nil {
+		return nil, ErrRequestInProgress
+	}
+	return existing.Response, nil
+}
+
+// CompleteIdempotentRequest saves the response of the request for retries during the idempotency window.
+// The response is saved even if the client has gone, since that is when it retries.
+func (ks *KeeperService) CompleteIdempotentRequest(ctx context.Context, userID, key string, response []byte) error {
+	expiresAt := time.Now().Add(ks.idempotencyWindow())
+	return ks.Store.CompleteIdempotencyKey(context.WithoutCancel(ctx), userID, key, response, expiresAt)
+}
+
+// ReleaseIdempotentRequest frees the idempotency key of a failed request so that it can be retried.
+func (ks *KeeperService) ReleaseIdempotentRequest(ctx context.Context, userID, key string) error {
+	return ks.Store.ReleaseIdempotencyKey(context.WithoutCancel(ctx), userID, key)
+}
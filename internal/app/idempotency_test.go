@@ -0,0 +1,58 @@
+package app_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: idempotency keys are reserved until the response is saved and can't be reused for another request.
+func TestIdempotentRequest(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+
+	_, err = svc.BeginIdempotentRequest(ctx, userID, strings.Repeat("k", 129), "add", "hash")
+	require.ErrorIs(t, err, app.ErrInvalidIdempotencyKey)
+	_, err = svc.BeginIdempotentRequest(ctx, userID, "key\n", "add", "hash")
+	require.ErrorIs(t, err, app.ErrInvalidIdempotencyKey)
+
+	saved, err := svc.BeginIdempotentRequest(ctx, userID, "key", "add", "hash")
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+
+	_, err = svc.BeginIdempotentRequest(ctx, userID, "key", "add", "hash")
+	require.ErrorIs(t, err, app.ErrRequestInProgress)
+
+	// The response is saved even if the request is cancelled.
+	cancelled, cancelRequest := context.WithCancel(ctx)
+	cancelRequest()
+	require.NoError(t, svc.CompleteIdempotentRequest(cancelled, userID, "key", []byte("response")))
+
+	saved, err = svc.BeginIdempotentRequest(ctx, userID, "key", "add", "hash")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("response"), saved)
+
+	_, err = svc.BeginIdempotentRequest(ctx, userID, "key", "add", "other")
+	require.ErrorIs(t, err, app.ErrIdempotencyKeyReused)
+	_, err = svc.BeginIdempotentRequest(ctx, userID, "key", "edit", "hash")
+	require.ErrorIs(t, err, app.ErrIdempotencyKeyReused)
+}
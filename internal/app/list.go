@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Page size limits of listing secrets.
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// ErrInvalidPageToken is returned when the page token is malformed or was issued for another order.
+var ErrInvalidPageToken = storage.NewError(storage.KindInvalidArgument, "invalid page token")
+
+// ErrInvalidOrder is returned when listing secrets in an unknown order.
+var ErrInvalidOrder = storage.NewError(storage.KindInvalidArgument, "invalid order: must be id or updated_at")
+
+// ErrInvalidType is returned when the secret type is unknown.
+var ErrInvalidType = storage.NewError(storage.KindInvalidArgument, "invalid secret type: must be card, credentials, text, bin or otp")
+
+// checkSecretType checks that the secret type is either empty or known.
+func checkSecretType(secretType string) error {
+	switch secretType {
+	case "", models.SecretTypeCard, models.SecretTypeCredentials, models.SecretTypeText, models.SecretTypeBin, models.SecretTypeOTP:
+		return nil
+	default:
+		return ErrInvalidType
+	}
+}
+
+// SecretPage is a page of user's secrets.
+type SecretPage struct {
+	Secrets       []models.Secret // Secrets of the page
+	NextPageToken string          // Token of the next page, empty for the last page
+	Total         int64           // Number of secrets matching the filter in all pages
+}
+
+// ListSecrets retrieves a page of user's secrets of secretType updated after updatedAfter.
+// Empty pageToken starts from the first page, the order can't change between pages.
+func (ks *KeeperService) ListSecrets(ctx context.Context, userID string, pageSize int, pageToken, orderBy, secretType string, updatedAfter time.Time) (SecretPage, error) {
+	if orderBy == "" {
+		orderBy = models.SecretOrderID
+	}
+	if orderBy != models.SecretOrderID && orderBy != models.SecretOrderUpdated {
+		return SecretPage{}, ErrInvalidOrder
+	}
+	if err := checkSecretType(secretType); err != nil {
+		return SecretPage{}, err
+	}
+
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	filter := models.SecretFilter{
+		Type:         secretType,
+		UpdatedAfter: updatedAfter,
+		OrderBy:      orderBy,
+		Limit:        pageSize,
+	}
+	if pageToken != "" {
+		var err error
+		filter.AfterID, filter.AfterUpdatedAt, err = decodePageToken(pageToken, orderBy)
+		if err != nil {
+			return SecretPage{}, err
+		}
+	}
+
+	secrets, total, err := ks.Store.ListSecrets(ctx, userID, filter)
+	if err != nil {
+		return SecretPage{}, err
+	}
+	ks.Audit.RecordRead(ctx, userID, audit.EventSecretList, true, fmt.Sprintf("secrets: %d of %d", len(secrets), total))
+
+	page := SecretPage{Secrets: secrets, Total: total}
+	if len(secrets) == pageSize {
+		page.NextPageToken = encodePageToken(secrets[len(secrets)-1], orderBy)
+	}
+
+	return page, nil
+}
+
+// encodePageToken encodes the position after the last secret of the page in the order.
+func encodePageToken(last models.Secret, orderBy string) string {
+	token := fmt.Sprintf("%s:%s:%d", orderBy, last.ID, last.UpdatedAt.UnixMicro())
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+// decodePageToken decodes the position encoded by encodePageToken checking its order.
+func decodePageToken(token, orderBy string) (string, time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidPageToken
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || parts[0] != orderBy {
+		return "", time.Time{}, ErrInvalidPageToken
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return "", time.Time{}, ErrInvalidPageToken
+	}
+	micros, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidPageToken
+	}
+
+	return id.String(), time.UnixMicro(micros).UTC(), nil
+}
+
+// StreamSecrets calls fn for every user's secret of secretType updated after updatedAfter in ID order.
+// Secrets are passed one by one as they are read from the storage, stopping on the first error of fn.
+func (ks *KeeperService) StreamSecrets(ctx context.Context, userID, secretType string, updatedAfter time.Time, fn func(secret models.Secret) error) error {
+	if err := checkSecretType(secretType); err != nil {
+		return err
+	}
+
+	filter := models.SecretFilter{
+		Type:         secretType,
+		UpdatedAfter: updatedAfter,
+		OrderBy:      models.SecretOrderID,
+	}
+
+	var n int
+	err := ks.Store.ScanSecrets(ctx, userID, filter, func(secret models.Secret) error {
+		n++
+		return fn(secret)
+	})
+	ks.Audit.RecordRead(ctx, userID, audit.EventSecretList, err == nil, fmt.Sprintf("streamed secrets: %d", n))
+
+	return err
+}
@@ -0,0 +1,98 @@
+package app_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: secrets are listed page by page with filters in a stable order.
+func TestListSecrets(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+
+	_, err = svc.AddSecret(ctx, userID, "", "unknown", "data", "meta", time.Time{}, nil)
+	require.ErrorIs(t, err, app.ErrInvalidType)
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		secretType := models.SecretTypeText
+		if i%2 == 0 {
+			secretType = models.SecretTypeCard
+		}
+		id, err := svc.AddSecret(ctx, userID, "", secretType, generateStr(), "meta", time.Time{}, nil)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// Walk all pages in ID order.
+	var listed []string
+	pageToken := ""
+	for {
+		page, err := svc.ListSecrets(ctx, userID, 2, pageToken, "", "", time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), page.Total)
+		for _, s := range page.Secrets {
+			listed = append(listed, s.ID)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	assert.ElementsMatch(t, ids, listed)
+	assert.True(t, slices.IsSorted(listed), "Secrets should be listed in ID order")
+
+	// Filter by type.
+	page, err := svc.ListSecrets(ctx, userID, 10, "", "", models.SecretTypeCard, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Len(t, page.Secrets, 3)
+	assert.Empty(t, page.NextPageToken)
+
+	// Updated secret is the last one in update order and the only one updated after the time.
+	time.Sleep(time.Millisecond)
+	updatedAfter := time.Now()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, svc.EditSecret(ctx, ids[0], userID, "", "new data", "meta", nil, nil, nil))
+
+	page, err = svc.ListSecrets(ctx, userID, 10, "", models.SecretOrderUpdated, "", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, page.Secrets, 5)
+	assert.Equal(t, ids[0], page.Secrets[4].ID)
+	assert.Equal(t, models.SecretTypeCard, page.Secrets[4].Type, "Empty type should keep the current one")
+
+	page, err = svc.ListSecrets(ctx, userID, 10, "", models.SecretOrderUpdated, "", updatedAfter)
+	require.NoError(t, err)
+	require.Len(t, page.Secrets, 1)
+	assert.Equal(t, ids[0], page.Secrets[0].ID)
+
+	// Page token can't be used with another order.
+	page, err = svc.ListSecrets(ctx, userID, 2, "", models.SecretOrderID, "", time.Time{})
+	require.NoError(t, err)
+	_, err = svc.ListSecrets(ctx, userID, 2, page.NextPageToken, models.SecretOrderUpdated, "", time.Time{})
+	require.ErrorIs(t, err, app.ErrInvalidPageToken)
+
+	_, err = svc.ListSecrets(ctx, userID, 2, "", "name", "", time.Time{})
+	require.ErrorIs(t, err, app.ErrInvalidOrder)
+}
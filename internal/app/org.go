@@ -0,0 +1,367 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// ErrNotMember is returned when user is not a member of organization.
+var ErrNotMember = storage.NewError(storage.KindPermissionDenied, "access denied: user is not a member of organization")
+
+// ErrInsufficientRole is returned when member's role doesn't allow the operation.
+var ErrInsufficientRole = storage.NewError(storage.KindPermissionDenied, "access denied: insufficient role")
+
+// ErrInvalidRole is returned for unknown role names.
+var ErrInvalidRole = storage.NewError(storage.KindInvalidArgument, "invalid role: expected owner, admin, member or read-only")
+
+// ErrAlreadyMember is returned when invited user is already a member of organization.
+var ErrAlreadyMember = storage.NewError(storage.KindAlreadyExists, "user is already a member of organization")
+
+// CollectionKeyGrant is a collection key wrapped for a user identified by username.
+type CollectionKeyGrant struct {
+	CollectionID int64
+	Username     string
+	WrappedKey   string
+}
+
+// CreateOrganization creates a new organization with user as its owner.
+func (ks *KeeperService) CreateOrganization(ctx context.Context, userID, name string) (int64, error) {
+	return ks.Store.CreateOrganization(ctx, &models.Organization{Name: name}, userID)
+}
+
+// ListOrganizations returns memberships of user in organizations.
+func (ks *KeeperService) ListOrganizations(ctx context.Context, userID string) ([]models.Member, error) {
+	return ks.Store.GetMemberships(ctx, userID)
+}
+
+// ListMembers returns members of organization. Any member can list them.
+func (ks *KeeperService) ListMembers(ctx context.Context, userID string, orgID int64) ([]models.Member, error) {
+	if _, err := ks.memberRole(ctx, orgID, userID); err != nil {
+		return nil, err
+	}
+
+	return ks.Store.GetMembers(ctx, orgID)
+}
+
+// InviteMember invites user with username to organization with the given role.
+// Keys contain collection keys of organization wrapped with invitee's public key.
+// Owners and admins can invite, only owners can invite new owners.
+func (ks *KeeperService) InviteMember(ctx context.Context, userID string, orgID int64, username string, role models.Role, keys []CollectionKeyGrant) (int64, error) {
+	if !role.Valid() {
+		return 0, ErrInvalidRole
+	}
+
+	inviterRole, err := ks.memberRole(ctx, orgID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !inviterRole.CanManage() || (role == models.RoleOwner && inviterRole != models.RoleOwner) {
+		return 0, ErrInsufficientRole
+	}
+
+	invitee, err := ks.Store.GetUser(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := ks.Store.GetMember(ctx, orgID, invitee.ID); err == nil {
+		return 0, ErrAlreadyMember
+	}
+	if _, err := ks.GetUserKeys(ctx, invitee.ID); err != nil {
+		return 0, err
+	}
+
+	collectionKeys, err := ks.resolveCollectionKeys(ctx, orgID, keys)
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range collectionKeys {
+		if key.UserID != invitee.ID {
+			return 0, ErrAccessDenied
+		}
+	}
+
+	return ks.Store.AddInvitation(ctx, &models.Invitation{
+		OrgID:     orgID,
+		UserID:    invitee.ID,
+		Role:      role,
+		InvitedBy: userID,
+	}, collectionKeys)
+}
+
+// ListInvitations returns pending invitations of user.
+func (ks *KeeperService) ListInvitations(ctx context.Context, userID string) ([]models.Invitation, error) {
+	return ks.Store.GetInvitations(ctx, userID)
+}
+
+// AcceptInvitation makes user a member of the inviting organization.
+func (ks *KeeperService) AcceptInvitation(ctx context.Context, userID string, invitationID int64) error {
+	return ks.Store.AcceptInvitation(ctx, invitationID, userID)
+}
+
+// RemoveMember removes user with username from organization.
+// Any member can leave organization, owners and admins can remove other members,
+// only owners can remove owners. The last owner can't be removed.
+func (ks *KeeperService) RemoveMember(ctx context.Context, userID string, orgID int64, username string) error {
+	target, err := ks.targetMember(ctx, userID, orgID, username)
+	if err != nil {
+		return err
+	}
+
+	return ks.Store.RemoveMember(ctx, orgID, target.UserID)
+}
+
+// SetMemberRole changes role of user with username in organization.
+// Owners and admins can change roles of non-owners, only owners can grant or revoke owner role.
+func (ks *KeeperService) SetMemberRole(ctx context.Context, userID string, orgID int64, username string, role models.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+
+	managerRole, err := ks.memberRole(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !managerRole.CanManage() {
+		return ErrInsufficientRole
+	}
+
+	target, err := ks.memberByUsername(ctx, orgID, username)
+	if err != nil {
+		return err
+	}
+
+	if (target.Role == models.RoleOwner || role == models.RoleOwner) && managerRole != models.RoleOwner {
+		return ErrInsufficientRole
+	}
+	return ks.Store.SetMemberRole(ctx, orgID, target.UserID, role)
+}
+
+// CreateCollection creates a new collection in organization.
+// Keys contain the collection key wrapped for organization members.
+func (ks *KeeperService) CreateCollection(ctx context.Context, userID string, orgID int64, name string, keys []CollectionKeyGrant) (int64, error) {
+	role, err := ks.memberRole(ctx, orgID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !role.CanManage() {
+		return 0, ErrInsufficientRole
+	}
+
+	collectionKeys := make([]models.CollectionKey, 0, len(keys))
+	for _, key := range keys {
+		user, err := ks.Store.GetUser(ctx, key.Username)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := ks.Store.GetMember(ctx, orgID, user.ID); err != nil {
+			return 0, ErrNotMember
+		}
+		collectionKeys = append(collectionKeys, models.CollectionKey{
+			UserID:     user.ID,
+			WrappedKey: key.WrappedKey,
+		})
+	}
+
+	return ks.Store.CreateCollection(ctx, &models.Collection{OrgID: orgID, Name: name}, collectionKeys)
+}
+
+// GetCollectionKeys returns collection keys wrapped for user, of all organizations if orgID is 0.
+func (ks *KeeperService) GetCollectionKeys(ctx context.Context, userID string, orgID int64) ([]models.CollectionKey, error) {
+	if orgID != 0 {
+		if _, err := ks.memberRole(ctx, orgID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := ks.Store.GetCollectionKeys(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys of pending invitations are not available until the invitation is accepted.
+	memberships, err := ks.Store.GetMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	member := make(map[int64]bool, len(memberships))
+	for _, m := range memberships {
+		member[m.OrgID] = true
+	}
+
+	available := make([]models.CollectionKey, 0, len(keys))
+	for _, key := range keys {
+		if member[key.OrgID] {
+			available = append(available, key)
+		}
+	}
+
+	return available, nil
+}
+
+// GetCollectionSecrets returns secrets of collection. Any member of organization can read them.
+func (ks *KeeperService) GetCollectionSecrets(ctx context.Context, userID string, collectionID int64) ([]models.Secret, error) {
+	if _, err := ks.collectionRole(ctx, collectionID, userID); err != nil {
+		return nil, err
+	}
+
+	return ks.Store.GetCollectionSecrets(ctx, collectionID)
+}
+
+// AddCollectionSecret adds secret data to organization collection.
+// Secret data must be encrypted with the collection key. Empty secretID makes the server generate one.
+func (ks *KeeperService) AddCollectionSecret(ctx context.Context, userID string, collectionID int64, secretID, secretType, data, meta string, expiresAt time.Time) (string, error) {
+	secretID, err := checkSecretID(secretID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkSecretType(secretType); err != nil {
+		return "", err
+	}
+	if err := checkExpiry(expiresAt); err != nil {
+		return "", err
+	}
+
+	role, err := ks.collectionRole(ctx, collectionID, userID)
+	if err != nil {
+		return "", err
+	}
+	if !role.CanWrite() {
+		return "", ErrReadOnly
+	}
+
+	id, err := ks.Store.AddSecret(ctx, &models.Secret{
+		ID:           secretID,
+		Data:         data,
+		Meta:         meta,
+		UserID:       userID,
+		CollectionID: collectionID,
+		ExpiresAt:    expiresAt,
+		Type:         secretType,
+	}, ks.quota())
+	ks.Audit.Record(ctx, userID, audit.EventSecretCreate, err == nil,
+		fmt.Sprintf("secret id: %s, collection id: %d", id, collectionID))
+
+	return id, err
+}
+
+// checkWriteAccess checks that user is allowed to modify the secret.
+// Personal secrets can be modified by their owner only, collection secrets
+// by organization members with a writing role.
+func (ks *KeeperService) checkWriteAccess(ctx context.Context, secret *models.Secret, userID string) error {
+	if secret.CollectionID != 0 {
+		role, err := ks.collectionRole(ctx, secret.CollectionID, userID)
+		if err != nil {
+			return err
+		}
+		if !role.CanWrite() {
+			return ErrReadOnly
+		}
+		return nil
+	}
+
+	if secret.UserID != userID {
+		// Recipients of a shared secret have read-only access.
+		shared, err := ks.Store.IsSecretSharedWith(ctx, secret.ID, userID)
+		if err != nil {
+			return err
+		}
+		if shared {
+			return ErrReadOnly
+		}
+		return ErrAccessDenied
+	}
+
+	return nil
+}
+
+// memberRole returns user's role in organization.
+func (ks *KeeperService) memberRole(ctx context.Context, orgID int64, userID string) (models.Role, error) {
+	member, err := ks.Store.GetMember(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			return "", ErrNotMember
+		}
+		return "", err
+	}
+
+	return member.Role, nil
+}
+
+// collectionRole returns user's role in organization owning the collection.
+func (ks *KeeperService) collectionRole(ctx context.Context, collectionID int64, userID string) (models.Role, error) {
+	collection, err := ks.Store.GetCollection(ctx, collectionID)
+	if err != nil {
+		if errors.Is(err, storage.ErrCollectionNotFound) {
+			return "", ErrNotMember
+		}
+		return "", err
+	}
+
+	return ks.memberRole(ctx, collection.OrgID, userID)
+}
+
+// memberByUsername returns member of organization by his username.
+func (ks *KeeperService) memberByUsername(ctx context.Context, orgID int64, username string) (models.Member, error) {
+	user, err := ks.Store.GetUser(ctx, username)
+	if err != nil {
+		return models.Member{}, err
+	}
+
+	return ks.Store.GetMember(ctx, orgID, user.ID)
+}
+
+// targetMember returns member to be removed by user and checks user's right to remove him.
+func (ks *KeeperService) targetMember(ctx context.Context, userID string, orgID int64, username string) (models.Member, error) {
+	role, err := ks.memberRole(ctx, orgID, userID)
+	if err != nil {
+		return models.Member{}, err
+	}
+
+	target, err := ks.memberByUsername(ctx, orgID, username)
+	if err != nil {
+		return models.Member{}, err
+	}
+
+	if target.UserID == userID {
+		return target, nil
+	}
+	if !role.CanManage() || (target.Role == models.RoleOwner && role != models.RoleOwner) {
+		return models.Member{}, ErrInsufficientRole
+	}
+
+	return target, nil
+}
+
+// resolveCollectionKeys converts key grants to collection keys checking that
+// collections belong to organization.
+func (ks *KeeperService) resolveCollectionKeys(ctx context.Context, orgID int64, keys []CollectionKeyGrant) ([]models.CollectionKey, error) {
+	collectionKeys := make([]models.CollectionKey, 0, len(keys))
+	for _, key := range keys {
+		collection, err := ks.Store.GetCollection(ctx, key.CollectionID)
+		if err != nil {
+			return nil, err
+		}
+		if collection.OrgID != orgID {
+			return nil, ErrAccessDenied
+		}
+
+		user, err := ks.Store.GetUser(ctx, key.Username)
+		if err != nil {
+			return nil, err
+		}
+
+		collectionKeys = append(collectionKeys, models.CollectionKey{
+			CollectionID: key.CollectionID,
+			UserID:       user.ID,
+			WrappedKey:   key.WrappedKey,
+		})
+	}
+
+	return collectionKeys, nil
+}
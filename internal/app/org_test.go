@@ -0,0 +1,126 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registerWithKeys registers a user with sharing keys and returns his ID.
+func registerWithKeys(t *testing.T, svc *app.KeeperService, username string) string {
+	token, err := svc.Register(context.Background(), username, "password")
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+	require.NoError(t, svc.SetUserKeys(context.Background(), userID, "public-"+username, "private-"+username))
+	return userID
+}
+
+// Test case: organization members access collection secrets according to their roles.
+func TestOrganizationRoles(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ownerID := registerWithKeys(t, svc, "owner")
+	memberID := registerWithKeys(t, svc, "member")
+	readerID := registerWithKeys(t, svc, "reader")
+	outsiderID := registerWithKeys(t, svc, "outsider")
+
+	orgID, err := svc.CreateOrganization(ctx, ownerID, "team")
+	require.NoError(t, err)
+
+	collectionID, err := svc.CreateCollection(ctx, ownerID, orgID, "servers", []app.CollectionKeyGrant{
+		{Username: "owner", WrappedKey: "key-owner"},
+	})
+	require.NoError(t, err)
+
+	// Only owner can invite new owners, outsiders can't invite at all.
+	_, err = svc.InviteMember(ctx, outsiderID, orgID, "member", models.RoleMember, nil)
+	require.ErrorIs(t, err, app.ErrNotMember)
+	_, err = svc.InviteMember(ctx, ownerID, orgID, "member", "superuser", nil)
+	require.ErrorIs(t, err, app.ErrInvalidRole)
+
+	for _, invite := range []struct {
+		username string
+		userID   string
+		role     models.Role
+	}{
+		{"member", memberID, models.RoleMember},
+		{"reader", readerID, models.RoleReadOnly},
+	} {
+		invID, err := svc.InviteMember(ctx, ownerID, orgID, invite.username, invite.role, []app.CollectionKeyGrant{
+			{CollectionID: collectionID, Username: invite.username, WrappedKey: "key-" + invite.username},
+		})
+		require.NoError(t, err)
+
+		// Keys are not available before the invitation is accepted.
+		keys, err := svc.GetCollectionKeys(ctx, invite.userID, 0)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		invitations, err := svc.ListInvitations(ctx, invite.userID)
+		require.NoError(t, err)
+		require.Len(t, invitations, 1)
+		assert.Equal(t, "owner", invitations[0].InvitedBy)
+
+		require.NoError(t, svc.AcceptInvitation(ctx, invite.userID, invID))
+
+		keys, err = svc.GetCollectionKeys(ctx, invite.userID, orgID)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Equal(t, "key-"+invite.username, keys[0].WrappedKey)
+	}
+
+	// Member can't invite, reader can't write.
+	_, err = svc.InviteMember(ctx, memberID, orgID, "outsider", models.RoleMember, nil)
+	require.ErrorIs(t, err, app.ErrInsufficientRole)
+
+	id, err := svc.AddCollectionSecret(ctx, memberID, collectionID, "", "", "data", "meta", time.Time{})
+	require.NoError(t, err)
+	_, err = svc.AddCollectionSecret(ctx, readerID, collectionID, "", "", "data", "meta", time.Time{})
+	require.ErrorIs(t, err, app.ErrReadOnly)
+	_, err = svc.AddCollectionSecret(ctx, outsiderID, collectionID, "", "", "data", "meta", time.Time{})
+	require.ErrorIs(t, err, app.ErrNotMember)
+
+	// Any writing member can edit collection secret.
+	require.NoError(t, svc.EditSecret(ctx, id, ownerID, "", "new data", "new meta", nil, nil, nil))
+	require.ErrorIs(t, svc.EditSecret(ctx, id, readerID, "", "data", "meta", nil, nil, nil), app.ErrReadOnly)
+	require.ErrorIs(t, svc.EditSecret(ctx, id, outsiderID, "", "data", "meta", nil, nil, nil), app.ErrNotMember)
+
+	secrets, err := svc.GetCollectionSecrets(ctx, readerID, collectionID)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "new data", secrets[0].Data)
+
+	// Collection secrets are not listed as personal secrets.
+	personal, err := svc.GetSecrets(ctx, memberID)
+	require.NoError(t, err)
+	assert.Empty(t, personal)
+
+	// Last owner can't leave, reader loses access after removal.
+	require.ErrorIs(t, svc.RemoveMember(ctx, ownerID, orgID, "owner"), storage.ErrLastOwner)
+	require.ErrorIs(t, svc.RemoveMember(ctx, memberID, orgID, "reader"), app.ErrInsufficientRole)
+	require.NoError(t, svc.RemoveMember(ctx, ownerID, orgID, "reader"))
+	_, err = svc.GetCollectionSecrets(ctx, readerID, collectionID)
+	require.ErrorIs(t, err, app.ErrNotMember)
+
+	// Promoted member can manage the organization.
+	require.ErrorIs(t, svc.SetMemberRole(ctx, memberID, orgID, "member", models.RoleOwner), app.ErrInsufficientRole)
+	require.NoError(t, svc.SetMemberRole(ctx, ownerID, orgID, "member", models.RoleAdmin))
+	_, err = svc.InviteMember(ctx, memberID, orgID, "outsider", models.RoleReadOnly, nil)
+	require.NoError(t, err)
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+)
+
+// quota returns per-user storage limits from the configuration.
+// Without configuration storage is unlimited.
+func (ks *KeeperService) quota() models.Quota {
+	if ks.Cfg == nil {
+		return models.Quota{}
+	}
+
+	return models.Quota{
+		MaxSecrets:     ks.Cfg.Quota.MaxSecrets,
+		MaxSecretBytes: ks.Cfg.Quota.MaxSecretBytes,
+		MaxTotalBytes:  ks.Cfg.Quota.MaxTotalBytes,
+	}
+}
+
+// GetUsage retrieves user's storage usage and quota.
+func (ks *KeeperService) GetUsage(ctx context.Context, userID string) (models.Usage, models.Quota, error) {
+	usage, err := ks.Store.GetUsage(ctx, userID)
+	if err != nil {
+		return models.Usage{}, models.Quota{}, err
+	}
+
+	return usage, ks.quota(), nil
+}
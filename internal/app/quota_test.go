@@ -0,0 +1,67 @@
+package app_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/config"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: secrets over the configured quota are rejected.
+func TestQuota(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+		Cfg: &config.Config{Quota: config.QuotaConfig{
+			MaxSecrets:     2,
+			MaxSecretBytes: 10,
+			MaxTotalBytes:  15,
+		}},
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+
+	// Single secret is too large.
+	_, err = svc.AddSecret(ctx, userID, "", "", strings.Repeat("x", 11), "", time.Time{}, nil)
+	assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+
+	id, err := svc.AddSecret(ctx, userID, "", "", "12345678", "", time.Time{}, nil)
+	require.NoError(t, err)
+
+	// Total size would be 16 bytes.
+	_, err = svc.AddSecret(ctx, userID, "", "", "12345678", "", time.Time{}, nil)
+	assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+
+	_, err = svc.AddSecret(ctx, userID, "", "", "1234567", "", time.Time{}, nil)
+	require.NoError(t, err)
+
+	// Count limit is reached.
+	_, err = svc.AddSecret(ctx, userID, "", "", "1", "", time.Time{}, nil)
+	assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+
+	// Growing a secret over the total size fails, shrinking succeeds.
+	err = svc.EditSecret(ctx, id, userID, "", "123456789", "", nil, nil, nil)
+	assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+	require.NoError(t, svc.EditSecret(ctx, id, userID, "", "1", "2", nil, nil, nil))
+
+	usage, quota, err := svc.GetUsage(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), usage.Secrets)
+	assert.Equal(t, int64(9), usage.Bytes)
+	assert.Equal(t, int64(15), quota.MaxTotalBytes)
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// Blind index limits.
+const (
+	MaxSearchTokens   = 64 // Maximum number of search tokens of a secret
+	searchTokenLength = 64 // Length of a hex encoded HMAC-SHA256 token
+)
+
+// ErrInvalidSearchTokens is returned when search tokens are not hex encoded HMAC-SHA256 values or too many.
+var ErrInvalidSearchTokens = storage.NewError(storage.KindInvalidArgument, "invalid search tokens: expected at most 64 hex encoded HMAC-SHA256 values")
+
+// checkSearchTokens checks the number and format of blind index tokens.
+func checkSearchTokens(tokens []string) error {
+	if len(tokens) > MaxSearchTokens {
+		return ErrInvalidSearchTokens
+	}
+	for _, token := range tokens {
+		if len(token) != searchTokenLength {
+			return ErrInvalidSearchTokens
+		}
+		for _, c := range token {
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+				return ErrInvalidSearchTokens
+			}
+		}
+	}
+	return nil
+}
+
+// SearchSecrets retrieves user's secrets having all the blind index tokens.
+// Tokens are computed on the client so the server filters secrets without seeing their plaintext.
+func (ks *KeeperService) SearchSecrets(ctx context.Context, userID string, tokens []string) ([]models.Secret, error) {
+	if len(tokens) == 0 {
+		return nil, ErrInvalidSearchTokens
+	}
+	if err := checkSearchTokens(tokens); err != nil {
+		return nil, err
+	}
+
+	secrets, err := ks.Store.SearchSecrets(ctx, userID, tokens)
+	if err != nil {
+		return nil, err
+	}
+	ks.Audit.RecordRead(ctx, userID, audit.EventSecretSearch, true,
+		fmt.Sprintf("tokens: %d, secrets: %d", len(tokens), len(secrets)))
+
+	return secrets, nil
+}
@@ -0,0 +1,71 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: secrets are found by blind index tokens of their fields.
+func TestSearchSecrets(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+
+	key := encryption.BlindIndexKey("master")
+	alice := encryption.BlindIndex(key, "login", "alice")
+	bob := encryption.BlindIndex(key, "login", "bob")
+	prod := encryption.BlindIndex(key, "tag", "prod")
+
+	_, err = svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Time{}, []string{"not a token"})
+	require.ErrorIs(t, err, app.ErrInvalidSearchTokens)
+
+	aliceID, err := svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Time{}, []string{alice, prod})
+	require.NoError(t, err)
+	bobID, err := svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Time{}, []string{bob, prod})
+	require.NoError(t, err)
+
+	secrets, err := svc.SearchSecrets(ctx, userID, []string{prod})
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+
+	secrets, err = svc.SearchSecrets(ctx, userID, []string{alice, prod})
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, aliceID, secrets[0].ID)
+
+	// Editing replaces the tokens.
+	require.NoError(t, svc.EditSecret(ctx, bobID, userID, "", "data", "meta", nil, []string{alice}, nil))
+	secrets, err = svc.SearchSecrets(ctx, userID, []string{alice})
+	require.NoError(t, err)
+	assert.Len(t, secrets, 2)
+	secrets, err = svc.SearchSecrets(ctx, userID, []string{bob})
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	// Other users don't see the secrets.
+	secrets, err = svc.SearchSecrets(ctx, "other", []string{alice})
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	_, err = svc.SearchSecrets(ctx, userID, nil)
+	require.ErrorIs(t, err, app.ErrInvalidSearchTokens)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSecretID is returned when the secret id is neither a UUID nor a legacy integer id.
+var ErrInvalidSecretID = storage.NewError(storage.KindInvalidArgument, "invalid secret id: must be a UUID")
+
+// checkSecretID checks the id of a new secret generated by the client and returns its canonical form.
+// Empty id is kept, the storage generates a random one.
+func checkSecretID(id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil || parsed == uuid.Nil {
+		return "", ErrInvalidSecretID
+	}
+	return parsed.String(), nil
+}
+
+// getSecret returns the secret by its UUID or by the integer id it had before secret ids became UUIDs.
+func (ks *KeeperService) getSecret(ctx context.Context, id string) (*models.Secret, error) {
+	if parsed, err := uuid.Parse(id); err == nil {
+		return ks.Store.GetSecretByID(ctx, parsed.String())
+	}
+
+	legacyID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || legacyID <= 0 {
+		return nil, ErrInvalidSecretID
+	}
+	return ks.Store.GetSecretByLegacyID(ctx, legacyID)
+}
+
+// resolveSecretID returns the canonical UUID of an existing secret, looking up legacy integer ids.
+func (ks *KeeperService) resolveSecretID(ctx context.Context, id string) (string, error) {
+	if parsed, err := uuid.Parse(id); err == nil {
+		return parsed.String(), nil
+	}
+
+	secret, err := ks.getSecret(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return secret.ID, nil
+}
@@ -0,0 +1,84 @@
+package app_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: secrets keep ids generated by the client and are found by legacy integer ids.
+func TestSecretIDs(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+		Audit: audit.NewLogger(fakeStore, nil, 0),
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+
+	// Client ids are saved in the canonical form.
+	clientID := uuid.New().String()
+	id, err := svc.AddSecret(ctx, userID, strings.ToUpper(clientID), "", "data", "meta", time.Time{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, clientID, id)
+
+	_, err = svc.AddSecret(ctx, userID, clientID, "", "data", "meta", time.Time{}, nil)
+	require.ErrorIs(t, err, storage.ErrSecretExists)
+	for _, invalid := range []string{"42", "not a uuid", uuid.Nil.String()} {
+		_, err = svc.AddSecret(ctx, userID, invalid, "", "data", "meta", time.Time{}, nil)
+		require.ErrorIs(t, err, app.ErrInvalidSecretID, invalid)
+	}
+
+	generated, err := svc.AddSecret(ctx, userID, "", "", "data", "meta", time.Time{}, nil)
+	require.NoError(t, err)
+	_, err = uuid.Parse(generated)
+	require.NoError(t, err)
+
+	// Secrets created before UUIDs are selected by their integer ids.
+	legacyID, err := fakeStore.AddSecret(ctx, &models.Secret{LegacyID: 42, UserID: userID, Data: "legacy"}, models.Quota{})
+	require.NoError(t, err)
+	require.NoError(t, svc.EditSecret(ctx, "42", userID, "", "edited", "meta", nil, nil, nil))
+	legacy, err := fakeStore.GetSecretByID(ctx, legacyID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", legacy.Data)
+
+	// A single secret is returned to its owner only.
+	legacy, err = svc.GetSecret(ctx, userID, "42")
+	require.NoError(t, err)
+	assert.Equal(t, legacyID, legacy.ID)
+	_, err = svc.GetSecret(ctx, uuid.New().String(), legacyID)
+	require.ErrorIs(t, err, app.ErrAccessDenied)
+	_, err = svc.GetSecret(ctx, userID, uuid.New().String())
+	require.ErrorIs(t, err, storage.ErrSecretNotFound)
+
+	require.ErrorIs(t, svc.EditSecret(ctx, "43", userID, "", "data", "meta", nil, nil, nil), storage.ErrSecretNotFound)
+	require.ErrorIs(t, svc.EditSecret(ctx, "-1", userID, "", "data", "meta", nil, nil, nil), app.ErrInvalidSecretID)
+
+	results, committed, err := svc.MutateSecrets(ctx, userID, []models.SecretMutation{
+		{Op: models.MutationDelete, Secret: models.Secret{ID: "42"}},
+		{Op: models.MutationDelete, Secret: models.Secret{ID: "not a uuid"}},
+	}, false)
+	require.NoError(t, err)
+	assert.True(t, committed)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, legacyID, results[0].ID)
+	assert.ErrorIs(t, results[1].Err, app.ErrInvalidSecretID)
+}
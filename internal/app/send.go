@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// Send limits.
+const (
+	MaxSendViews    = 100                 // Maximum number of views of a send
+	MaxSendLifetime = 30 * 24 * time.Hour // Maximum time a send is kept
+	sendIDBytes     = 16                  // Number of random bytes in a send id
+)
+
+// ErrInvalidSend is returned when a send has invalid view limit or expiration time.
+var ErrInvalidSend = storage.NewError(storage.KindInvalidArgument, "invalid send: views must be 1-100 and expiration within 30 days")
+
+// CreateSend saves the ciphertext of a send created by user and returns its random id.
+// The send is deleted after maxViews openings or at expiresAt, whichever comes first.
+// Until then its ciphertext counts against user's quota of total size.
+func (ks *KeeperService) CreateSend(ctx context.Context, userID, ciphertext string, maxViews int, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	if maxViews < 1 || maxViews > MaxSendViews || !expiresAt.After(now) || expiresAt.After(now.Add(MaxSendLifetime)) {
+		return "", ErrInvalidSend
+	}
+
+	if limit := ks.quota().MaxSecretBytes; limit > 0 && int64(len(ciphertext)) > limit {
+		return "", fmt.Errorf("%w: send of %d bytes exceeds the limit of %d bytes",
+			storage.ErrQuotaExceeded, len(ciphertext), limit)
+	}
+
+	id, err := newSendID()
+	if err != nil {
+		return "", err
+	}
+
+	err = ks.Store.AddSend(ctx, &models.Send{
+		ID:         id,
+		OwnerID:    userID,
+		Ciphertext: ciphertext,
+		ViewsLeft:  maxViews,
+		ExpiresAt:  expiresAt,
+	}, ks.quota())
+	ks.Audit.Record(ctx, userID, audit.EventSendCreate, err == nil, fmt.Sprintf("views: %d, expires at %s", maxViews, expiresAt.Format(time.RFC3339)))
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// OpenSend consumes one view of the send and returns it.
+// Opening doesn't require authentification, knowing the id is enough to get the ciphertext.
+func (ks *KeeperService) OpenSend(ctx context.Context, id string) (models.Send, error) {
+	send, err := ks.Store.OpenSend(ctx, id, time.Now())
+	if err != nil {
+		ks.Audit.Record(ctx, "", audit.EventSendOpen, false, err.Error())
+		return models.Send{}, err
+	}
+	ks.Audit.Record(ctx, send.OwnerID, audit.EventSendOpen, true, fmt.Sprintf("views left: %d", send.ViewsLeft))
+
+	return send, nil
+}
+
+// newSendID generates a random URL-safe send id.
+func newSendID() (string, error) {
+	b := make([]byte, sendIDBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,89 @@
+package app_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: a send is opened at most max views times and then burned.
+func TestSend(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, generateStr(), generateStr())
+	require.NoError(t, err)
+	userID := auth.GetUserID(token)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	// View limit and expiration time are checked.
+	_, err = svc.CreateSend(ctx, userID, "ciphertext", 0, expiresAt)
+	require.ErrorIs(t, err, app.ErrInvalidSend)
+	_, err = svc.CreateSend(ctx, userID, "ciphertext", 1, time.Now().Add(-time.Minute))
+	require.ErrorIs(t, err, app.ErrInvalidSend)
+	_, err = svc.CreateSend(ctx, userID, "ciphertext", 1, time.Now().Add(app.MaxSendLifetime+time.Hour))
+	require.ErrorIs(t, err, app.ErrInvalidSend)
+
+	id, err := svc.CreateSend(ctx, userID, "ciphertext", 2, expiresAt)
+	require.NoError(t, err)
+
+	send, err := svc.OpenSend(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "ciphertext", send.Ciphertext)
+	assert.Equal(t, 1, send.ViewsLeft)
+
+	send, err = svc.OpenSend(ctx, id)
+	require.NoError(t, err)
+	assert.Zero(t, send.ViewsLeft)
+
+	_, err = svc.OpenSend(ctx, id)
+	require.ErrorIs(t, err, storage.ErrSendNotFound)
+
+	// Concurrent openings can't exceed the view limit.
+	id, err = svc.CreateSend(ctx, userID, "ciphertext", 3, expiresAt)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	opened := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.OpenSend(ctx, id); err == nil {
+				mu.Lock()
+				opened++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 3, opened)
+
+	// Expired sends can't be opened and are deleted by the reaper.
+	id, err = svc.CreateSend(ctx, userID, "ciphertext", 1, expiresAt)
+	require.NoError(t, err)
+
+	n, err := fakeStore.DeleteExpiredSends(ctx, expiresAt.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = svc.OpenSend(ctx, id)
+	require.ErrorIs(t, err, storage.ErrSendNotFound)
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// ErrReadOnly is returned when recipient of a shared secret tries to modify it.
+var ErrReadOnly = storage.NewError(storage.KindPermissionDenied, "access denied: secret is shared read-only")
+
+// ErrNoPublicKey is returned when user has not uploaded his sharing keys yet.
+var ErrNoPublicKey = storage.NewError(storage.KindFailedPrecondition, "user has no sharing keys")
+
+// ErrShareWithSelf is returned when user tries to share a secret with himself.
+var ErrShareWithSelf = storage.NewError(storage.KindInvalidArgument, "secret can't be shared with its owner")
+
+// SetUserKeys saves user's sharing keypair.
+// The private key must be encrypted on the client with user's encryption key.
+func (ks *KeeperService) SetUserKeys(ctx context.Context, userID, publicKey, encryptedPrivateKey string) error {
+	return ks.Store.SetUserKeys(ctx, &models.UserKeys{
+		UserID:              userID,
+		PublicKey:           publicKey,
+		EncryptedPrivateKey: encryptedPrivateKey,
+	})
+}
+
+// GetUserKeys returns user's sharing keypair.
+func (ks *KeeperService) GetUserKeys(ctx context.Context, userID string) (*models.UserKeys, error) {
+	keys, err := ks.Store.GetUserKeys(ctx, userID)
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return nil, ErrNoPublicKey
+	}
+	return keys, err
+}
+
+// GetPublicKey returns public key of the user with username.
+func (ks *KeeperService) GetPublicKey(ctx context.Context, username string) (string, error) {
+	user, err := ks.Store.GetUser(ctx, username)
+	if err != nil {
+		return "", err
+	}
+
+	keys, err := ks.GetUserKeys(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	return keys.PublicKey, nil
+}
+
+// ShareSecret shares user's secret with recipient.
+// Data and meta are encrypted with a data key which is wrapped with recipient's public key.
+// Sharing the same secret again with the same recipient replaces the previous share.
+func (ks *KeeperService) ShareSecret(ctx context.Context, userID, secretID, recipient, wrappedKey, data, meta string) (int64, error) {
+	secret, err := ks.getSecret(ctx, secretID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Collection secrets are shared through organization membership only.
+	if secret.UserID != userID || secret.CollectionID != 0 {
+		return 0, ErrAccessDenied
+	}
+
+	user, err := ks.Store.GetUser(ctx, recipient)
+	if err != nil {
+		return 0, err
+	}
+
+	if user.ID == userID {
+		return 0, ErrShareWithSelf
+	}
+
+	if _, err := ks.GetUserKeys(ctx, user.ID); err != nil {
+		return 0, err
+	}
+
+	id, err := ks.Store.AddShare(ctx, &models.Share{
+		SecretID:    secret.ID,
+		OwnerID:     userID,
+		RecipientID: user.ID,
+		WrappedKey:  wrappedKey,
+		Data:        data,
+		Meta:        meta,
+	})
+	ks.Audit.Record(ctx, userID, audit.EventShareCreate, err == nil,
+		fmt.Sprintf("secret id: %s, recipient: %s", secret.ID, recipient))
+
+	return id, err
+}
+
+// ShareRecipient is a user a secret is shared with and his public key to re-encrypt the edited secret for.
+type ShareRecipient struct {
+	SecretID  string
+	Username  string
+	PublicKey string
+}
+
+// ListShares returns recipients of user's secrets with their public keys, of the secret with secretID if it is set.
+func (ks *KeeperService) ListShares(ctx context.Context, userID, secretID string) ([]ShareRecipient, error) {
+	if secretID != "" {
+		secret, err := ks.getSecret(ctx, secretID)
+		if err != nil {
+			return nil, err
+		}
+		if secret.UserID != userID {
+			return nil, ErrAccessDenied
+		}
+		secretID = secret.ID
+	}
+
+	shares, err := ks.Store.GetSharesForOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make([]ShareRecipient, 0, len(shares))
+	for _, share := range shares {
+		if secretID != "" && share.SecretID != secretID {
+			continue
+		}
+		keys, err := ks.GetUserKeys(ctx, share.RecipientID)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, ShareRecipient{
+			SecretID:  share.SecretID,
+			Username:  share.RecipientName,
+			PublicKey: keys.PublicKey,
+		})
+	}
+
+	return recipients, nil
+}
+
+// shareCopies resolves recipients of the copies of an edited secret by their usernames.
+func (ks *KeeperService) shareCopies(ctx context.Context, shares []models.Share) ([]models.Share, error) {
+	copies := make([]models.Share, 0, len(shares))
+	for _, share := range shares {
+		user, err := ks.Store.GetUser(ctx, share.RecipientName)
+		if err != nil {
+			return nil, err
+		}
+		share.RecipientID = user.ID
+		copies = append(copies, share)
+	}
+
+	return copies, nil
+}
+
+// ListSharedWithMe retrieves all secrets shared with user.
+func (ks *KeeperService) ListSharedWithMe(ctx context.Context, userID string) ([]models.Share, error) {
+	return ks.Store.GetSharesForRecipient(ctx, userID)
+}
+
+// RevokeShare revokes recipient's access to user's secret.
+func (ks *KeeperService) RevokeShare(ctx context.Context, userID, secretID, recipient string) error {
+	secret, err := ks.getSecret(ctx, secretID)
+	if err != nil {
+		return err
+	}
+
+	if secret.UserID != userID {
+		return ErrAccessDenied
+	}
+
+	user, err := ks.Store.GetUser(ctx, recipient)
+	if err != nil {
+		return err
+	}
+
+	err = ks.Store.DeleteShare(ctx, secret.ID, user.ID)
+	ks.Audit.Record(ctx, userID, audit.EventShareRevoke, err == nil,
+		fmt.Sprintf("secret id: %s, recipient: %s", secret.ID, recipient))
+
+	return err
+}
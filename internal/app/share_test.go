@@ -0,0 +1,111 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: owner shares a secret, recipient reads it but can't modify it.
+func TestShareSecret(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := &app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("testsecret", "1h")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := svc.Register(ctx, "owner", "password")
+	require.NoError(t, err)
+	ownerID := auth.GetUserID(token)
+
+	token, err = svc.Register(ctx, "recipient", "password")
+	require.NoError(t, err)
+	recipientID := auth.GetUserID(token)
+
+	id, err := svc.AddSecret(ctx, ownerID, "", "", "data", "meta", time.Time{}, nil)
+	require.NoError(t, err)
+
+	// Recipient has no keys yet.
+	_, err = svc.ShareSecret(ctx, ownerID, id, "recipient", "wrapped", "shared data", "shared meta")
+	require.ErrorIs(t, err, app.ErrNoPublicKey)
+
+	err = svc.SetUserKeys(ctx, recipientID, "public", "private")
+	require.NoError(t, err)
+
+	key, err := svc.GetPublicKey(ctx, "recipient")
+	require.NoError(t, err)
+	assert.Equal(t, "public", key)
+
+	// Only the owner can share the secret.
+	_, err = svc.ShareSecret(ctx, recipientID, id, "owner", "wrapped", "shared data", "shared meta")
+	require.ErrorIs(t, err, app.ErrAccessDenied)
+
+	_, err = svc.ShareSecret(ctx, ownerID, id, "owner", "wrapped", "shared data", "shared meta")
+	require.ErrorIs(t, err, app.ErrShareWithSelf)
+
+	_, err = svc.ShareSecret(ctx, ownerID, id, "recipient", "wrapped", "shared data", "shared meta")
+	require.NoError(t, err)
+
+	shares, err := svc.ListSharedWithMe(ctx, recipientID)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	assert.Equal(t, id, shares[0].SecretID)
+	assert.Equal(t, "owner", shares[0].OwnerName)
+	assert.Equal(t, "wrapped", shares[0].WrappedKey)
+	assert.Equal(t, "shared data", shares[0].Data)
+
+	// Recipient has read-only access.
+	err = svc.EditSecret(ctx, id, recipientID, "", "new data", "new meta", nil, nil, nil)
+	require.ErrorIs(t, err, app.ErrReadOnly)
+
+	// Sharing again replaces recipient's copy.
+	_, err = svc.ShareSecret(ctx, ownerID, id, "recipient", "wrapped2", "updated data", "updated meta")
+	require.NoError(t, err)
+	shares, err = svc.ListSharedWithMe(ctx, recipientID)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	assert.Equal(t, "updated data", shares[0].Data)
+
+	// Owner gets the recipients to re-encrypt the secret for, others can't.
+	recipients, err := svc.ListShares(ctx, ownerID, id)
+	require.NoError(t, err)
+	assert.Equal(t, []app.ShareRecipient{{SecretID: id, Username: "recipient", PublicKey: "public"}}, recipients)
+	_, err = svc.ListShares(ctx, recipientID, id)
+	require.ErrorIs(t, err, app.ErrAccessDenied)
+
+	// Owner's edit replaces recipient's copy and fails without it.
+	err = svc.EditSecret(ctx, id, ownerID, "", "edited data", "edited meta", nil, nil, nil)
+	require.ErrorIs(t, err, storage.ErrSharesOutdated)
+	err = svc.EditSecret(ctx, id, ownerID, "", "edited data", "edited meta", nil, nil, []models.Share{
+		{RecipientName: "recipient", WrappedKey: "wrapped3", Data: "edited shared data", Meta: "edited shared meta"},
+	})
+	require.NoError(t, err)
+	shares, err = svc.ListSharedWithMe(ctx, recipientID)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	assert.Equal(t, "edited shared data", shares[0].Data)
+	assert.Equal(t, "wrapped3", shares[0].WrappedKey)
+
+	err = svc.RevokeShare(ctx, ownerID, id, "recipient")
+	require.NoError(t, err)
+
+	shares, err = svc.ListSharedWithMe(ctx, recipientID)
+	require.NoError(t, err)
+	assert.Empty(t, shares)
+
+	// After revoke recipient has no access at all.
+	err = svc.EditSecret(ctx, id, recipientID, "", "new data", "new meta", nil, nil, nil)
+	require.ErrorIs(t, err, app.ErrAccessDenied)
+}
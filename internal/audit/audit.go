@@ -0,0 +1,214 @@
+// Package audit provides the append-only audit log of security-relevant events.
+// Events are written by the gRPC interceptors and by the business logic layer.
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Audit event types.
+const (
+	EventRegister      = "user.register"
+	EventLoginSuccess  = "login.success"
+	EventLoginFailure  = "login.failure"
+	EventTokenIssued   = "token.issued"
+	EventTokenRejected = "token.rejected"
+	EventSecretCreate  = "secret.create"
+	EventSecretEdit    = "secret.edit"
+	EventSecretDelete  = "secret.delete"
+	EventSecretRead    = "secret.read"
+	EventSecretList    = "secret.list"
+	EventSecretSearch  = "secret.search"
+	EventSecretBatch   = "secret.batch"
+	EventShareCreate   = "share.create"
+	EventShareRevoke   = "share.revoke"
+	EventAccountExport = "account.export"
+	EventAccountDelete = "account.delete"
+	EventSendCreate    = "send.create"
+	EventSendOpen      = "send.open"
+)
+
+// contextKey defines a type of a key for storing request info in context.
+type contextKey string
+
+// requestInfoKey is the key in context where the interceptor stores request info.
+const requestInfoKey contextKey = "auditRequestInfo"
+
+// requestInfo describes the client and the method of a gRPC request.
+type requestInfo struct {
+	method    string
+	peerIP    string
+	userAgent string
+}
+
+// Logger writes audit events to the storage.
+// A nil Logger discards all events.
+type Logger struct {
+	store storage.Storage
+
+	signingKey ed25519.PrivateKey // Key signing checkpoints, nil disables checkpoints
+	interval   int64              // Number of events between checkpoints
+
+	mu      sync.Mutex
+	pending int64 // Number of events written since the last checkpoint
+
+	queue   chan *models.AuditEvent // Read events waiting for the background writer
+	running atomic.Bool             // Whether Run writes queued events
+}
+
+// queueSize is the number of read events queued for the background writer.
+// Read events are written synchronously when the queue is full.
+const queueSize = 1024
+
+// NewLogger creates a new audit logger writing to the storage.
+// Every interval events the logger saves a checkpoint signed with signingKey.
+// Checkpoints are disabled if signingKey is nil or interval is not positive.
+func NewLogger(store storage.Storage, signingKey ed25519.PrivateKey, interval int64) *Logger {
+	return &Logger{
+		store:      store,
+		signingKey: signingKey,
+		interval:   interval,
+		queue:      make(chan *models.AuditEvent, queueSize),
+	}
+}
+
+// Record appends an event to the audit log adding the request info saved in ctx by the interceptor.
+// Failures to write the audit log are logged and don't affect the request.
+// The event is written even if the request is cancelled, since the attempt itself is auditable.
+func (l *Logger) Record(ctx context.Context, userID, eventType string, success bool, details string) {
+	if l == nil {
+		return
+	}
+
+	l.write(ctx, newEvent(ctx, userID, eventType, success, details))
+}
+
+// RecordRead records an event of reading secrets like Record, but in background while Run is running,
+// so that reads don't wait for the lock serializing appends to the audit log.
+func (l *Logger) RecordRead(ctx context.Context, userID, eventType string, success bool, details string) {
+	if l == nil {
+		return
+	}
+
+	event := newEvent(ctx, userID, eventType, success, details)
+	if l.running.Load() {
+		select {
+		case l.queue <- event:
+			return
+		default:
+		}
+	}
+	l.write(ctx, event)
+}
+
+// Run writes events queued by RecordRead until ctx is done.
+// Events queued before ctx is done are written before Run returns.
+func (l *Logger) Run(ctx context.Context) {
+	l.running.Store(true)
+	defer l.running.Store(false)
+
+	for {
+		select {
+		case event := <-l.queue:
+			l.write(ctx, event)
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-l.queue:
+					l.write(ctx, event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// newEvent creates an event adding the request info saved in ctx by the interceptor.
+func newEvent(ctx context.Context, userID, eventType string, success bool, details string) *models.AuditEvent {
+	event := &models.AuditEvent{
+		UserID:    userID,
+		Type:      eventType,
+		Success:   success,
+		Details:   details,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if info, ok := ctx.Value(requestInfoKey).(requestInfo); ok {
+		event.Method = info.method
+		event.PeerIP = info.peerIP
+		event.UserAgent = info.userAgent
+	}
+
+	return event
+}
+
+// write appends event to the audit log and saves a checkpoint if it's due.
+func (l *Logger) write(ctx context.Context, event *models.AuditEvent) {
+	ctx = context.WithoutCancel(ctx)
+	if err := l.store.AddAuditEvent(ctx, event); err != nil {
+		logging.Sugar.Errorw("Failed to write audit event", "type", event.Type, "error", err)
+		return
+	}
+
+	l.checkpoint(ctx, event)
+}
+
+// checkpoint saves a signed checkpoint at the event if interval events were written since the last one.
+func (l *Logger) checkpoint(ctx context.Context, event *models.AuditEvent) {
+	if l.signingKey == nil || l.interval <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	l.pending++
+	due := l.pending >= l.interval
+	if due {
+		l.pending = 0
+	}
+	l.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	cp := &models.AuditCheckpoint{
+		EventID:   event.ID,
+		Hash:      event.Hash,
+		Signature: Sign(l.signingKey, event.ID, event.Hash),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := l.store.AddAuditCheckpoint(ctx, cp); err != nil {
+		logging.Sugar.Errorw("Failed to write audit checkpoint", "event_id", event.ID, "error", err)
+	}
+}
+
+// withRequestInfo saves gRPC method, client IP and user agent in context.
+func withRequestInfo(ctx context.Context, method string) context.Context {
+	info := requestInfo{method: method}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.peerIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(info.peerIP); err == nil {
+			info.peerIP = host
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ua := md.Get("user-agent"); len(ua) > 0 {
+			info.userAgent = ua[0]
+		}
+	}
+
+	return context.WithValue(ctx, requestInfoKey, info)
+}
@@ -0,0 +1,56 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: read events are written in background by Run and the ones left in the queue are written on stop.
+func TestRecordRead(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+	logger := audit.NewLogger(fakeStore, nil, 0)
+
+	// Without a running writer reads are recorded synchronously.
+	logger.RecordRead(context.Background(), "user", audit.EventSecretList, true, "sync")
+	events, err := fakeStore.GetAuditEvents(context.Background(), "user", time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		logger.RecordRead(context.Background(), "user", audit.EventSecretSearch, true, "async")
+		events, err := fakeStore.GetAuditEvents(context.Background(), "user", time.Time{}, time.Time{}, 0)
+		return err == nil && len(events) > 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		logger.RecordRead(context.Background(), "user", audit.EventSecretList, true, "queued")
+	}
+	cancel()
+	<-done
+
+	events, err = fakeStore.GetAuditEvents(context.Background(), "user", time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	queued := 0
+	for _, e := range events {
+		if e.Details == "queued" {
+			queued++
+		}
+	}
+	assert.Equal(t, 10, queued)
+
+	report, err := audit.Verify(context.Background(), fakeStore, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(events)), report.Events)
+}
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryInterceptor is a gRPC interceptor saving request info for audit events
+// and recording requests rejected because of missing or invalid tokens.
+// It must be chained before the authentification interceptor.
+func UnaryInterceptor(l *Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx = withRequestInfo(ctx, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+		recordRejectedToken(ctx, l, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor is a gRPC stream interceptor doing the same as UnaryInterceptor for streaming methods.
+func StreamInterceptor(l *Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := withRequestInfo(ss.Context(), info.FullMethod)
+
+		err := handler(srv, &auditStream{ServerStream: ss, ctx: ctx})
+		recordRejectedToken(ctx, l, err)
+		return err
+	}
+}
+
+// recordRejectedToken records token.rejected event if the request failed authentification.
+// Failed logins are recorded by the business logic with the username.
+func recordRejectedToken(ctx context.Context, l *Logger, err error) {
+	if status.Code(err) != codes.Unauthenticated {
+		return
+	}
+
+	info, _ := ctx.Value(requestInfoKey).(requestInfo)
+	if info.method == "/proto.Keeper/Login" || info.method == "/proto.Keeper/DeleteAccount" {
+		return
+	}
+
+	l.Record(ctx, "", EventTokenRejected, false, status.Convert(err).Message())
+}
+
+// auditStream wraps grpc.ServerStream to replace its context with the one carrying request info.
+type auditStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context with request info.
+func (s *auditStream) Context() context.Context {
+	return s.ctx
+}
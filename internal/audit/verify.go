@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+)
+
+// ErrBadKey is returned when a checkpoint key can't be decoded.
+var ErrBadKey = errors.New("invalid audit key: expected base64 Ed25519 key")
+
+// ChainError describes the first broken link of the audit log chain.
+type ChainError struct {
+	EventID int64  // ID of the first event which fails verification
+	Reason  string // Description of the failure
+}
+
+// Error implements error interface.
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at event %d: %s", e.EventID, e.Reason)
+}
+
+// Report summarizes a successful verification of the audit log.
+type Report struct {
+	Events      int64  // Number of verified chained events
+	Checkpoints int    // Number of verified checkpoints
+	LastHash    string // Hash of the last event
+}
+
+// checkpointMessage returns the signed representation of a checkpoint.
+func checkpointMessage(eventID int64, hash string) []byte {
+	return []byte(fmt.Sprintf("gophkeeper-audit-checkpoint:%d:%s", eventID, hash))
+}
+
+// Sign returns base64 Ed25519 signature of the checkpoint at the event.
+func Sign(key ed25519.PrivateKey, eventID int64, hash string) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, checkpointMessage(eventID, hash)))
+}
+
+// ParseSigningKey decodes base64 Ed25519 seed. Empty string results in nil key.
+func ParseSigningKey(encoded string) (ed25519.PrivateKey, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, ErrBadKey
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// ParseVerifyKey decodes base64 Ed25519 public key. Empty string results in nil key.
+func ParseVerifyKey(encoded string) (ed25519.PublicKey, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, ErrBadKey
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// Verify walks the audit log from the first event and checks that every event
+// is linked to the previous one and its hash matches its content.
+// Checkpoint signatures are verified with key, if key is nil only checkpoint hashes are compared.
+// A broken chain is reported as *ChainError, other errors come from the storage.
+func Verify(ctx context.Context, store storage.Storage, key ed25519.PublicKey) (Report, error) {
+	var report Report
+
+	checkpoints, err := store.GetAuditCheckpoints(ctx)
+	if err != nil {
+		return report, err
+	}
+	byEvent := make(map[int64]models.AuditCheckpoint, len(checkpoints))
+	for _, cp := range checkpoints {
+		byEvent[cp.EventID] = cp
+	}
+
+	var lastID int64
+	err = store.ScanAuditEvents(ctx, func(event models.AuditEvent) error {
+		// Events written before chaining was introduced can't be told apart from events with removed hashes.
+		if event.Hash == "" {
+			return &ChainError{EventID: event.ID, Reason: "event isn't chained"}
+		}
+		if event.PrevHash != report.LastHash {
+			return &ChainError{EventID: event.ID, Reason: "previous hash doesn't match the preceding event"}
+		}
+		if event.ComputeHash() != event.Hash {
+			return &ChainError{EventID: event.ID, Reason: "event content doesn't match its hash"}
+		}
+
+		if cp, ok := byEvent[event.ID]; ok {
+			if err := verifyCheckpoint(cp, event, key); err != nil {
+				return err
+			}
+			delete(byEvent, event.ID)
+			report.Checkpoints++
+		}
+
+		report.Events++
+		report.LastHash = event.Hash
+		lastID = event.ID
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	// A checkpoint without its event means the log was truncated.
+	for _, cp := range checkpoints {
+		if _, ok := byEvent[cp.EventID]; ok {
+			return report, &ChainError{EventID: lastID, Reason: fmt.Sprintf("checkpointed event %d is missing", cp.EventID)}
+		}
+	}
+
+	return report, nil
+}
+
+// verifyCheckpoint checks that the checkpoint matches the event and is signed with key.
+func verifyCheckpoint(cp models.AuditCheckpoint, event models.AuditEvent, key ed25519.PublicKey) error {
+	if cp.Hash != event.Hash {
+		return &ChainError{EventID: event.ID, Reason: "event hash doesn't match the signed checkpoint"}
+	}
+	if key == nil {
+		return nil
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(cp.Signature)
+	if err != nil || !ed25519.Verify(key, checkpointMessage(cp.EventID, cp.Hash), sig) {
+		return &ChainError{EventID: event.ID, Reason: "invalid checkpoint signature"}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package audit_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/KirillZiborov/GophKeeper/internal/audit"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tamperedStorage rewrites audit events while they are scanned.
+type tamperedStorage struct {
+	*storage.FakeStorage
+	tamper func(event *models.AuditEvent) bool // returns false to drop the event
+}
+
+// ScanAuditEvents passes tampered events to fn.
+func (ts *tamperedStorage) ScanAuditEvents(ctx context.Context, fn func(event models.AuditEvent) error) error {
+	return ts.FakeStorage.ScanAuditEvents(ctx, func(event models.AuditEvent) error {
+		if !ts.tamper(&event) {
+			return nil
+		}
+		return fn(event)
+	})
+}
+
+// Test case: verification of intact and tampered audit log chains.
+func TestVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	fakeStore := storage.NewFakeStorage()
+	logger := audit.NewLogger(fakeStore, priv, 3)
+	for i := 0; i < 10; i++ {
+		logger.Record(context.Background(), "user", audit.EventSecretList, true, "")
+	}
+
+	report, err := audit.Verify(context.Background(), fakeStore, pub)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), report.Events)
+	assert.Equal(t, 3, report.Checkpoints)
+
+	tests := []struct {
+		name    string
+		tamper  func(event *models.AuditEvent) bool
+		key     ed25519.PublicKey
+		eventID int64
+	}{
+		{
+			name: "modified event",
+			tamper: func(e *models.AuditEvent) bool {
+				if e.ID == 5 {
+					e.Success = false
+				}
+				return true
+			},
+			key:     pub,
+			eventID: 5,
+		},
+		{
+			name: "rehashed event breaks next link",
+			tamper: func(e *models.AuditEvent) bool {
+				if e.ID == 4 {
+					e.Details = "forged"
+					e.Hash = e.ComputeHash()
+				}
+				return true
+			},
+			key:     pub,
+			eventID: 5,
+		},
+		{
+			name:    "deleted event",
+			tamper:  func(e *models.AuditEvent) bool { return e.ID != 7 },
+			key:     pub,
+			eventID: 8,
+		},
+		{
+			name: "unchained event",
+			tamper: func(e *models.AuditEvent) bool {
+				if e.ID == 1 {
+					e.Hash = ""
+				}
+				return true
+			},
+			key:     pub,
+			eventID: 1,
+		},
+		{
+			name:    "truncated log",
+			tamper:  func(e *models.AuditEvent) bool { return e.ID <= 7 },
+			key:     pub,
+			eventID: 7,
+		},
+		{
+			name:    "foreign signing key",
+			tamper:  func(e *models.AuditEvent) bool { return true },
+			key:     ed25519.PublicKey(make([]byte, ed25519.PublicKeySize)),
+			eventID: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := audit.Verify(context.Background(), &tamperedStorage{FakeStorage: fakeStore, tamper: tt.tamper}, tt.key)
+
+			var chainErr *audit.ChainError
+			require.True(t, errors.As(err, &chainErr), "expected chain error, got %v", err)
+			assert.Equal(t, tt.eventID, chainErr.EventID)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -42,6 +43,21 @@ type JWToken struct {
 
 var tokenConfig JWToken
 
+// publicMethods are gRPC methods available without authentification.
+var publicMethods = map[string]bool{
+	"/proto.Keeper/Login":    true,
+	"/proto.Keeper/Register": true,
+	"/proto.Keeper/OpenSend": true,
+}
+
+// reflectionPrefix is the prefix of gRPC reflection service methods used by grpcurl, they are public too.
+const reflectionPrefix = "/grpc.reflection."
+
+// isPublic reports whether the method is available without authentification.
+func isPublic(fullMethod string) bool {
+	return publicMethods[fullMethod] || strings.HasPrefix(fullMethod, reflectionPrefix)
+}
+
 // SetTokenConfig sets JWT parameters from the configuration.
 func SetTokenConfig(secret string, exp string) {
 	tokenConfig.SecretKey = secret
@@ -64,8 +80,8 @@ func AuthInterceptor() grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		// Skip authentification for register and login.
-		if info.FullMethod == "/proto.Keeper/Login" || info.FullMethod == "/proto.Keeper/Register" {
+		// Skip authentification for register, login and opening sends.
+		if isPublic(info.FullMethod) {
 			return handler(ctx, req)
 		}
 
@@ -91,6 +107,52 @@ func AuthInterceptor() grpc.UnaryServerInterceptor {
 	}
 }
 
+// StreamAuthInterceptor is a gRPC stream interceptor that handles users authentification
+// for server-streaming methods the same way AuthInterceptor does for unary ones.
+func StreamAuthInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		// Skip authentification for the reflection service.
+		if isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		md, ok := metadata.FromIncomingContext(ss.Context())
+		if !ok || len(md.Get(cookieHeader)) == 0 {
+			return status.Errorf(codes.Unauthenticated, "token is required")
+		}
+
+		token := md.Get(cookieHeader)[0]
+
+		// Parse and validate cookie from metadata.
+		userID := GetUserID(token)
+		if userID == "" {
+			return status.Errorf(codes.Unauthenticated, "Invalid token in %s", cookieHeader)
+		}
+
+		// Put userID in stream context.
+		return handler(srv, &authStream{
+			ServerStream: ss,
+			ctx:          context.WithValue(ss.Context(), metadataKey, userID),
+		})
+	}
+}
+
+// authStream wraps grpc.ServerStream to replace its context with the authentificated one.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context with userID.
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
 // GenerateToken creates a new JWT token for a given userID.
 // If the provided userID is empty, it generates a new UUID for the user.
 // The function returns the signed JWT token string or an error if the process fails.
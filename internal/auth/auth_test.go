@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
@@ -64,3 +65,46 @@ func TestAuthInterceptor_NoToken(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, st.Code(), status.Code(err))
 }
+
+// testStream is a server stream with the context only.
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamAuthInterceptor(t *testing.T) {
+	auth.SetTokenConfig("test-secret", "2h")
+	interceptor := auth.StreamAuthInterceptor()
+
+	var userID string
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		userID, _ = auth.GetUserIDFromContext(ss.Context())
+		return nil
+	}
+
+	// Streams of the API require a token.
+	stream := &testStream{ctx: context.Background()}
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/proto.Keeper/ExportAccount"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	token, err := auth.GenerateToken("user1")
+	require.NoError(t, err)
+	stream = &testStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("token", token))}
+	require.NoError(t, interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/proto.Keeper/ExportAccount"}, handler))
+	assert.Equal(t, "user1", userID)
+
+	// Reflection used by grpcurl is public.
+	for _, method := range []string{
+		"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+		"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+	} {
+		userID = ""
+		stream = &testStream{ctx: context.Background()}
+		require.NoError(t, interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: method}, handler))
+		assert.Empty(t, userID)
+	}
+}
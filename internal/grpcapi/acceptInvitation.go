@@ -0,0 +1,28 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AcceptInvitation is the gRPC method for accepting an invitation to organization.
+func (s *GophKeeperServer) AcceptInvitation(ctx context.Context, req *proto.AcceptInvitationRequest) (*proto.AcceptInvitationResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	err := s.svc.AcceptInvitation(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	return &proto.AcceptInvitationResponse{}, nil
+}
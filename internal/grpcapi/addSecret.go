@@ -2,6 +2,7 @@ package grpcapi
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/KirillZiborov/GophKeeper/internal/auth"
 	"github.com/KirillZiborov/GophKeeper/proto"
@@ -23,10 +24,19 @@ func (s *GophKeeperServer) AddSecret(ctx context.Context, req *proto.AddSecretRe
 		return nil, status.Error(codes.InvalidArgument, "Secret data must be provided")
 	}
 
+	// Secrets of organization collections require member's role checks.
+	if req.GetCollectionId() != 0 {
+		id, err := s.svc.AddCollectionSecret(ctx, userID, req.GetCollectionId(), req.GetId(), secret.Type, secret.Data, secret.Meta, expiresAt(secret))
+		if err != nil {
+			return nil, fmt.Errorf("failed to add Secret: %w", err)
+		}
+		return &proto.AddSecretResponse{Id: id}, nil
+	}
+
 	// Call to business logic.
-	id, err := s.svc.AddSecret(ctx, userID, secret.Data, secret.Meta)
+	id, err := s.svc.AddSecret(ctx, userID, req.GetId(), secret.Type, secret.Data, secret.Meta, expiresAt(secret), secret.SearchTokens)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to add Secret: %v", err)
+		return nil, fmt.Errorf("failed to add Secret: %w", err)
 	}
 
 	return &proto.AddSecretResponse{Id: id}, nil
@@ -0,0 +1,75 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mutationOps maps batch operations of the API to business logic ones.
+var mutationOps = map[proto.MutationOp]models.MutationOp{
+	proto.MutationOp_MUTATION_OP_CREATE: models.MutationCreate,
+	proto.MutationOp_MUTATION_OP_UPDATE: models.MutationUpdate,
+	proto.MutationOp_MUTATION_OP_DELETE: models.MutationDelete,
+}
+
+// BatchMutateSecrets is the gRPC method for applying create, update and delete operations
+// to personal secrets of an authentificated user in one transaction.
+// Errors of single mutations are reported in their results, the call fails only if the batch can't be applied.
+func (s *GophKeeperServer) BatchMutateSecrets(ctx context.Context, req *proto.BatchMutateSecretsRequest) (*proto.BatchMutateSecretsResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	mutations := make([]models.SecretMutation, 0, len(req.GetMutations()))
+	for _, m := range req.GetMutations() {
+		mutations = append(mutations, secretMutation(m))
+	}
+
+	// Call to business logic.
+	results, committed, err := s.svc.MutateSecrets(ctx, userID, mutations, !req.GetBestEffort())
+	if err != nil {
+		return nil, fmt.Errorf("failed to mutate Secrets: %w", err)
+	}
+
+	resp := &proto.BatchMutateSecretsResponse{Committed: committed}
+	for _, result := range results {
+		st := status.Convert(toStatus(proto.Keeper_BatchMutateSecrets_FullMethodName, result.Err))
+		resp.Results = append(resp.Results, &proto.MutationResult{
+			Id:      result.ID,
+			Code:    int32(st.Code()),
+			Message: st.Message(),
+		})
+	}
+
+	return resp, nil
+}
+
+// secretMutation converts a mutation from request to business logic structure.
+// Unset expiration time keeps the current one on update unless it is cleared.
+func secretMutation(m *proto.SecretMutation) models.SecretMutation {
+	secret := m.GetSecret()
+	mutation := models.SecretMutation{
+		Op: mutationOps[m.GetOp()],
+		Secret: models.Secret{
+			ID:           m.GetId(),
+			Data:         secret.GetData(),
+			Meta:         secret.GetMeta(),
+			ExpiresAt:    expiresAt(secret),
+			SearchTokens: secret.GetSearchTokens(),
+			Type:         secret.GetType(),
+		},
+	}
+	if mutation.Op == models.MutationUpdate {
+		mutation.KeepExpiresAt = secret.GetExpiresAt() == nil && !m.GetClearExpiresAt()
+		mutation.Shares = sharedCopies(m.GetShares())
+	}
+	return mutation
+}
@@ -0,0 +1,33 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateCollection is the gRPC method for creating a collection of secrets in organization.
+// Request contains the collection key wrapped for organization members.
+func (s *GophKeeperServer) CreateCollection(ctx context.Context, req *proto.CreateCollectionRequest) (*proto.CreateCollectionResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "collection name must be provided")
+	}
+
+	// Call to business logic.
+	id, err := s.svc.CreateCollection(ctx, userID, req.GetOrgId(), req.GetName(), keyGrants(req.GetKeys()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	return &proto.CreateCollectionResponse{Id: id}, nil
+}
@@ -0,0 +1,32 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateOrganization is the gRPC method for creating an organization owned by an authentificated user.
+func (s *GophKeeperServer) CreateOrganization(ctx context.Context, req *proto.CreateOrganizationRequest) (*proto.CreateOrganizationResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "organization name must be provided")
+	}
+
+	// Call to business logic.
+	id, err := s.svc.CreateOrganization(ctx, userID, req.GetName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	return &proto.CreateOrganizationResponse{Id: id}, nil
+}
@@ -0,0 +1,33 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateSend is the gRPC method for creating a one-time send by an authentificated user.
+// Client encrypts the payload with a random key and uploads only the ciphertext.
+func (s *GophKeeperServer) CreateSend(ctx context.Context, req *proto.CreateSendRequest) (*proto.CreateSendResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetCiphertext() == "" || req.GetExpiresAt() == nil {
+		return nil, status.Error(codes.InvalidArgument, "ciphertext and expiration time must be provided")
+	}
+
+	// Call to business logic.
+	id, err := s.svc.CreateSend(ctx, userID, req.GetCiphertext(), int(req.GetMaxViews()), req.GetExpiresAt().AsTime())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create send: %w", err)
+	}
+
+	return &proto.CreateSendResponse{Id: id}, nil
+}
@@ -0,0 +1,33 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeleteAccount is the gRPC method for deleting an authentificated user with all his secrets.
+// User must confirm deletion with his current password.
+func (s *GophKeeperServer) DeleteAccount(ctx context.Context, req *proto.DeleteAccountRequest) (*proto.DeleteAccountResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password must be provided")
+	}
+
+	// Call to business logic.
+	err := s.svc.DeleteAccount(ctx, userID, req.GetPassword())
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	return &proto.DeleteAccountResponse{}, nil
+}
@@ -2,6 +2,8 @@ package grpcapi
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/auth"
 	"github.com/KirillZiborov/GophKeeper/proto"
@@ -24,10 +26,20 @@ func (s *GophKeeperServer) EditSecret(ctx context.Context, req *proto.EditSecret
 		return nil, status.Error(codes.InvalidArgument, "Secret data must be provided")
 	}
 
+	// Keep the current expiration time unless a new one is set or it is cleared.
+	var expires *time.Time
+	if req.GetClearExpiresAt() {
+		expires = &time.Time{}
+	} else if secret.ExpiresAt != nil {
+		t := expiresAt(secret)
+		expires = &t
+	}
+
 	// Call to business logic.
-	err := s.svc.EditSecret(ctx, id, userID, secret.Data, secret.Meta)
+	err := s.svc.EditSecret(ctx, id, userID, secret.Type, secret.Data, secret.Meta, expires, secret.SearchTokens,
+		sharedCopies(req.GetShares()))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to edit Secret: %v", err)
+		return nil, fmt.Errorf("failed to edit Secret: %w", err)
 	}
 
 	return &proto.EditSecretResponse{}, nil
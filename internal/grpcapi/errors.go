@@ -0,0 +1,72 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// internalMessage replaces messages of unexpected errors, whose details stay in the server log.
+const internalMessage = "internal server error"
+
+// kindCodes maps kinds of domain errors to gRPC status codes.
+var kindCodes = map[storage.Kind]codes.Code{
+	storage.KindNotFound:           codes.NotFound,
+	storage.KindAlreadyExists:      codes.AlreadyExists,
+	storage.KindPermissionDenied:   codes.PermissionDenied,
+	storage.KindInvalidArgument:    codes.InvalidArgument,
+	storage.KindFailedPrecondition: codes.FailedPrecondition,
+	storage.KindResourceExhausted:  codes.ResourceExhausted,
+	storage.KindUnauthenticated:    codes.Unauthenticated,
+	storage.KindAborted:            codes.Aborted,
+}
+
+// ErrorInterceptor is a gRPC interceptor translating errors returned by handlers to gRPC statuses.
+// It must be the outermost interceptor to see errors of the other ones.
+func ErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, toStatus(info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamErrorInterceptor is a gRPC stream interceptor doing the same as ErrorInterceptor for streaming methods.
+func StreamErrorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return toStatus(info.FullMethod, err)
+		}
+		return nil
+	}
+}
+
+// toStatus converts err to gRPC status error.
+// Statuses are returned as is, domain errors get the code of their kind and keep their message.
+// Cancelled requests report the context error. Messages of other errors are scrubbed and logged.
+func toStatus(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if code, ok := kindCodes[storage.KindOf(err)]; ok {
+		return status.Error(code, err.Error())
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "request timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request cancelled")
+	default:
+		logging.Sugar.Errorw("Request failed", "method", method, "error", err)
+		return status.Error(codes.Internal, internalMessage)
+	}
+}
@@ -0,0 +1,115 @@
+package grpcapi
+
+import (
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExportAccount is the gRPC method streaming all account data of an authentificated user.
+// The user row is sent first followed by user's keys, secrets, shares, memberships and collections.
+func (s *GophKeeperServer) ExportAccount(req *proto.ExportAccountRequest, stream grpc.ServerStreamingServer[proto.ExportItem]) error {
+	ctx := stream.Context()
+
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic, every item is sent as soon as it is read.
+	err := s.svc.ExportAccount(ctx, userID, func(item app.ExportItem) error {
+		return stream.Send(exportItem(item))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export account: %w", err)
+	}
+
+	return nil
+}
+
+// exportItem converts a record of the account export to the streamed item.
+func exportItem(item app.ExportItem) *proto.ExportItem {
+	switch {
+	case item.User != nil:
+		return &proto.ExportItem{
+			Item: &proto.ExportItem_User{
+				User: &proto.ExportedUser{
+					Id:       item.User.ID,
+					Username: item.User.Username,
+				},
+			},
+		}
+	case item.Keys != nil:
+		return &proto.ExportItem{
+			Item: &proto.ExportItem_Keys{
+				Keys: &proto.UserKeys{
+					PublicKey:           item.Keys.PublicKey,
+					EncryptedPrivateKey: item.Keys.EncryptedPrivateKey,
+				},
+			},
+		}
+	case item.Secret != nil:
+		return &proto.ExportItem{
+			Item: &proto.ExportItem_Secret{
+				Secret: &proto.CountedSecret{
+					Id:     item.Secret.ID,
+					Secret: protoSecret(*item.Secret),
+				},
+			},
+		}
+	case item.Share != nil:
+		return &proto.ExportItem{
+			Item: &proto.ExportItem_Share{
+				Share: &proto.SharedSecret{
+					Id:         item.Share.ID,
+					SecretId:   item.Share.SecretID,
+					Owner:      item.Share.OwnerName,
+					WrappedKey: item.Share.WrappedKey,
+					Secret: &proto.Secret{
+						Data: item.Share.Data,
+						Meta: item.Share.Meta,
+					},
+				},
+			},
+		}
+	case item.Membership != nil:
+		return &proto.ExportItem{
+			Item: &proto.ExportItem_Organization{
+				Organization: &proto.Organization{
+					Id:   item.Membership.OrgID,
+					Name: item.Membership.OrgName,
+					Role: string(item.Membership.Role),
+				},
+			},
+		}
+	case item.CollectionKey != nil:
+		return &proto.ExportItem{
+			Item: &proto.ExportItem_CollectionKey{
+				CollectionKey: &proto.CollectionKey{
+					CollectionId:   item.CollectionKey.CollectionID,
+					CollectionName: item.CollectionKey.CollectionName,
+					OrgId:          item.CollectionKey.OrgID,
+					WrappedKey:     item.CollectionKey.WrappedKey,
+				},
+			},
+		}
+	default:
+		return &proto.ExportItem{
+			Item: &proto.ExportItem_CollectionSecret{
+				CollectionSecret: &proto.CollectionSecret{
+					CollectionId: item.CollectionSecret.CollectionID,
+					Secret: &proto.CountedSecret{
+						Id:     item.CollectionSecret.ID,
+						Secret: protoSecret(*item.CollectionSecret),
+					},
+				},
+			},
+		}
+	}
+}
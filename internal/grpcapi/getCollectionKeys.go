@@ -0,0 +1,39 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetCollectionKeys is the gRPC method returning collection keys wrapped for an authentificated user.
+func (s *GophKeeperServer) GetCollectionKeys(ctx context.Context, req *proto.GetCollectionKeysRequest) (*proto.GetCollectionKeysResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	keys, err := s.svc.GetCollectionKeys(ctx, userID, req.GetOrgId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection keys: %w", err)
+	}
+
+	// Prepare response.
+	var protoKeys []*proto.CollectionKey
+	for _, k := range keys {
+		protoKeys = append(protoKeys, &proto.CollectionKey{
+			CollectionId:   k.CollectionID,
+			CollectionName: k.CollectionName,
+			OrgId:          k.OrgID,
+			WrappedKey:     k.WrappedKey,
+		})
+	}
+
+	return &proto.GetCollectionKeysResponse{Keys: protoKeys}, nil
+}
@@ -0,0 +1,28 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetCollectionSecrets is the gRPC method returning secrets of organization collection.
+func (s *GophKeeperServer) GetCollectionSecrets(ctx context.Context, req *proto.GetCollectionSecretsRequest) (*proto.GetCollectionSecretsResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	secrets, err := s.svc.GetCollectionSecrets(ctx, userID, req.GetCollectionId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection secrets: %w", err)
+	}
+
+	return &proto.GetCollectionSecretsResponse{Secret: protoSecrets(secrets)}, nil
+}
@@ -0,0 +1,38 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetPublicKey is the gRPC method returning public sharing key of the user with requested username.
+func (s *GophKeeperServer) GetPublicKey(ctx context.Context, req *proto.GetPublicKeyRequest) (*proto.GetPublicKeyResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetUsername() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username must be provided")
+	}
+
+	// Call to business logic.
+	key, err := s.svc.GetPublicKey(ctx, req.GetUsername())
+	if err != nil {
+		// Missing keys are reported as not found rather than as a failed precondition.
+		if errors.Is(err, app.ErrNoPublicKey) {
+			return nil, status.Errorf(codes.NotFound, "failed to get public key: %v", err)
+		}
+		return nil, fmt.Errorf("failed to get public key: %w", err)
+	}
+
+	return &proto.GetPublicKeyResponse{PublicKey: key}, nil
+}
@@ -2,6 +2,7 @@ package grpcapi
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/KirillZiborov/GophKeeper/internal/auth"
 	"github.com/KirillZiborov/GophKeeper/proto"
@@ -20,22 +21,11 @@ func (s *GophKeeperServer) GetSecret(ctx context.Context, req *proto.GetSecretRe
 	// Call to business logic.
 	credsList, err := s.svc.GetSecrets(ctx, userID)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get Secret: %v", err)
+		return nil, fmt.Errorf("failed to get Secret: %w", err)
 	}
 
 	// Prepare response.
-	var protoCreds []*proto.CountedSecret
-	for _, c := range credsList {
-		protoCreds = append(protoCreds, &proto.CountedSecret{
-			Id: c.ID,
-			Secret: &proto.Secret{
-				Data: c.Data,
-				Meta: c.Meta,
-			},
-		})
-	}
-
 	return &proto.GetSecretResponse{
-		Secret: protoCreds,
+		Secret: protoSecrets(credsList),
 	}, nil
 }
@@ -0,0 +1,30 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetSecretById is the gRPC method returning a single secret available to an authentificated user.
+func (s *GophKeeperServer) GetSecretById(ctx context.Context, req *proto.GetSecretByIdRequest) (*proto.GetSecretByIdResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	secret, err := s.svc.GetSecret(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	return &proto.GetSecretByIdResponse{
+		Secret: &proto.CountedSecret{Id: secret.ID, Secret: protoSecret(*secret)},
+	}, nil
+}
@@ -0,0 +1,34 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetUsage is the gRPC method returning storage usage and quota of an authentificated user.
+func (s *GophKeeperServer) GetUsage(ctx context.Context, req *proto.GetUsageRequest) (*proto.GetUsageResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	usage, quota, err := s.svc.GetUsage(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	return &proto.GetUsageResponse{
+		Secrets:        usage.Secrets,
+		Bytes:          usage.Bytes,
+		MaxSecrets:     quota.MaxSecrets,
+		MaxSecretBytes: quota.MaxSecretBytes,
+		MaxTotalBytes:  quota.MaxTotalBytes,
+	}, nil
+}
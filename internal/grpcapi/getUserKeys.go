@@ -0,0 +1,39 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetUserKeys is the gRPC method returning sharing keypair of an authentificated user.
+func (s *GophKeeperServer) GetUserKeys(ctx context.Context, req *proto.GetUserKeysRequest) (*proto.GetUserKeysResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	keys, err := s.svc.GetUserKeys(ctx, userID)
+	if err != nil {
+		// Missing keys are reported as not found rather than as a failed precondition.
+		if errors.Is(err, app.ErrNoPublicKey) {
+			return nil, status.Errorf(codes.NotFound, "failed to get keys: %v", err)
+		}
+		return nil, fmt.Errorf("failed to get keys: %w", err)
+	}
+
+	return &proto.GetUserKeysResponse{
+		Keys: &proto.UserKeys{
+			PublicKey:           keys.PublicKey,
+			EncryptedPrivateKey: keys.EncryptedPrivateKey,
+		},
+	}, nil
+}
@@ -2,16 +2,21 @@ package grpcapi_test
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/app"
 	"github.com/KirillZiborov/GophKeeper/internal/auth"
 	"github.com/KirillZiborov/GophKeeper/internal/grpcapi"
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
 	"github.com/KirillZiborov/GophKeeper/internal/models"
 	"github.com/KirillZiborov/GophKeeper/internal/storage"
 	"github.com/KirillZiborov/GophKeeper/proto"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
@@ -40,7 +45,7 @@ func TestWrongCredentialsGRPC(t *testing.T) {
 	}
 
 	lis = bufconn.Listen(bufSize)
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.AuthInterceptor()))
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()))
 	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
 	go func() {
 		if err := grpcServer.Serve(lis); err != nil {
@@ -90,7 +95,7 @@ func TestLoginGRPC(t *testing.T) {
 	}
 
 	lis = bufconn.Listen(bufSize)
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.AuthInterceptor()))
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()))
 	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
 	go func() {
 		if err := grpcServer.Serve(lis); err != nil {
@@ -152,7 +157,7 @@ func TestLoginWrongPassGRPC(t *testing.T) {
 	}
 
 	lis = bufconn.Listen(bufSize)
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.AuthInterceptor()))
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()))
 	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
 	go func() {
 		if err := grpcServer.Serve(lis); err != nil {
@@ -220,7 +225,7 @@ func TestSecretCRUDGRPC(t *testing.T) {
 	auth.SetTokenConfig("test-secret", "2h")
 
 	lis = bufconn.Listen(bufSize)
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.AuthInterceptor()))
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()))
 	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
 	go func() {
 		if err := grpcServer.Serve(lis); err != nil {
@@ -312,6 +317,17 @@ func TestSecretCRUDGRPC(t *testing.T) {
 	require.NotNil(t, found, "Secret with given id not found")
 	assert.Equal(t, "updated note", found.Secret.Meta, "Secret meta should be updated")
 	assert.Equal(t, "updatedEncryptedData", found.Secret.Data, "Secret data should be updated")
+
+	// GetSecretById returns the single secret.
+	byIDResp, err := client.GetSecretById(authCtx, &proto.GetSecretByIdRequest{Id: secretID})
+	require.NoError(t, err)
+	assert.Equal(t, secretID, byIDResp.Secret.Id)
+	assert.Equal(t, "updatedEncryptedData", byIDResp.Secret.Secret.Data)
+
+	_, err = client.GetSecretById(authCtx, &proto.GetSecretByIdRequest{Id: uuid.New().String()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.GetSecretById(authCtx, &proto.GetSecretByIdRequest{Id: "not-an-id"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
 }
 
 // Test case: UpdateSecret request on other's user secret.
@@ -325,7 +341,7 @@ func TestUpdateNotMySecret(t *testing.T) {
 	auth.SetTokenConfig("test-secret", "2h")
 
 	lis = bufconn.Listen(bufSize)
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.AuthInterceptor()))
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()))
 	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
 	go func() {
 		if err := grpcServer.Serve(lis); err != nil {
@@ -355,9 +371,9 @@ func TestUpdateNotMySecret(t *testing.T) {
 		Password: "password",
 	}
 
-	err = fakeStore.RegisterUser(user1)
+	err = fakeStore.RegisterUser(context.Background(), user1)
 	require.NoError(t, err)
-	err = fakeStore.RegisterUser(user2)
+	err = fakeStore.RegisterUser(context.Background(), user2)
 	require.NoError(t, err)
 
 	// Generate tokens for user1 and user2.
@@ -402,5 +418,444 @@ func TestUpdateNotMySecret(t *testing.T) {
 	st, ok := status.FromError(err)
 	code := st.Code()
 	require.True(t, ok, "Expected gRPC status error")
-	assert.Equal(t, code, codes.Internal)
+	assert.Equal(t, code, codes.PermissionDenied)
+
+	// user2 can't read user1 secret by id either.
+	_, err = client.GetSecretById(ctxUser2, &proto.GetSecretByIdRequest{Id: secretID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// Test case: user exports his account and then deletes it.
+func TestExportAndDeleteAccountGRPC(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("test-secret", "2h")
+
+	lis = bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()),
+		grpc.ChainStreamInterceptor(grpcapi.StreamErrorInterceptor(), auth.StreamAuthInterceptor()),
+	)
+	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Errorf("gRPC server exited with error")
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
+	resolver.SetDefaultScheme("passthrough")
+	conn, err := grpc.NewClient(
+		"bufnet", grpc.WithContextDialer(bufDialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := proto.NewKeeperClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Register first.
+	var regHeader metadata.MD
+	_, err = client.Register(ctx, &proto.RegisterRequest{
+		UserData: &proto.User{
+			Username: "testuser",
+			Password: "testpassword",
+		},
+	}, grpc.Header(&regHeader))
+	require.NoError(t, err)
+	tokens := regHeader.Get("token")
+	require.NotEmpty(t, tokens, "Expected token in header after registration")
+
+	md := metadata.Pairs("token", tokens[0])
+	authCtx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), 5*time.Second)
+	defer cancel()
+
+	_, err = client.AddSecret(authCtx, &proto.AddSecretRequest{
+		Secret: &proto.Secret{
+			Data: "encryptedData",
+			Meta: "encryptedMeta",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = client.SetUserKeys(authCtx, &proto.SetUserKeysRequest{
+		Keys: &proto.UserKeys{PublicKey: "public", EncryptedPrivateKey: "private"},
+	})
+	require.NoError(t, err)
+
+	// Export stream starts with the user row followed by keys and secrets.
+	stream, err := client.ExportAccount(authCtx, &proto.ExportAccountRequest{})
+	require.NoError(t, err)
+
+	var items []*proto.ExportItem
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		items = append(items, item)
+	}
+	require.Len(t, items, 3)
+	assert.Equal(t, "testuser", items[0].GetUser().GetUsername())
+	assert.Equal(t, "private", items[1].GetKeys().GetEncryptedPrivateKey())
+	assert.Equal(t, "encryptedData", items[2].GetSecret().GetSecret().GetData())
+
+	// Deletion with wrong password is rejected.
+	_, err = client.DeleteAccount(authCtx, &proto.DeleteAccountRequest{Password: "wrong"})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.DeleteAccount(authCtx, &proto.DeleteAccountRequest{Password: "testpassword"})
+	require.NoError(t, err)
+
+	// Deleted user can't sign in.
+	_, err = client.Login(ctx, &proto.LoginRequest{
+		UserData: &proto.User{
+			Username: "testuser",
+			Password: "testpassword",
+		},
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// Test case: secrets are streamed one by one with a type filter.
+func TestStreamSecretsGRPC(t *testing.T) {
+	fakeStore := storage.NewFakeStorage()
+
+	svc := app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("test-secret", "2h")
+
+	lis = bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()),
+		grpc.ChainStreamInterceptor(grpcapi.StreamErrorInterceptor(), auth.StreamAuthInterceptor()),
+	)
+	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Errorf("gRPC server exited with error")
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
+	resolver.SetDefaultScheme("passthrough")
+	conn, err := grpc.NewClient(
+		"bufnet", grpc.WithContextDialer(bufDialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := proto.NewKeeperClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var regHeader metadata.MD
+	_, err = client.Register(ctx, &proto.RegisterRequest{
+		UserData: &proto.User{
+			Username: "testuser",
+			Password: "testpassword",
+		},
+	}, grpc.Header(&regHeader))
+	require.NoError(t, err)
+	tokens := regHeader.Get("token")
+	require.NotEmpty(t, tokens, "Expected token in header after registration")
+
+	md := metadata.Pairs("token", tokens[0])
+	authCtx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), 5*time.Second)
+	defer cancel()
+
+	var textIDs []string
+	for _, secretType := range []string{models.SecretTypeText, models.SecretTypeCard, models.SecretTypeText} {
+		resp, err := client.AddSecret(authCtx, &proto.AddSecretRequest{
+			Secret: &proto.Secret{
+				Data: "encryptedData",
+				Meta: "encryptedMeta",
+				Type: secretType,
+			},
+		})
+		require.NoError(t, err)
+		if secretType == models.SecretTypeText {
+			textIDs = append(textIDs, resp.Id)
+		}
+	}
+	slices.Sort(textIDs)
+
+	stream, err := client.StreamSecrets(authCtx, &proto.StreamSecretsRequest{Type: models.SecretTypeText})
+	require.NoError(t, err)
+
+	var ids []string
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, models.SecretTypeText, item.GetSecret().GetType())
+		ids = append(ids, item.GetId())
+	}
+	assert.Equal(t, textIDs, ids)
+
+	// Unknown type is rejected.
+	stream, err = client.StreamSecrets(authCtx, &proto.StreamSecretsRequest{Type: "unknown"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Streaming requires authentification.
+	stream, err = client.StreamSecrets(ctx, &proto.StreamSecretsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// brokenStorage fails to read secrets with an error exposing storage internals.
+type brokenStorage struct {
+	*storage.FakeStorage
+}
+
+// GetSecrets always fails.
+func (bs *brokenStorage) GetSecrets(context.Context, string) ([]models.Secret, error) {
+	return nil, errors.New("dial tcp 10.0.0.5:5432: connection refused")
+}
+
+// Test case: domain errors are mapped to status codes and internal errors are scrubbed.
+func TestErrorTranslationGRPC(t *testing.T) {
+	require.NoError(t, logging.Initialize())
+
+	svc := app.KeeperService{
+		Store: &brokenStorage{FakeStorage: storage.NewFakeStorage()},
+	}
+
+	auth.SetTokenConfig("test-secret", "2h")
+
+	lis = bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()))
+	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Errorf("gRPC server exited with error")
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
+	resolver.SetDefaultScheme("passthrough")
+	conn, err := grpc.NewClient(
+		"bufnet", grpc.WithContextDialer(bufDialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := proto.NewKeeperClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user := &proto.User{Username: "testuser", Password: "testpassword"}
+	var regHeader metadata.MD
+	_, err = client.Register(ctx, &proto.RegisterRequest{UserData: user}, grpc.Header(&regHeader))
+	require.NoError(t, err)
+	require.NotEmpty(t, regHeader.Get("token"))
+
+	// Duplicate username.
+	_, err = client.Register(ctx, &proto.RegisterRequest{UserData: user})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// Unknown username and wrong password look the same.
+	_, err = client.Login(ctx, &proto.LoginRequest{UserData: &proto.User{Username: "nobody", Password: "x"}})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	unknownUser := status.Convert(err).Message()
+	_, err = client.Login(ctx, &proto.LoginRequest{UserData: &proto.User{Username: user.Username, Password: "x"}})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, unknownUser, status.Convert(err).Message())
+
+	md := metadata.Pairs("token", regHeader.Get("token")[0])
+	authCtx := metadata.NewOutgoingContext(ctx, md)
+
+	// Missing secret.
+	_, err = client.EditSecret(authCtx, &proto.EditSecretRequest{Id: "42", Secret: &proto.Secret{Data: "data"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "failed to edit Secret: secret not found", status.Convert(err).Message())
+
+	// Invalid argument.
+	_, err = client.AddSecret(authCtx, &proto.AddSecretRequest{Secret: &proto.Secret{Data: "data", Type: "unknown"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.AddSecret(authCtx, &proto.AddSecretRequest{Id: "not a uuid", Secret: &proto.Secret{Data: "data"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Secret with the client-generated id already exists.
+	clientID := uuid.New().String()
+	added, err := client.AddSecret(authCtx, &proto.AddSecretRequest{Id: clientID, Secret: &proto.Secret{Data: "data"}})
+	require.NoError(t, err)
+	assert.Equal(t, clientID, added.Id)
+	_, err = client.AddSecret(authCtx, &proto.AddSecretRequest{Id: clientID, Secret: &proto.Secret{Data: "data"}})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// Storage failure details are not sent to the client.
+	_, err = client.GetSecret(authCtx, &proto.GetSecretRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NotContains(t, status.Convert(err).Message(), "10.0.0.5")
+}
+
+// Test case: batch mutations report per-item status codes and atomic batches are rolled back on failure.
+func TestBatchMutateSecretsGRPC(t *testing.T) {
+	require.NoError(t, logging.Initialize())
+
+	svc := app.KeeperService{
+		Store: storage.NewFakeStorage(),
+	}
+
+	auth.SetTokenConfig("test-secret", "2h")
+
+	lis = bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor()))
+	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Errorf("gRPC server exited with error")
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
+	resolver.SetDefaultScheme("passthrough")
+	conn, err := grpc.NewClient(
+		"bufnet", grpc.WithContextDialer(bufDialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := proto.NewKeeperClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var regHeader metadata.MD
+	_, err = client.Register(ctx, &proto.RegisterRequest{UserData: &proto.User{Username: "testuser", Password: "testpassword"}},
+		grpc.Header(&regHeader))
+	require.NoError(t, err)
+	authCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("token", regHeader.Get("token")[0]))
+
+	added, err := client.AddSecret(authCtx, &proto.AddSecretRequest{Secret: &proto.Secret{Data: "old"}})
+	require.NoError(t, err)
+
+	req := &proto.BatchMutateSecretsRequest{Mutations: []*proto.SecretMutation{
+		{Op: proto.MutationOp_MUTATION_OP_CREATE, Secret: &proto.Secret{Data: "new"}},
+		{Op: proto.MutationOp_MUTATION_OP_UPDATE, Id: added.Id, Secret: &proto.Secret{Data: "updated"}},
+		{Op: proto.MutationOp_MUTATION_OP_DELETE, Id: uuid.New().String()},
+	}}
+
+	resp, err := client.BatchMutateSecrets(authCtx, req)
+	require.NoError(t, err)
+	assert.False(t, resp.Committed)
+	require.Len(t, resp.Results, 3)
+	assert.Equal(t, int32(codes.Aborted), resp.Results[0].Code)
+	assert.Equal(t, int32(codes.Aborted), resp.Results[1].Code)
+	assert.Equal(t, int32(codes.NotFound), resp.Results[2].Code)
+	assert.Equal(t, "secret not found", resp.Results[2].Message)
+
+	req.BestEffort = true
+	resp, err = client.BatchMutateSecrets(authCtx, req)
+	require.NoError(t, err)
+	assert.True(t, resp.Committed)
+	assert.Equal(t, int32(codes.OK), resp.Results[0].Code)
+	assert.Equal(t, int32(codes.OK), resp.Results[1].Code)
+	assert.Equal(t, added.Id, resp.Results[1].Id)
+
+	get, err := client.GetSecret(authCtx, &proto.GetSecretRequest{})
+	require.NoError(t, err)
+	assert.Len(t, get.Secret, 2)
+
+	// Empty batch is rejected as a whole.
+	_, err = client.BatchMutateSecrets(authCtx, &proto.BatchMutateSecretsRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// Test case: retries with the same idempotency key replay the saved response instead of applying the request again.
+func TestIdempotencyKeyGRPC(t *testing.T) {
+	require.NoError(t, logging.Initialize())
+
+	fakeStore := storage.NewFakeStorage()
+	svc := app.KeeperService{
+		Store: fakeStore,
+	}
+
+	auth.SetTokenConfig("test-secret", "2h")
+
+	lis = bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor(),
+		grpcapi.IdempotencyInterceptor(&svc)))
+	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Errorf("gRPC server exited with error")
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
+	resolver.SetDefaultScheme("passthrough")
+	conn, err := grpc.NewClient(
+		"bufnet", grpc.WithContextDialer(bufDialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := proto.NewKeeperClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var regHeader metadata.MD
+	_, err = client.Register(ctx, &proto.RegisterRequest{UserData: &proto.User{Username: "testuser", Password: "testpassword"}},
+		grpc.Header(&regHeader))
+	require.NoError(t, err)
+	token := regHeader.Get("token")[0]
+	keyCtx := func(key string) context.Context {
+		return metadata.NewOutgoingContext(ctx, metadata.Pairs("token", token, proto.IdempotencyKeyHeader, key))
+	}
+
+	req := &proto.AddSecretRequest{Secret: &proto.Secret{Data: "data"}}
+	first, err := client.AddSecret(keyCtx("add-1"), req)
+	require.NoError(t, err)
+	retry, err := client.AddSecret(keyCtx("add-1"), req)
+	require.NoError(t, err)
+	assert.Equal(t, first.Id, retry.Id)
+
+	get, err := client.GetSecret(keyCtx("add-1"), &proto.GetSecretRequest{})
+	require.NoError(t, err)
+	assert.Len(t, get.Secret, 1)
+
+	// The key can't be reused for another request.
+	_, err = client.AddSecret(keyCtx("add-1"), &proto.AddSecretRequest{Secret: &proto.Secret{Data: "other"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Failed requests release the key, so a corrected retry with the same key is applied.
+	edit := &proto.EditSecretRequest{Id: first.Id, Secret: &proto.Secret{Data: "edited", Type: "unknown"}}
+	_, err = client.EditSecret(keyCtx("edit-1"), edit)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	edit.Secret.Type = ""
+	_, err = client.EditSecret(keyCtx("edit-1"), edit)
+	require.NoError(t, err)
+	_, err = client.EditSecret(keyCtx("edit-1"), edit)
+	require.NoError(t, err)
+
+	// Requests without the key are applied every time.
+	plainCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("token", token))
+	_, err = client.AddSecret(plainCtx, req)
+	require.NoError(t, err)
+	_, err = client.AddSecret(plainCtx, req)
+	require.NoError(t, err)
+	get, err = client.GetSecret(plainCtx, &proto.GetSecretRequest{})
+	require.NoError(t, err)
+	assert.Len(t, get.Secret, 3)
 }
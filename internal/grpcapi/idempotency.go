@@ -0,0 +1,102 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// IdempotencyInterceptor is a gRPC interceptor making mutating requests with an idempotency key safe to retry.
+// The response of the first request is saved and replayed for retries with the same key.
+// Requests without the key are handled as usual. It must be chained after the authentification interceptor.
+func IdempotencyInterceptor(svc *app.KeeperService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := idempotencyKey(ctx)
+		userID, ok := auth.GetUserIDFromContext(ctx)
+		if key == "" || !proto.IdempotentMethods[info.FullMethod] || !ok || userID == "" {
+			return handler(ctx, req)
+		}
+
+		requestHash, err := hashRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		saved, err := svc.BeginIdempotentRequest(ctx, userID, key, info.FullMethod, requestHash)
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil {
+			return replayResponse(saved)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if releaseErr := svc.ReleaseIdempotentRequest(ctx, userID, key); releaseErr != nil {
+				logging.Sugar.Errorw("Failed to release idempotency key", "method", info.FullMethod, "error", releaseErr)
+			}
+			return nil, err
+		}
+
+		// The request is already applied, so failing to save its response only disables replaying it.
+		if err := saveResponse(ctx, svc, userID, key, resp); err != nil {
+			logging.Sugar.Errorw("Failed to save response for idempotency key", "method", info.FullMethod, "error", err)
+		}
+		return resp, nil
+	}
+}
+
+// idempotencyKey returns the idempotency key from request metadata, empty if it is not set.
+func idempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(proto.IdempotencyKeyHeader)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// hashRequest returns hex encoded SHA-256 of the deterministically serialized request.
+func hashRequest(req interface{}) (string, error) {
+	msg, _ := req.(protobuf.Message)
+	data, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// saveResponse serializes the response with its type and saves it under the idempotency key.
+func saveResponse(ctx context.Context, svc *app.KeeperService, userID, key string, resp interface{}) error {
+	msg, _ := resp.(protobuf.Message)
+	wrapped, err := anypb.New(msg)
+	if err != nil {
+		return err
+	}
+	data, err := protobuf.Marshal(wrapped)
+	if err != nil {
+		return err
+	}
+	return svc.CompleteIdempotentRequest(ctx, userID, key, data)
+}
+
+// replayResponse restores a response saved by saveResponse.
+func replayResponse(data []byte) (interface{}, error) {
+	var wrapped anypb.Any
+	if err := protobuf.Unmarshal(data, &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.UnmarshalNew()
+}
@@ -0,0 +1,34 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InviteMember is the gRPC method for inviting a user to organization.
+// Request contains organization collection keys wrapped with the invited user's public key.
+func (s *GophKeeperServer) InviteMember(ctx context.Context, req *proto.InviteMemberRequest) (*proto.InviteMemberResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetUsername() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username must be provided")
+	}
+
+	// Call to business logic.
+	id, err := s.svc.InviteMember(ctx, userID, req.GetOrgId(), req.GetUsername(), models.Role(req.GetRole()), keyGrants(req.GetKeys()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to invite member: %w", err)
+	}
+
+	return &proto.InviteMemberResponse{Id: id}, nil
+}
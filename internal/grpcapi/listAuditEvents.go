@@ -0,0 +1,57 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ListAuditEvents is the gRPC method returning audit events of an authentificated user in the time range.
+func (s *GophKeeperServer) ListAuditEvents(ctx context.Context, req *proto.ListAuditEventsRequest) (*proto.ListAuditEventsResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	var from, to time.Time
+	if req.From != nil {
+		from = req.From.AsTime()
+	}
+	if req.To != nil {
+		to = req.To.AsTime()
+	}
+
+	// Call to business logic.
+	events, err := s.svc.ListAuditEvents(ctx, userID, from, to, int(req.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+
+	// Prepare response.
+	var protoEvents []*proto.AuditEvent
+	for _, e := range events {
+		protoEvents = append(protoEvents, &proto.AuditEvent{
+			Id:        e.ID,
+			Type:      e.Type,
+			Method:    e.Method,
+			Success:   e.Success,
+			PeerIp:    e.PeerIP,
+			UserAgent: e.UserAgent,
+			Details:   e.Details,
+			CreatedAt: timestamppb.New(e.CreatedAt),
+		})
+	}
+
+	return &proto.ListAuditEventsResponse{Events: protoEvents}, nil
+}
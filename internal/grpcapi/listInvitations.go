@@ -0,0 +1,40 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListInvitations is the gRPC method returning pending invitations of an authentificated user.
+func (s *GophKeeperServer) ListInvitations(ctx context.Context, req *proto.ListInvitationsRequest) (*proto.ListInvitationsResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	invitations, err := s.svc.ListInvitations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	// Prepare response.
+	var protoInvitations []*proto.Invitation
+	for _, inv := range invitations {
+		protoInvitations = append(protoInvitations, &proto.Invitation{
+			Id:        inv.ID,
+			OrgId:     inv.OrgID,
+			OrgName:   inv.OrgName,
+			Role:      string(inv.Role),
+			InvitedBy: inv.InvitedBy,
+		})
+	}
+
+	return &proto.ListInvitationsResponse{Invitations: protoInvitations}, nil
+}
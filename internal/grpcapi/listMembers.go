@@ -0,0 +1,38 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListMembers is the gRPC method returning members of organization with their public keys.
+func (s *GophKeeperServer) ListMembers(ctx context.Context, req *proto.ListMembersRequest) (*proto.ListMembersResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	members, err := s.svc.ListMembers(ctx, userID, req.GetOrgId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	// Prepare response.
+	var protoMembers []*proto.OrgMember
+	for _, m := range members {
+		protoMembers = append(protoMembers, &proto.OrgMember{
+			Username:  m.Username,
+			Role:      string(m.Role),
+			PublicKey: m.PublicKey,
+		})
+	}
+
+	return &proto.ListMembersResponse{Members: protoMembers}, nil
+}
@@ -0,0 +1,38 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListOrganizations is the gRPC method returning organizations of an authentificated user with his roles.
+func (s *GophKeeperServer) ListOrganizations(ctx context.Context, req *proto.ListOrganizationsRequest) (*proto.ListOrganizationsResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	memberships, err := s.svc.ListOrganizations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	// Prepare response.
+	var orgs []*proto.Organization
+	for _, m := range memberships {
+		orgs = append(orgs, &proto.Organization{
+			Id:   m.OrgID,
+			Name: m.OrgName,
+			Role: string(m.Role),
+		})
+	}
+
+	return &proto.ListOrganizationsResponse{Organizations: orgs}, nil
+}
@@ -0,0 +1,39 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListSecrets is the gRPC method returning a page of secrets of an authentificated user.
+func (s *GophKeeperServer) ListSecrets(ctx context.Context, req *proto.ListSecretsRequest) (*proto.ListSecretsResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	var updatedAfter time.Time
+	if req.GetUpdatedAfter() != nil {
+		updatedAfter = req.GetUpdatedAfter().AsTime()
+	}
+
+	// Call to business logic.
+	page, err := s.svc.ListSecrets(ctx, userID, int(req.GetPageSize()), req.GetPageToken(),
+		req.GetOrderBy(), req.GetType(), updatedAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Secrets: %w", err)
+	}
+
+	return &proto.ListSecretsResponse{
+		Secret:        protoSecrets(page.Secrets),
+		NextPageToken: page.NextPageToken,
+		Total:         page.Total,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListSharedWithMe is the gRPC method returning all secrets shared with an authentificated user.
+func (s *GophKeeperServer) ListSharedWithMe(ctx context.Context, req *proto.ListSharedWithMeRequest) (*proto.ListSharedWithMeResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	shares, err := s.svc.ListSharedWithMe(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shared secrets: %w", err)
+	}
+
+	// Prepare response.
+	var protoShares []*proto.SharedSecret
+	for _, sh := range shares {
+		protoShares = append(protoShares, &proto.SharedSecret{
+			Id:         sh.ID,
+			SecretId:   sh.SecretID,
+			Owner:      sh.OwnerName,
+			WrappedKey: sh.WrappedKey,
+			Secret: &proto.Secret{
+				Data: sh.Data,
+				Meta: sh.Meta,
+			},
+		})
+	}
+
+	return &proto.ListSharedWithMeResponse{Shares: protoShares}, nil
+}
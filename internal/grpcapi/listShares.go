@@ -0,0 +1,38 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListShares is the gRPC method for listing recipients of secrets of an authentificated user with their public keys.
+func (s *GophKeeperServer) ListShares(ctx context.Context, req *proto.ListSharesRequest) (*proto.ListSharesResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	recipients, err := s.svc.ListShares(ctx, userID, req.GetSecretId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shares: %w", err)
+	}
+
+	// Prepare response.
+	var shares []*proto.ShareRecipient
+	for _, r := range recipients {
+		shares = append(shares, &proto.ShareRecipient{
+			SecretId:  r.SecretID,
+			Recipient: r.Username,
+			PublicKey: r.PublicKey,
+		})
+	}
+
+	return &proto.ListSharesResponse{Shares: shares}, nil
+}
@@ -2,8 +2,11 @@ package grpcapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
 	"github.com/KirillZiborov/GophKeeper/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -20,7 +23,11 @@ func (s *GophKeeperServer) Login(ctx context.Context, req *proto.LoginRequest) (
 	// Call to business logic.
 	token, err := s.svc.Login(ctx, userData.Username, userData.Password)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "login failed: %v", err)
+		// Unknown usernames and wrong passwords are reported alike.
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, app.ErrUserNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "login failed: %v", app.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("login failed: %w", err)
 	}
 
 	// Set token to the response header.
@@ -0,0 +1,31 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// OpenSend is the gRPC method for opening a send by its id.
+// It is available without authentification and burns one view of the send.
+func (s *GophKeeperServer) OpenSend(ctx context.Context, req *proto.OpenSendRequest) (*proto.OpenSendResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "send id must be provided")
+	}
+
+	// Call to business logic.
+	send, err := s.svc.OpenSend(ctx, req.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open send: %w", err)
+	}
+
+	return &proto.OpenSendResponse{
+		Ciphertext: send.Ciphertext,
+		ViewsLeft:  int32(send.ViewsLeft),
+		ExpiresAt:  timestamppb.New(send.ExpiresAt),
+	}, nil
+}
@@ -21,7 +21,7 @@ func (s *GophKeeperServer) Register(ctx context.Context, req *proto.RegisterRequ
 	// Call to business logic.
 	token, err := s.svc.Register(ctx, userData.Username, userData.Password)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "registration failed: %v", err)
+		return nil, fmt.Errorf("registration failed: %w", err)
 	}
 
 	// Set token to the response header.
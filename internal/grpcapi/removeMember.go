@@ -0,0 +1,32 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RemoveMember is the gRPC method for removing a member from organization.
+func (s *GophKeeperServer) RemoveMember(ctx context.Context, req *proto.RemoveMemberRequest) (*proto.RemoveMemberResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetUsername() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username must be provided")
+	}
+
+	// Call to business logic.
+	err := s.svc.RemoveMember(ctx, userID, req.GetOrgId(), req.GetUsername())
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	return &proto.RemoveMemberResponse{}, nil
+}
@@ -0,0 +1,32 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RevokeShare is the gRPC method for revoking recipient's access to a secret of an authentificated user.
+func (s *GophKeeperServer) RevokeShare(ctx context.Context, req *proto.RevokeShareRequest) (*proto.RevokeShareResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetRecipient() == "" {
+		return nil, status.Error(codes.InvalidArgument, "recipient must be provided")
+	}
+
+	// Call to business logic.
+	err := s.svc.RevokeShare(ctx, userID, req.GetSecretId(), req.GetRecipient())
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke share: %w", err)
+	}
+
+	return &proto.RevokeShareResponse{}, nil
+}
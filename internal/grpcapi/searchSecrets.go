@@ -0,0 +1,28 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SearchSecrets is the gRPC method returning secrets of an authentificated user matching blind index tokens.
+func (s *GophKeeperServer) SearchSecrets(ctx context.Context, req *proto.SearchSecretsRequest) (*proto.SearchSecretsResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	// Call to business logic.
+	secrets, err := s.svc.SearchSecrets(ctx, userID, req.GetTokens())
+	if err != nil {
+		return nil, fmt.Errorf("failed to search Secrets: %w", err)
+	}
+
+	return &proto.SearchSecretsResponse{Secret: protoSecrets(secrets)}, nil
+}
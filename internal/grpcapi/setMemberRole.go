@@ -0,0 +1,33 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetMemberRole is the gRPC method for changing role of organization member.
+func (s *GophKeeperServer) SetMemberRole(ctx context.Context, req *proto.SetMemberRoleRequest) (*proto.SetMemberRoleResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	if req.GetUsername() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username must be provided")
+	}
+
+	// Call to business logic.
+	err := s.svc.SetMemberRole(ctx, userID, req.GetOrgId(), req.GetUsername(), models.Role(req.GetRole()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set member role: %w", err)
+	}
+
+	return &proto.SetMemberRoleResponse{}, nil
+}
@@ -0,0 +1,33 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetUserKeys is the gRPC method for saving sharing keypair of an authentificated user.
+func (s *GophKeeperServer) SetUserKeys(ctx context.Context, req *proto.SetUserKeysRequest) (*proto.SetUserKeysResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	keys := req.GetKeys()
+	if keys == nil || keys.PublicKey == "" || keys.EncryptedPrivateKey == "" {
+		return nil, status.Error(codes.InvalidArgument, "public and encrypted private keys must be provided")
+	}
+
+	// Call to business logic.
+	err := s.svc.SetUserKeys(ctx, userID, keys.PublicKey, keys.EncryptedPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set keys: %w", err)
+	}
+
+	return &proto.SetUserKeysResponse{}, nil
+}
@@ -0,0 +1,37 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ShareSecret is the gRPC method for sharing secret of an authentificated user with another user.
+// Client re-encrypts secret with a data key and wraps the data key with recipient's public key.
+func (s *GophKeeperServer) ShareSecret(ctx context.Context, req *proto.ShareSecretRequest) (*proto.ShareSecretResponse, error) {
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	secret := req.GetSecret()
+	if secret == nil || secret.Data == "" {
+		return nil, status.Error(codes.InvalidArgument, "Secret data must be provided")
+	}
+	if req.GetRecipient() == "" || req.GetWrappedKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "recipient and wrapped key must be provided")
+	}
+
+	// Call to business logic.
+	id, err := s.svc.ShareSecret(ctx, userID, req.GetSecretId(), req.GetRecipient(), req.GetWrappedKey(), secret.Data, secret.Meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share Secret: %w", err)
+	}
+
+	return &proto.ShareSecretResponse{Id: id}, nil
+}
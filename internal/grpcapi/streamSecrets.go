@@ -0,0 +1,43 @@
+package grpcapi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamSecrets is the gRPC method streaming secrets of an authentificated user.
+// Every secret is sent as soon as it is read from the storage.
+func (s *GophKeeperServer) StreamSecrets(req *proto.StreamSecretsRequest, stream grpc.ServerStreamingServer[proto.CountedSecret]) error {
+	ctx := stream.Context()
+
+	// Extract userID from context set by interceptor.
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return status.Error(codes.Unauthenticated, "unauthenticated: no valid token")
+	}
+
+	var updatedAfter time.Time
+	if req.GetUpdatedAfter() != nil {
+		updatedAfter = req.GetUpdatedAfter().AsTime()
+	}
+
+	// Call to business logic.
+	err := s.svc.StreamSecrets(ctx, userID, req.GetType(), updatedAfter, func(secret models.Secret) error {
+		return stream.Send(&proto.CountedSecret{
+			Id:     secret.ID,
+			Secret: protoSecret(secret),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to stream Secrets: %w", err)
+	}
+
+	return nil
+}
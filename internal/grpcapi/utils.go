@@ -2,9 +2,14 @@ package grpcapi
 
 import (
 	"context"
+	"time"
 
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // setResponseToken sets token in response header using grpc.SetHeader.
@@ -12,3 +17,66 @@ func setResponseToken(ctx context.Context, token string) error {
 	md := metadata.Pairs("token", token)
 	return grpc.SetHeader(ctx, md)
 }
+
+// keyGrants converts wrapped collection keys from request to business logic structures.
+func keyGrants(keys []*proto.CollectionKey) []app.CollectionKeyGrant {
+	grants := make([]app.CollectionKeyGrant, 0, len(keys))
+	for _, k := range keys {
+		grants = append(grants, app.CollectionKeyGrant{
+			CollectionID: k.CollectionId,
+			Username:     k.Username,
+			WrappedKey:   k.WrappedKey,
+		})
+	}
+	return grants
+}
+
+// sharedCopies converts copies of an edited secret re-encrypted for its recipients to business logic structures.
+func sharedCopies(copies []*proto.SharedCopy) []models.Share {
+	shares := make([]models.Share, 0, len(copies))
+	for _, c := range copies {
+		shares = append(shares, models.Share{
+			RecipientName: c.GetRecipient(),
+			WrappedKey:    c.GetWrappedKey(),
+			Data:          c.GetSecret().GetData(),
+			Meta:          c.GetSecret().GetMeta(),
+		})
+	}
+	return shares
+}
+
+// protoSecrets converts secrets to the gRPC representation.
+func protoSecrets(secrets []models.Secret) []*proto.CountedSecret {
+	var protoSecrets []*proto.CountedSecret
+	for _, c := range secrets {
+		protoSecrets = append(protoSecrets, &proto.CountedSecret{
+			Id:     c.ID,
+			Secret: protoSecret(c),
+		})
+	}
+	return protoSecrets
+}
+
+// protoSecret converts secret to proto structure.
+func protoSecret(secret models.Secret) *proto.Secret {
+	ps := &proto.Secret{
+		Data: secret.Data,
+		Meta: secret.Meta,
+		Type: secret.Type,
+	}
+	if !secret.ExpiresAt.IsZero() {
+		ps.ExpiresAt = timestamppb.New(secret.ExpiresAt)
+	}
+	if !secret.UpdatedAt.IsZero() {
+		ps.UpdatedAt = timestamppb.New(secret.UpdatedAt)
+	}
+	return ps
+}
+
+// expiresAt returns expiration time of the proto secret, zero if it is not set.
+func expiresAt(secret *proto.Secret) time.Time {
+	if secret.GetExpiresAt() == nil {
+		return time.Time{}
+	}
+	return secret.GetExpiresAt().AsTime()
+}
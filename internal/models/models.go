@@ -1,6 +1,14 @@
 // Package models provides internal structures representing users and their secrets.
 package models
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"time"
+)
+
 // User represents a registered user.
 type User struct {
 	ID       string `json:"id"`       // Unique user's id
@@ -10,8 +18,255 @@ type User struct {
 
 // Secret represents secret data.
 type Secret struct {
-	ID     int64  `json:"id"`      // Unique credentials id
-	UserID string `json:"user_id"` // User's id
-	Data   string `json:"data"`    // Secret data
-	Meta   string `json:"meta"`    // Additional Metadata
+	ID       string `json:"id"`                  // Unique secret UUID, may be generated by the client
+	LegacyID int64  `json:"legacy_id,omitempty"` // Integer id of secrets created before UUIDs, 0 for newer ones
+	UserID   string `json:"user_id"`             // User's id
+	Data     string `json:"data"`                // Secret data
+	Meta     string `json:"meta"`                // Additional Metadata
+
+	CollectionID int64 `json:"collection_id"` // Organization collection id, 0 for personal secrets
+
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Time after which the secret is deleted, zero if it never expires
+
+	SearchTokens []string `json:"search_tokens,omitempty"` // Blind index tokens of the secret fields
+
+	Type      string    `json:"type,omitempty"` // Plaintext secret type set by the client: card, credentials, text, bin or otp
+	UpdatedAt time.Time `json:"updated_at"`     // Time of the last creation or update
+}
+
+// Expired reports whether the secret is expired at the moment now.
+func (s Secret) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// Size returns the number of bytes the secret takes in user's quota.
+func (s Secret) Size() int64 {
+	return int64(len(s.Data) + len(s.Meta))
+}
+
+// Secret types.
+const (
+	SecretTypeCard        = "card"
+	SecretTypeCredentials = "credentials"
+	SecretTypeText        = "text"
+	SecretTypeBin         = "bin"
+	SecretTypeOTP         = "otp"
+)
+
+// Orders of listing secrets.
+const (
+	SecretOrderID      = "id"         // Ascending secret ID
+	SecretOrderUpdated = "updated_at" // Ascending update time, then ID
+)
+
+// SecretFilter selects a page of user's secrets.
+type SecretFilter struct {
+	Type         string    // Secret type, empty for all types
+	UpdatedAfter time.Time // Only secrets updated after the time, zero for all
+	OrderBy      string    // SecretOrderID or SecretOrderUpdated
+
+	AfterID        string    // ID of the last secret of the previous page, empty for the first page
+	AfterUpdatedAt time.Time // Update time of the last secret of the previous page for SecretOrderUpdated
+	Limit          int       // Maximum number of secrets in the page
+}
+
+// MutationOp is an operation of a batch mutation.
+type MutationOp int
+
+// Batch mutation operations.
+const (
+	MutationCreate MutationOp = iota + 1 // Create a new secret
+	MutationUpdate                       // Update an existing secret
+	MutationDelete                       // Delete an existing secret
+)
+
+// SecretMutation is a single operation of a batch applied to user's personal secrets.
+// Secret.ID selects the secret to update or delete, on create it is either set by the client or generated.
+// Updates with empty Secret.Type keep the current type.
+type SecretMutation struct {
+	Op            MutationOp // Operation
+	Secret        Secret     // New secret or its new content
+	KeepExpiresAt bool       // Keep the current expiration time on update
+	Shares        []Share    // Copies of the updated secret re-encrypted for its recipients
+}
+
+// MutationResult is the result of a single operation of a batch mutation.
+type MutationResult struct {
+	ID  string // ID of the created, updated or deleted secret
+	Err error  // Error of the operation, nil if it was applied
+}
+
+// IdempotencyKey is a client-generated key of a mutating request with the response saved for retries.
+type IdempotencyKey struct {
+	UserID      string    // User's id
+	Key         string    // Key sent by the client
+	Method      string    // Full name of the gRPC method
+	RequestHash string    // Hex encoded SHA-256 of the request
+	Response    []byte    // Serialized response, nil while the request is in progress
+	ExpiresAt   time.Time // Time after which the key may be reused
+}
+
+// Quota limits user's storage. Zero value of a field means no limit.
+type Quota struct {
+	MaxSecrets     int64 `json:"max_secrets"`      // Maximum number of secrets
+	MaxSecretBytes int64 `json:"max_secret_bytes"` // Maximum size of a single secret
+	MaxTotalBytes  int64 `json:"max_total_bytes"`  // Maximum total size of secrets
+}
+
+// Usage represents user's storage usage.
+type Usage struct {
+	Secrets int64 `json:"secrets"` // Number of secrets
+	Bytes   int64 `json:"bytes"`   // Total size of secrets and ciphertexts of sends
+}
+
+// UserKeys represents user's X25519 keypair used for sharing secrets.
+// Private key is encrypted on the client with user's encryption key.
+type UserKeys struct {
+	UserID              string `json:"user_id"`               // User's id
+	PublicKey           string `json:"public_key"`            // Base64 encoded public key
+	EncryptedPrivateKey string `json:"encrypted_private_key"` // Private key encrypted with user's key
+}
+
+// Share represents a secret shared by its owner with another user.
+// Data and Meta are encrypted with a data key, the data key is wrapped with recipient's public key.
+type Share struct {
+	ID            int64  `json:"id"`             // Unique share id
+	SecretID      string `json:"secret_id"`      // Shared secret id
+	OwnerID       string `json:"owner_id"`       // Secret owner's id
+	OwnerName     string `json:"owner_name"`     // Secret owner's username
+	RecipientID   string `json:"recipient_id"`   // Recipient's id
+	RecipientName string `json:"recipient_name"` // Recipient's username
+	WrappedKey    string `json:"wrapped_key"`    // Data key wrapped for the recipient
+	Data          string `json:"data"`           // Secret data encrypted with data key
+	Meta          string `json:"meta"`           // Metadata encrypted with data key
+}
+
+// Role is a role of a member in organization.
+type Role string
+
+// Organization member roles from the most to the least privileged.
+const (
+	RoleOwner    Role = "owner"     // Manages organization and all members
+	RoleAdmin    Role = "admin"     // Manages collections and non-owner members
+	RoleMember   Role = "member"    // Reads and writes collection secrets
+	RoleReadOnly Role = "read-only" // Reads collection secrets
+)
+
+// Valid reports whether the role is known.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleOwner, RoleAdmin, RoleMember, RoleReadOnly:
+		return true
+	}
+	return false
+}
+
+// CanWrite reports whether the role allows to create and edit collection secrets.
+func (r Role) CanWrite() bool {
+	return r == RoleOwner || r == RoleAdmin || r == RoleMember
+}
+
+// CanManage reports whether the role allows to manage collections and members.
+func (r Role) CanManage() bool {
+	return r == RoleOwner || r == RoleAdmin
+}
+
+// Organization represents a team owning collections of secrets.
+type Organization struct {
+	ID   int64  `json:"id"`   // Unique organization id
+	Name string `json:"name"` // Organization name
+}
+
+// Member represents a user's membership in organization.
+type Member struct {
+	OrgID     int64  `json:"org_id"`     // Organization id
+	OrgName   string `json:"org_name"`   // Organization name
+	UserID    string `json:"user_id"`    // Member's id
+	Username  string `json:"username"`   // Member's username
+	Role      Role   `json:"role"`       // Member's role
+	PublicKey string `json:"public_key"` // Member's public sharing key
+}
+
+// Invitation represents a pending invitation of a user to organization.
+type Invitation struct {
+	ID        int64  `json:"id"`         // Unique invitation id
+	OrgID     int64  `json:"org_id"`     // Organization id
+	OrgName   string `json:"org_name"`   // Organization name
+	UserID    string `json:"user_id"`    // Invited user's id
+	Role      Role   `json:"role"`       // Role granted on acceptance
+	InvitedBy string `json:"invited_by"` // Inviting member: id when saved, username when listed
+}
+
+// Collection represents a collection of secrets owned by organization.
+type Collection struct {
+	ID    int64  `json:"id"`     // Unique collection id
+	OrgID int64  `json:"org_id"` // Organization id
+	Name  string `json:"name"`   // Collection name
+}
+
+// CollectionKey is a collection key wrapped with a member's public key.
+type CollectionKey struct {
+	CollectionID   int64  `json:"collection_id"`   // Collection id
+	CollectionName string `json:"collection_name"` // Collection name
+	OrgID          int64  `json:"org_id"`          // Organization id
+	UserID         string `json:"user_id"`         // Member's id
+	WrappedKey     string `json:"wrapped_key"`     // Collection key wrapped for the member
+}
+
+// AuditEvent represents a security-relevant event recorded in the audit log.
+type AuditEvent struct {
+	ID        int64     `json:"id"`         // Unique sequential event id
+	UserID    string    `json:"user_id"`    // User's id, empty if user is unknown
+	Type      string    `json:"type"`       // Event type, e.g. login.success
+	Method    string    `json:"method"`     // gRPC method which caused the event
+	Success   bool      `json:"success"`    // Whether the operation succeeded
+	PeerIP    string    `json:"peer_ip"`    // Client IP address
+	UserAgent string    `json:"user_agent"` // Client user agent
+	Details   string    `json:"details"`    // Additional details
+	CreatedAt time.Time `json:"created_at"` // Event time
+	PrevHash  string    `json:"prev_hash"`  // Hash of the previous event in the chain
+	Hash      string    `json:"hash"`       // Hash of this event including PrevHash
+}
+
+// ComputeHash returns hex-encoded SHA-256 of the event fields and the previous event's hash.
+// The event ID is not hashed: the order of events is fixed by PrevHash links.
+// CreatedAt is hashed with microsecond precision which is kept by PostgreSQL.
+func (e AuditEvent) ComputeHash() string {
+	h := sha256.New()
+	for _, field := range []string{
+		e.PrevHash, e.UserID, e.Type, e.Method, fmt.Sprint(e.Success),
+		e.PeerIP, e.UserAgent, e.Details, fmt.Sprint(e.CreatedAt.UnixMicro()),
+	} {
+		writeField(h, field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeField writes length-prefixed field to the hash so that fields can't be shifted into each other.
+func writeField(h hash.Hash, field string) {
+	fmt.Fprintf(h, "%d:%s;", len(field), field)
+}
+
+// AuditCheckpoint is a signed hash of the audit log chain at the given event.
+type AuditCheckpoint struct {
+	EventID   int64     `json:"event_id"`  // ID of the last event covered by the checkpoint
+	Hash      string    `json:"hash"`      // Hash of the event
+	Signature string    `json:"signature"` // Base64 Ed25519 signature of the checkpoint
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Send is an ephemeral encrypted payload shared by link with people without an account.
+// The server stores ciphertext only, the key is kept in the link fragment.
+type Send struct {
+	ID         string    `json:"id"`         // Random unguessable id
+	OwnerID    string    `json:"owner_id"`   // Creator's user id
+	Ciphertext string    `json:"ciphertext"` // Encrypted payload
+	ViewsLeft  int       `json:"views_left"` // Number of remaining views
+	ExpiresAt  time.Time `json:"expires_at"` // Time after which the send is deleted
+	CreatedAt  time.Time `json:"created_at"` // Creation time
+}
+
+// Size returns the number of bytes the send takes in owner's quota.
+func (s Send) Size() int64 {
+	return int64(len(s.Ciphertext))
 }
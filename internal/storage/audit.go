@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// auditLockID is the advisory lock key serializing appends to the audit log chain.
+const auditLockID = 0x61756469 // "audi"
+
+// AddAuditEvent appends event to the audit log.
+// The audit log is append-only: events are never updated or deleted, even with their user.
+// Each event stores the hash of the previous one, appends are serialized with an advisory lock
+// so that concurrent server replicas build a single chain.
+func (store *DBStore) AddAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, auditLockID); err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(ctx, `SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&event.PrevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	event.Hash = event.ComputeHash()
+
+	var userID *string
+	if event.UserID != "" {
+		userID = &event.UserID
+	}
+
+	query := `
+	INSERT INTO audit_events (user_id, type, method, success, peer_ip, user_agent, details, created_at, prev_hash, hash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+	err = tx.QueryRow(ctx, query, userID, event.Type, event.Method, event.Success, event.PeerIP,
+		event.UserAgent, event.Details, event.CreatedAt, event.PrevHash, event.Hash).Scan(&event.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// auditColumns are the columns of audit_events scanned by scanAuditEvent.
+const auditColumns = `id, COALESCE(user_id::text, ''), type, method, success, peer_ip, user_agent, details, created_at, prev_hash, hash`
+
+// scanAuditEvent scans a row selected with auditColumns.
+func scanAuditEvent(row pgx.Row) (models.AuditEvent, error) {
+	var event models.AuditEvent
+	err := row.Scan(&event.ID, &event.UserID, &event.Type, &event.Method, &event.Success, &event.PeerIP,
+		&event.UserAgent, &event.Details, &event.CreatedAt, &event.PrevHash, &event.Hash)
+	event.CreatedAt = event.CreatedAt.UTC()
+
+	return event, err
+}
+
+// GetAuditEvents retrieves user's audit events created in [from, to) ordered by time.
+func (store *DBStore) GetAuditEvents(ctx context.Context, userID string, from, to time.Time, limit int) ([]models.AuditEvent, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT ` + auditColumns + `
+	FROM audit_events
+	WHERE user_id = $1
+		AND ($2::timestamptz IS NULL OR created_at >= $2)
+		AND ($3::timestamptz IS NULL OR created_at < $3)
+	ORDER BY id
+	LIMIT NULLIF($4, 0)`
+	if limit < 0 {
+		limit = 0
+	}
+	rows, err := store.db.Query(ctx, query, userID, nullableTime(from), nullableTime(to), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve audit event", "error", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		logging.Sugar.Errorw("failed to retrieve audit event", "error", err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ScanAuditEvents calls fn for every audit event ordered by ID without loading the whole log in memory.
+func (store *DBStore) ScanAuditEvents(ctx context.Context, fn func(event models.AuditEvent) error) error {
+	rows, err := store.db.Query(ctx, `SELECT `+auditColumns+` FROM audit_events ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// AddAuditCheckpoint saves signed checkpoint of the audit log.
+func (store *DBStore) AddAuditCheckpoint(ctx context.Context, cp *models.AuditCheckpoint) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO audit_checkpoints (event_id, hash, signature, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := store.db.Exec(ctx, query, cp.EventID, cp.Hash, cp.Signature, cp.CreatedAt)
+
+	return err
+}
+
+// GetAuditCheckpoints retrieves all audit log checkpoints ordered by event ID.
+func (store *DBStore) GetAuditCheckpoints(ctx context.Context) ([]models.AuditCheckpoint, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT event_id, hash, signature, created_at FROM audit_checkpoints ORDER BY event_id`
+	rows, err := store.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := make([]models.AuditCheckpoint, 0)
+	for rows.Next() {
+		var cp models.AuditCheckpoint
+		if err := rows.Scan(&cp.EventID, &cp.Hash, &cp.Signature, &cp.CreatedAt); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+
+	return checkpoints, rows.Err()
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrBatchAborted is the result of mutations not applied because another mutation of an atomic batch failed.
+var ErrBatchAborted = NewError(KindAborted, "batch aborted: another mutation failed")
+
+// ErrInvalidMutation is returned for a mutation with unknown operation.
+var ErrInvalidMutation = NewError(KindInvalidArgument, "invalid mutation operation")
+
+// applyMutations applies mutations one by one with apply and returns their results.
+// Mutations failing with a domain error must leave no changes, their errors are reported in the results.
+// In atomic mode the first failed mutation stops the batch and the remaining ones are marked with ErrBatchAborted.
+// Reports whether all the mutations were applied. Other errors abort the whole batch.
+func applyMutations(mutations []models.SecretMutation, atomic bool,
+	apply func(m models.SecretMutation) (string, error)) ([]models.MutationResult, bool, error) {
+	results := make([]models.MutationResult, len(mutations))
+	applied := true
+	for i, m := range mutations {
+		if !applied && atomic {
+			results[i] = models.MutationResult{ID: m.Secret.ID, Err: ErrBatchAborted}
+			continue
+		}
+
+		id, err := apply(m)
+		if err != nil && KindOf(err) == KindInternal {
+			return nil, false, err
+		}
+		if err != nil {
+			id = m.Secret.ID
+			applied = false
+		}
+		results[i] = models.MutationResult{ID: id, Err: err}
+	}
+
+	if !applied && atomic {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = ErrBatchAborted
+			}
+		}
+	}
+
+	return results, applied, nil
+}
+
+// MutateSecrets applies mutations to user's personal secrets in one transaction holding a lock on the user.
+// Every mutation checks ownership and quota before writing, so failed mutations leave no changes.
+func (store *DBStore) MutateSecrets(ctx context.Context, userID string, mutations []models.SecretMutation,
+	quota models.Quota, atomic bool) ([]models.MutationResult, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	usage, err := lockUsage(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	results, applied, err := applyMutations(mutations, atomic, func(m models.SecretMutation) (string, error) {
+		return mutateSecret(ctx, tx, userID, m, quota, &usage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !applied && atomic {
+		return results, nil
+	}
+
+	return results, tx.Commit(ctx)
+}
+
+// mutateSecret applies mutation to user's secret inside transaction tx and updates usage.
+func mutateSecret(ctx context.Context, tx pgx.Tx, userID string, m models.SecretMutation,
+	quota models.Quota, usage *models.Usage) (string, error) {
+	secret := m.Secret
+	switch m.Op {
+	case models.MutationCreate:
+		if err := checkQuota(quota, *usage, 1, secret.Size(), secret.Size()); err != nil {
+			return "", err
+		}
+
+		if secret.ID == "" {
+			secret.ID = uuid.NewString()
+		}
+		// Conflicts don't abort the transaction, so the batch goes on after a taken ID.
+		query := `
+		INSERT INTO secrets (id, user_id, data, meta, expires_at, search_tokens, type, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, now()) ON CONFLICT (id) DO NOTHING`
+		tag, err := tx.Exec(ctx, query, secret.ID, userID, secret.Data, secret.Meta, nullableTime(secret.ExpiresAt),
+			searchTokens(secret.SearchTokens), secret.Type)
+		if err != nil {
+			return "", err
+		}
+		if tag.RowsAffected() == 0 {
+			return "", ErrSecretExists
+		}
+		usage.Secrets++
+		usage.Bytes += secret.Size()
+		return secret.ID, nil
+
+	case models.MutationUpdate:
+		var oldSize int64
+		query := `
+		SELECT ` + secretSize + ` FROM secrets
+		WHERE id = $1 AND user_id = $2 AND collection_id IS NULL FOR UPDATE`
+		if err := tx.QueryRow(ctx, query, secret.ID, userID).Scan(&oldSize); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return "", ErrSecretNotFound
+			}
+			return "", err
+		}
+		if err := checkQuota(quota, *usage, 0, secret.Size()-oldSize, secret.Size()); err != nil {
+			return "", err
+		}
+		if err := replaceShareCopies(ctx, tx, secret.ID, m.Shares); err != nil {
+			return "", err
+		}
+
+		query = `
+		UPDATE secrets SET data = $1, meta = $2, search_tokens = $3, type = COALESCE(NULLIF($4, ''), type),
+			expires_at = CASE WHEN $5::boolean THEN expires_at ELSE $6 END, updated_at = now()
+		WHERE id = $7`
+		_, err := tx.Exec(ctx, query, secret.Data, secret.Meta, searchTokens(secret.SearchTokens), secret.Type,
+			m.KeepExpiresAt, nullableTime(secret.ExpiresAt), secret.ID)
+		if err != nil {
+			return "", err
+		}
+		usage.Bytes += secret.Size() - oldSize
+		return secret.ID, nil
+
+	case models.MutationDelete:
+		var size int64
+		query := `
+		DELETE FROM secrets WHERE id = $1 AND user_id = $2 AND collection_id IS NULL
+		RETURNING ` + secretSize
+		if err := tx.QueryRow(ctx, query, secret.ID, userID).Scan(&size); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return "", ErrSecretNotFound
+			}
+			return "", err
+		}
+		usage.Secrets--
+		usage.Bytes -= size
+		return secret.ID, nil
+
+	default:
+		return "", ErrInvalidMutation
+	}
+}
+
+// MutateSecrets applies mutations to user's personal secrets.
+// Mutations are applied to a copy of user's secrets which replaces them unless an atomic batch fails.
+func (fs *FakeStorage) MutateSecrets(ctx context.Context, userID string, mutations []models.SecretMutation,
+	quota models.Quota, atomic bool) ([]models.MutationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, exists := fs.usersByID[userID]; !exists {
+		return nil, ErrNotFound
+	}
+
+	secrets := make(map[string]*models.Secret, len(fs.secrets[userID]))
+	for id, secret := range fs.secrets[userID] {
+		secrets[id] = secret
+	}
+	usage := fs.usage(userID)
+	var deleted []string
+	copies := make(map[string][]models.Share)
+
+	results, applied, err := applyMutations(mutations, atomic, func(m models.SecretMutation) (string, error) {
+		secret := m.Secret
+		secret.UserID = userID
+		secret.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+		switch m.Op {
+		case models.MutationCreate:
+			if err := checkQuota(quota, usage, 1, secret.Size(), secret.Size()); err != nil {
+				return "", err
+			}
+			if secret.ID == "" {
+				secret.ID = uuid.NewString()
+			}
+			if _, exists := secrets[secret.ID]; exists || fs.secretExists(secret.ID) {
+				return "", ErrSecretExists
+			}
+			secret.CollectionID = 0
+			usage.Secrets++
+			usage.Bytes += secret.Size()
+
+		case models.MutationUpdate:
+			old, exists := secrets[secret.ID]
+			if !exists || old.CollectionID != 0 {
+				return "", ErrSecretNotFound
+			}
+			if err := checkQuota(quota, usage, 0, secret.Size()-old.Size(), secret.Size()); err != nil {
+				return "", err
+			}
+			if err := checkShareCopies(fs.shareRecipients(secret.ID), m.Shares); err != nil {
+				return "", err
+			}
+			if secret.Type == "" {
+				secret.Type = old.Type
+			}
+			if m.KeepExpiresAt {
+				secret.ExpiresAt = old.ExpiresAt
+			}
+			usage.Bytes += secret.Size() - old.Size()
+			copies[secret.ID] = m.Shares
+
+		case models.MutationDelete:
+			old, exists := secrets[secret.ID]
+			if !exists || old.CollectionID != 0 {
+				return "", ErrSecretNotFound
+			}
+			delete(secrets, secret.ID)
+			deleted = append(deleted, secret.ID)
+			usage.Secrets--
+			usage.Bytes -= old.Size()
+			return secret.ID, nil
+
+		default:
+			return "", ErrInvalidMutation
+		}
+
+		secrets[secret.ID] = &secret
+		return secret.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !applied && atomic {
+		return results, nil
+	}
+
+	fs.secrets[userID] = secrets
+	for secretID, shares := range copies {
+		fs.replaceShareCopies(secretID, shares)
+	}
+	for id, share := range fs.shares {
+		if slices.Contains(deleted, share.SecretID) {
+			delete(fs.shares, id)
+		}
+	}
+	return results, nil
+}
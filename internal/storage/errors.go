@@ -0,0 +1,55 @@
+package storage
+
+import "errors"
+
+// Kind classifies domain errors by the reason of the failure.
+// The API layer reports every kind with its own status code.
+type Kind int
+
+// Kinds of domain errors.
+const (
+	// KindInternal is an unexpected failure, its details must not be shown to clients.
+	KindInternal Kind = iota
+	// KindNotFound means the requested object doesn't exist or isn't visible to the user.
+	KindNotFound
+	// KindAlreadyExists means the object being created already exists.
+	KindAlreadyExists
+	// KindPermissionDenied means the user isn't allowed to perform the operation.
+	KindPermissionDenied
+	// KindInvalidArgument means the request is malformed.
+	KindInvalidArgument
+	// KindFailedPrecondition means the operation is valid but not in the current state.
+	KindFailedPrecondition
+	// KindResourceExhausted means the operation exceeds a limit.
+	KindResourceExhausted
+	// KindUnauthenticated means the user's credentials are wrong.
+	KindUnauthenticated
+	// KindAborted means the operation wasn't applied because the batch it belongs to failed.
+	KindAborted
+)
+
+// Error is a domain error. Its message is safe to show to clients.
+type Error struct {
+	Kind Kind
+	Msg  string
+}
+
+// NewError creates a domain error of the kind.
+func NewError(kind Kind, msg string) *Error {
+	return &Error{Kind: kind, Msg: msg}
+}
+
+// Error returns the message of the error.
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// KindOf returns the kind of the first domain error in the chain of err.
+// Errors without a domain error in their chain are internal.
+func KindOf(err error) Kind {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind
+	}
+	return KindInternal
+}
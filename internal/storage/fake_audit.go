@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+)
+
+// AddAuditEvent appends event to the audit log chaining it to the last event.
+func (fs *FakeStorage) AddAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	event.PrevHash = ""
+	if n := len(fs.auditEvents); n > 0 {
+		event.PrevHash = fs.auditEvents[n-1].Hash
+	}
+	event.Hash = event.ComputeHash()
+	event.ID = int64(len(fs.auditEvents) + 1)
+	fs.auditEvents = append(fs.auditEvents, *event)
+	return nil
+}
+
+// GetAuditEvents returns user's audit events created in [from, to).
+func (fs *FakeStorage) GetAuditEvents(ctx context.Context, userID string, from, to time.Time, limit int) ([]models.AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	events := make([]models.AuditEvent, 0)
+	for _, event := range fs.auditEvents {
+		if event.UserID != userID {
+			continue
+		}
+		if !from.IsZero() && event.CreatedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !event.CreatedAt.Before(to) {
+			continue
+		}
+		if limit > 0 && len(events) == limit {
+			break
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ScanAuditEvents calls fn for every audit event in order.
+func (fs *FakeStorage) ScanAuditEvents(ctx context.Context, fn func(event models.AuditEvent) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	events := append([]models.AuditEvent(nil), fs.auditEvents...)
+	fs.mu.Unlock()
+
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddAuditCheckpoint saves signed checkpoint of the audit log.
+func (fs *FakeStorage) AddAuditCheckpoint(ctx context.Context, cp *models.AuditCheckpoint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.auditCheckpoints = append(fs.auditCheckpoints, *cp)
+	return nil
+}
+
+// GetAuditCheckpoints returns all audit log checkpoints.
+func (fs *FakeStorage) GetAuditCheckpoints(ctx context.Context) ([]models.AuditCheckpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return append([]models.AuditCheckpoint{}, fs.auditCheckpoints...), nil
+}
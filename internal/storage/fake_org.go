@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+)
+
+// CreateOrganization saves a new organization making user its owner.
+func (fs *FakeStorage) CreateOrganization(ctx context.Context, org *models.Organization, ownerID string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	org.ID = fs.nextOrgID
+	fs.nextOrgID++
+	stored := *org
+	fs.orgs[org.ID] = &stored
+	fs.members[org.ID] = map[string]models.Role{ownerID: models.RoleOwner}
+	return org.ID, nil
+}
+
+// GetMemberships returns all organizations user is member of.
+func (fs *FakeStorage) GetMemberships(ctx context.Context, userID string) ([]models.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	members := make([]models.Member, 0)
+	for orgID, orgMembers := range fs.members {
+		if role, ok := orgMembers[userID]; ok {
+			members = append(members, fs.member(orgID, userID, role))
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].OrgID < members[j].OrgID })
+	return members, nil
+}
+
+// GetMember returns user's membership in organization.
+func (fs *FakeStorage) GetMember(ctx context.Context, orgID int64, userID string) (models.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Member{}, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	role, ok := fs.members[orgID][userID]
+	if !ok {
+		return models.Member{}, ErrMemberNotFound
+	}
+	return fs.member(orgID, userID, role), nil
+}
+
+// GetMembers returns all members of organization with their public keys.
+func (fs *FakeStorage) GetMembers(ctx context.Context, orgID int64) ([]models.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	members := make([]models.Member, 0)
+	for userID, role := range fs.members[orgID] {
+		members = append(members, fs.member(orgID, userID, role))
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Username < members[j].Username })
+	return members, nil
+}
+
+// member builds membership model, fs.mu must be held.
+func (fs *FakeStorage) member(orgID int64, userID string, role models.Role) models.Member {
+	member := models.Member{
+		OrgID:  orgID,
+		UserID: userID,
+		Role:   role,
+	}
+	if org, ok := fs.orgs[orgID]; ok {
+		member.OrgName = org.Name
+	}
+	if user, ok := fs.usersByID[userID]; ok {
+		member.Username = user.Username
+	}
+	if keys, ok := fs.keys[userID]; ok {
+		member.PublicKey = keys.PublicKey
+	}
+	return member
+}
+
+// SetMemberRole changes member's role in organization.
+func (fs *FakeStorage) SetMemberRole(ctx context.Context, orgID int64, userID string, role models.Role) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := checkOwnerLeft(fs.members[orgID], userID, role); err != nil {
+		return err
+	}
+	fs.members[orgID][userID] = role
+	return nil
+}
+
+// RemoveMember removes member from organization together with his collection keys.
+// The last owner of organization can't be removed.
+func (fs *FakeStorage) RemoveMember(ctx context.Context, orgID int64, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if err := checkOwnerLeft(fs.members[orgID], userID, ""); err != nil {
+		return err
+	}
+	delete(fs.members[orgID], userID)
+	for id, collection := range fs.collections {
+		if collection.OrgID == orgID {
+			delete(fs.collectionKeys[id], userID)
+		}
+	}
+	return nil
+}
+
+// AddInvitation saves invitation of user to organization with collection keys wrapped for him.
+func (fs *FakeStorage) AddInvitation(ctx context.Context, inv *models.Invitation, keys []models.CollectionKey) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, existing := range fs.invitations {
+		if existing.OrgID == inv.OrgID && existing.UserID == inv.UserID {
+			return 0, ErrAlreadyExists
+		}
+	}
+
+	inv.ID = fs.nextOrgID
+	fs.nextOrgID++
+	stored := *inv
+	if user, ok := fs.usersByID[inv.InvitedBy]; ok {
+		stored.InvitedBy = user.Username
+	}
+	if org, ok := fs.orgs[inv.OrgID]; ok {
+		stored.OrgName = org.Name
+	}
+	fs.invitations[inv.ID] = &stored
+	fs.saveCollectionKeys(keys)
+	return inv.ID, nil
+}
+
+// GetInvitations returns pending invitations of user.
+func (fs *FakeStorage) GetInvitations(ctx context.Context, userID string) ([]models.Invitation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	invitations := make([]models.Invitation, 0)
+	for _, inv := range fs.invitations {
+		if inv.UserID == userID {
+			invitations = append(invitations, *inv)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].ID < invitations[j].ID })
+	return invitations, nil
+}
+
+// AcceptInvitation makes invited user a member of organization and removes the invitation.
+func (fs *FakeStorage) AcceptInvitation(ctx context.Context, invitationID int64, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	inv, ok := fs.invitations[invitationID]
+	if !ok || inv.UserID != userID {
+		return ErrInvitationNotFound
+	}
+	if _, ok := fs.members[inv.OrgID][userID]; ok {
+		return ErrAlreadyExists
+	}
+	delete(fs.invitations, invitationID)
+	fs.members[inv.OrgID][userID] = inv.Role
+	return nil
+}
+
+// CreateCollection saves a new collection of organization with its key wrapped for members.
+func (fs *FakeStorage) CreateCollection(ctx context.Context, collection *models.Collection, keys []models.CollectionKey) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	collection.ID = fs.nextOrgID
+	fs.nextOrgID++
+	stored := *collection
+	fs.collections[collection.ID] = &stored
+
+	for i := range keys {
+		keys[i].CollectionID = collection.ID
+	}
+	fs.saveCollectionKeys(keys)
+	return collection.ID, nil
+}
+
+// saveCollectionKeys saves wrapped collection keys replacing existing ones, fs.mu must be held.
+func (fs *FakeStorage) saveCollectionKeys(keys []models.CollectionKey) {
+	for _, key := range keys {
+		if fs.collectionKeys[key.CollectionID] == nil {
+			fs.collectionKeys[key.CollectionID] = make(map[string]string)
+		}
+		fs.collectionKeys[key.CollectionID][key.UserID] = key.WrappedKey
+	}
+}
+
+// GetCollection returns collection by its ID.
+func (fs *FakeStorage) GetCollection(ctx context.Context, collectionID int64) (models.Collection, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Collection{}, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	collection, ok := fs.collections[collectionID]
+	if !ok {
+		return models.Collection{}, ErrCollectionNotFound
+	}
+	return *collection, nil
+}
+
+// GetCollectionKeys returns collection keys wrapped for user.
+// Keys of all organizations are returned if orgID is 0.
+func (fs *FakeStorage) GetCollectionKeys(ctx context.Context, userID string, orgID int64) ([]models.CollectionKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	keys := make([]models.CollectionKey, 0)
+	for id, collectionKeys := range fs.collectionKeys {
+		wrapped, ok := collectionKeys[userID]
+		collection := fs.collections[id]
+		if !ok || collection == nil || (orgID != 0 && collection.OrgID != orgID) {
+			continue
+		}
+		keys = append(keys, models.CollectionKey{
+			CollectionID:   id,
+			CollectionName: collection.Name,
+			OrgID:          collection.OrgID,
+			UserID:         userID,
+			WrappedKey:     wrapped,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CollectionID < keys[j].CollectionID })
+	return keys, nil
+}
+
+// GetCollectionSecrets returns all secrets of collection.
+func (fs *FakeStorage) GetCollectionSecrets(ctx context.Context, collectionID int64) ([]models.Secret, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	secrets := make([]models.Secret, 0)
+	for _, userSecrets := range fs.secrets {
+		for _, secret := range userSecrets {
+			if secret.CollectionID == collectionID && !secret.Expired(time.Now()) {
+				secrets = append(secrets, *secret)
+			}
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].ID < secrets[j].ID })
+	return secrets, nil
+}
@@ -1,38 +1,74 @@
 package storage
 
 import (
-	"errors"
+	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/google/uuid"
 )
 
 // FakeStorage implements Storage interface for tests using in-memory cards.
 type FakeStorage struct {
-	mu           sync.Mutex
-	usersByName  map[string]*models.User             // username key
-	usersByID    map[string]*models.User             // ID key
-	secrets      map[string]map[int64]*models.Secret // UserID key, map ID -> Secret values
-	nextSecretID int64
+	mu          sync.Mutex
+	usersByName map[string]*models.User              // username key
+	usersByID   map[string]*models.User              // ID key
+	secrets     map[string]map[string]*models.Secret // UserID key, map ID -> Secret values
+	keys        map[string]*models.UserKeys          // UserID key
+	shares      map[int64]*models.Share              // Share ID key
+	nextShareID int64
+
+	orgs           map[int64]*models.Organization   // Organization ID key
+	members        map[int64]map[string]models.Role // Organization ID key, map UserID -> Role values
+	invitations    map[int64]*models.Invitation     // Invitation ID key
+	collections    map[int64]*models.Collection     // Collection ID key
+	collectionKeys map[int64]map[string]string      // Collection ID key, map UserID -> wrapped key values
+	nextOrgID      int64
+
+	sends map[string]*models.Send // Send ID key
+
+	idempotencyKeys map[idempotencyKeyID]*models.IdempotencyKey // UserID and key
+
+	auditEvents      []models.AuditEvent      // Append-only audit log
+	auditCheckpoints []models.AuditCheckpoint // Signed audit log checkpoints
 }
 
 // NewFakeStorage creates a new instance of FakeStorage.
 func NewFakeStorage() *FakeStorage {
 	return &FakeStorage{
-		usersByName:  make(map[string]*models.User),
-		usersByID:    make(map[string]*models.User),
-		secrets:      make(map[string]map[int64]*models.Secret),
-		nextSecretID: 1,
+		usersByName: make(map[string]*models.User),
+		usersByID:   make(map[string]*models.User),
+		secrets:     make(map[string]map[string]*models.Secret),
+		keys:        make(map[string]*models.UserKeys),
+		shares:      make(map[int64]*models.Share),
+		nextShareID: 1,
+
+		orgs:           make(map[int64]*models.Organization),
+		members:        make(map[int64]map[string]models.Role),
+		invitations:    make(map[int64]*models.Invitation),
+		collections:    make(map[int64]*models.Collection),
+		collectionKeys: make(map[int64]map[string]string),
+		nextOrgID:      1,
+
+		sends: make(map[string]*models.Send),
+
+		idempotencyKeys: make(map[idempotencyKeyID]*models.IdempotencyKey),
 	}
 }
 
 // RegisterUser adds a new user to the storage.
-func (fs *FakeStorage) RegisterUser(user *models.User) error {
+func (fs *FakeStorage) RegisterUser(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
 	if _, exists := fs.usersByName[user.Username]; exists {
-		return errors.New("user already exists")
+		return ErrAlreadyExists
 	}
 	fs.usersByName[user.Username] = user
 	fs.usersByID[user.ID] = user
@@ -40,7 +76,11 @@ func (fs *FakeStorage) RegisterUser(user *models.User) error {
 }
 
 // GetUser retrieves users data by his username.
-func (fs *FakeStorage) GetUser(username string) (models.User, error) {
+func (fs *FakeStorage) GetUser(ctx context.Context, username string) (models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return models.User{}, err
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
@@ -51,43 +91,205 @@ func (fs *FakeStorage) GetUser(username string) (models.User, error) {
 	return *user, nil
 }
 
+// GetUserByID retrieves users data by his ID.
+func (fs *FakeStorage) GetUserByID(ctx context.Context, userID string) (models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return models.User{}, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	user, exists := fs.usersByID[userID]
+	if !exists {
+		return models.User{}, ErrNotFound
+	}
+	return *user, nil
+}
+
+// DeleteUser removes the user and all his secrets from the storage.
+func (fs *FakeStorage) DeleteUser(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	user, exists := fs.usersByID[userID]
+	if !exists {
+		return ErrNotFound
+	}
+
+	// Organizations of the user alone are removed, the others must keep an owner.
+	for _, members := range fs.members {
+		if _, ok := members[userID]; !ok || len(members) == 1 {
+			continue
+		}
+		if err := checkOwnerLeft(members, userID, ""); err != nil {
+			return err
+		}
+	}
+	for orgID, members := range fs.members {
+		if _, ok := members[userID]; ok && len(members) == 1 {
+			fs.deleteOrganization(orgID)
+		}
+	}
+
+	// Collection secrets created by the user pass to the first other owner of the organization.
+	for id, secret := range fs.secrets[userID] {
+		if secret.CollectionID == 0 {
+			continue
+		}
+		owner := fs.collectionOwner(secret.CollectionID, userID)
+		if fs.secrets[owner] == nil {
+			fs.secrets[owner] = make(map[string]*models.Secret)
+		}
+		secret.UserID = owner
+		fs.secrets[owner][id] = secret
+	}
+
+	delete(fs.usersByName, user.Username)
+	delete(fs.usersByID, userID)
+	delete(fs.secrets, userID)
+	delete(fs.keys, userID)
+	for id, share := range fs.shares {
+		if share.OwnerID == userID || share.RecipientID == userID {
+			delete(fs.shares, id)
+		}
+	}
+	for id, send := range fs.sends {
+		if send.OwnerID == userID {
+			delete(fs.sends, id)
+		}
+	}
+	for id := range fs.idempotencyKeys {
+		if id.userID == userID {
+			delete(fs.idempotencyKeys, id)
+		}
+	}
+	for _, members := range fs.members {
+		delete(members, userID)
+	}
+	for _, keys := range fs.collectionKeys {
+		delete(keys, userID)
+	}
+	for id, inv := range fs.invitations {
+		if inv.UserID == userID || inv.InvitedBy == userID {
+			delete(fs.invitations, id)
+		}
+	}
+	return nil
+}
+
+// deleteOrganization removes organization with its members, invitations, collections and their secrets.
+func (fs *FakeStorage) deleteOrganization(orgID int64) {
+	delete(fs.orgs, orgID)
+	delete(fs.members, orgID)
+	for id, inv := range fs.invitations {
+		if inv.OrgID == orgID {
+			delete(fs.invitations, id)
+		}
+	}
+	for id, collection := range fs.collections {
+		if collection.OrgID != orgID {
+			continue
+		}
+		delete(fs.collections, id)
+		delete(fs.collectionKeys, id)
+		for _, userSecrets := range fs.secrets {
+			for secretID, secret := range userSecrets {
+				if secret.CollectionID == id {
+					delete(userSecrets, secretID)
+				}
+			}
+		}
+	}
+}
+
+// collectionOwner returns the first owner of organization owning the collection other than userID.
+func (fs *FakeStorage) collectionOwner(collectionID int64, userID string) string {
+	var owners []string
+	for id, role := range fs.members[fs.collections[collectionID].OrgID] {
+		if id != userID && role == models.RoleOwner {
+			owners = append(owners, id)
+		}
+	}
+	sort.Strings(owners)
+
+	return owners[0]
+}
+
 // AddSecret saves users credentials to the database.
-func (fs *FakeStorage) AddSecret(secret *models.Secret) (int64, error) {
+func (fs *FakeStorage) AddSecret(ctx context.Context, secret *models.Secret, quota models.Quota) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
 	if _, exists := fs.usersByID[secret.UserID]; !exists {
-		return 0, errors.New("user not found")
+		return "", ErrNotFound
+	}
+	if err := checkQuota(quota, fs.usage(secret.UserID), 1, secret.Size(), secret.Size()); err != nil {
+		return "", err
 	}
 
-	secret.ID = fs.nextSecretID
-	fs.nextSecretID++
+	if secret.ID == "" {
+		secret.ID = uuid.NewString()
+	}
+	if fs.secretExists(secret.ID) {
+		return "", ErrSecretExists
+	}
+	secret.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
 
 	if fs.secrets[secret.UserID] == nil {
-		fs.secrets[secret.UserID] = make(map[int64]*models.Secret)
+		fs.secrets[secret.UserID] = make(map[string]*models.Secret)
 	}
-	fs.secrets[secret.UserID][secret.ID] = secret
+	stored := *secret
+	fs.secrets[secret.UserID][secret.ID] = &stored
 	return secret.ID, nil
 }
 
-// EditSecret updates users credentials in the storage.
-func (fs *FakeStorage) EditSecret(secret *models.Secret) error {
+// EditSecret updates users credentials in the storage replacing the copies shared with other users.
+func (fs *FakeStorage) EditSecret(ctx context.Context, secret *models.Secret, shares []models.Share, quota models.Quota) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
 	userSecrets, exists := fs.secrets[secret.UserID]
 	if !exists {
-		return errors.New("no secrets for user")
+		return ErrSecretNotFound
+	}
+	old, exists := userSecrets[secret.ID]
+	if !exists {
+		return ErrSecretNotFound
 	}
-	if _, exists := userSecrets[secret.ID]; !exists {
-		return errors.New("secret not found")
+	delta := secret.Size() - old.Size()
+	if err := checkQuota(quota, fs.usage(secret.UserID), 0, delta, secret.Size()); err != nil {
+		return err
 	}
-	userSecrets[secret.ID] = secret
+	if err := checkShareCopies(fs.shareRecipients(secret.ID), shares); err != nil {
+		return err
+	}
+	secret.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	stored := *secret
+	userSecrets[secret.ID] = &stored
+	fs.replaceShareCopies(secret.ID, shares)
 	return nil
 }
 
 // GetSecret retrives and returns all users credentials.
-func (fs *FakeStorage) GetSecrets(userID string) ([]models.Secret, error) {
+// Secrets of organization collections are not included.
+func (fs *FakeStorage) GetSecrets(ctx context.Context, userID string) ([]models.Secret, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
@@ -97,20 +299,253 @@ func (fs *FakeStorage) GetSecrets(userID string) ([]models.Secret, error) {
 	}
 	var secrets []models.Secret
 	for _, secret := range userSecrets {
+		if secret.CollectionID != 0 || secret.Expired(time.Now()) {
+			continue
+		}
 		secrets = append(secrets, *secret)
 	}
 	return secrets, nil
 }
 
+// DeleteExpiredSecrets removes secrets expired at the moment now.
+func (fs *FakeStorage) DeleteExpiredSecrets(ctx context.Context, now time.Time) ([]models.Secret, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	deleted := make([]models.Secret, 0)
+	for _, userSecrets := range fs.secrets {
+		for id, secret := range userSecrets {
+			if secret.Expired(now) {
+				deleted = append(deleted, models.Secret{
+					ID:           secret.ID,
+					UserID:       secret.UserID,
+					CollectionID: secret.CollectionID,
+					ExpiresAt:    secret.ExpiresAt,
+				})
+				delete(userSecrets, id)
+			}
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].ID < deleted[j].ID })
+	return deleted, nil
+}
+
 // GetSecretByID returns secret by its id.
-func (fs *FakeStorage) GetSecretByID(secretID int64) (*models.Secret, error) {
+func (fs *FakeStorage) GetSecretByID(ctx context.Context, secretID string) (*models.Secret, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
 	for _, userSecrets := range fs.secrets {
 		if secret, ok := userSecrets[secretID]; ok {
-			return secret, nil
+			// Return a copy so that changes are saved by EditSecret only.
+			s := *secret
+			return &s, nil
+		}
+	}
+	return nil, ErrSecretNotFound
+}
+
+// GetSecretByLegacyID returns secret by the integer id it had before secret ids became UUIDs.
+func (fs *FakeStorage) GetSecretByLegacyID(ctx context.Context, legacyID int64) (*models.Secret, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, userSecrets := range fs.secrets {
+		for _, secret := range userSecrets {
+			if legacyID != 0 && secret.LegacyID == legacyID {
+				s := *secret
+				return &s, nil
+			}
+		}
+	}
+	return nil, ErrSecretNotFound
+}
+
+// secretExists reports whether a secret of any user has the id. The caller must hold fs.mu.
+func (fs *FakeStorage) secretExists(secretID string) bool {
+	for _, userSecrets := range fs.secrets {
+		if _, ok := userSecrets[secretID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SetUserKeys saves users sharing keypair.
+func (fs *FakeStorage) SetUserKeys(ctx context.Context, keys *models.UserKeys) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, exists := fs.usersByID[keys.UserID]; !exists {
+		return ErrNotFound
+	}
+	k := *keys
+	fs.keys[keys.UserID] = &k
+	return nil
+}
+
+// GetUserKeys returns users sharing keypair.
+func (fs *FakeStorage) GetUserKeys(ctx context.Context, userID string) (*models.UserKeys, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	keys, exists := fs.keys[userID]
+	if !exists {
+		return nil, ErrKeyNotFound
+	}
+	k := *keys
+	return &k, nil
+}
+
+// AddShare saves a share of the secret, replacing the previous share with the same recipient.
+func (fs *FakeStorage) AddShare(ctx context.Context, share *models.Share) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for id, s := range fs.shares {
+		if s.SecretID == share.SecretID && s.RecipientID == share.RecipientID {
+			share.ID = id
+			stored := *share
+			fs.shares[id] = &stored
+			return id, nil
+		}
+	}
+
+	share.ID = fs.nextShareID
+	fs.nextShareID++
+	stored := *share
+	fs.shares[share.ID] = &stored
+	return share.ID, nil
+}
+
+// GetSharesForRecipient returns all secrets shared with user.
+func (fs *FakeStorage) GetSharesForRecipient(ctx context.Context, userID string) ([]models.Share, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	shares := make([]models.Share, 0)
+	for _, share := range fs.shares {
+		if share.RecipientID != userID {
+			continue
+		}
+		s := *share
+		if owner, ok := fs.usersByID[s.OwnerID]; ok {
+			s.OwnerName = owner.Username
+		}
+		shares = append(shares, s)
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ID < shares[j].ID })
+	return shares, nil
+}
+
+// GetSharesForOwner returns shares of user's secrets without the shared data.
+func (fs *FakeStorage) GetSharesForOwner(ctx context.Context, userID string) ([]models.Share, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	shares := make([]models.Share, 0)
+	for _, share := range fs.shares {
+		if share.OwnerID != userID {
+			continue
+		}
+		s := models.Share{ID: share.ID, SecretID: share.SecretID, OwnerID: share.OwnerID, RecipientID: share.RecipientID}
+		if recipient, ok := fs.usersByID[s.RecipientID]; ok {
+			s.RecipientName = recipient.Username
+		}
+		shares = append(shares, s)
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].ID < shares[j].ID })
+	return shares, nil
+}
+
+// shareRecipients returns users the secret is shared with.
+func (fs *FakeStorage) shareRecipients(secretID string) []string {
+	var recipients []string
+	for _, share := range fs.shares {
+		if share.SecretID == secretID {
+			recipients = append(recipients, share.RecipientID)
+		}
+	}
+	return recipients
+}
+
+// replaceShareCopies replaces the copies of the secret shared with its recipients by shares.
+func (fs *FakeStorage) replaceShareCopies(secretID string, shares []models.Share) {
+	for _, share := range fs.shares {
+		if share.SecretID != secretID {
+			continue
+		}
+		for _, c := range shares {
+			if c.RecipientID == share.RecipientID {
+				share.WrappedKey, share.Data, share.Meta = c.WrappedKey, c.Data, c.Meta
+			}
+		}
+	}
+}
+
+// DeleteShare removes a share of the secret.
+func (fs *FakeStorage) DeleteShare(ctx context.Context, secretID, recipientID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for id, share := range fs.shares {
+		if share.SecretID == secretID && share.RecipientID == recipientID {
+			delete(fs.shares, id)
+			return nil
+		}
+	}
+	return ErrShareNotFound
+}
+
+// IsSecretSharedWith reports whether the secret is shared with user.
+func (fs *FakeStorage) IsSecretSharedWith(ctx context.Context, secretID, userID string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for _, share := range fs.shares {
+		if share.SecretID == secretID && share.RecipientID == userID {
+			return true, nil
 		}
 	}
-	return nil, errors.New("secret not found")
+	return false, nil
 }
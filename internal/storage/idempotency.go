@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ReserveIdempotencyKey saves the key of a request in progress unless a live key with the same name exists.
+// Keys expired at the moment now are replaced. Returns the existing key and false if the key is live.
+func (store *DBStore) ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey, now time.Time) (models.IdempotencyKey, bool, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO idempotency_keys (user_id, key, method, request_hash, expires_at) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (user_id, key) DO UPDATE SET method = EXCLUDED.method, request_hash = EXCLUDED.request_hash,
+		response = NULL, expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= $6`
+	tag, err := store.db.Exec(ctx, query, key.UserID, key.Key, key.Method, key.RequestHash, key.ExpiresAt, now)
+	if err != nil {
+		return models.IdempotencyKey{}, false, err
+	}
+	if tag.RowsAffected() > 0 {
+		return *key, true, nil
+	}
+
+	existing := models.IdempotencyKey{UserID: key.UserID, Key: key.Key}
+	query = `SELECT method, request_hash, response, expires_at FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+	err = store.db.QueryRow(ctx, query, key.UserID, key.Key).Scan(&existing.Method, &existing.RequestHash,
+		&existing.Response, &existing.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The request holding the key failed and released it after the insert, report it as in progress.
+		return models.IdempotencyKey{UserID: key.UserID, Key: key.Key, Method: key.Method,
+			RequestHash: key.RequestHash}, false, nil
+	}
+	if err != nil {
+		return models.IdempotencyKey{}, false, err
+	}
+
+	return existing, false, nil
+}
+
+// CompleteIdempotencyKey saves the response of the request holding the key and extends the key until expiresAt.
+func (store *DBStore) CompleteIdempotencyKey(ctx context.Context, userID, key string, response []byte, expiresAt time.Time) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `UPDATE idempotency_keys SET response = $3, expires_at = $4 WHERE user_id = $1 AND key = $2`
+	_, err := store.db.Exec(ctx, query, userID, key, response, expiresAt)
+
+	return err
+}
+
+// ReleaseIdempotencyKey deletes the key of a failed request so that it can be retried.
+// Keys with saved responses are kept.
+func (store *DBStore) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND response IS NULL`
+	_, err := store.db.Exec(ctx, query, userID, key)
+
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys removes keys expired at the moment now from the database.
+func (store *DBStore) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tag, err := store.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// idempotencyKeyID identifies an idempotency key of FakeStorage.
+type idempotencyKeyID struct {
+	userID string
+	key    string
+}
+
+// ReserveIdempotencyKey saves the key of a request in progress unless a live key with the same name exists.
+func (fs *FakeStorage) ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey, now time.Time) (models.IdempotencyKey, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return models.IdempotencyKey{}, false, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	id := idempotencyKeyID{userID: key.UserID, key: key.Key}
+	if existing, exists := fs.idempotencyKeys[id]; exists && now.Before(existing.ExpiresAt) {
+		return *existing, false, nil
+	}
+	stored := *key
+	stored.Response = nil
+	fs.idempotencyKeys[id] = &stored
+	return stored, true, nil
+}
+
+// CompleteIdempotencyKey saves the response of the request holding the key and extends the key until expiresAt.
+func (fs *FakeStorage) CompleteIdempotencyKey(ctx context.Context, userID, key string, response []byte, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if stored, exists := fs.idempotencyKeys[idempotencyKeyID{userID: userID, key: key}]; exists {
+		stored.Response = response
+		stored.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes the key of a failed request so that it can be retried.
+func (fs *FakeStorage) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	id := idempotencyKeyID{userID: userID, key: key}
+	if stored, exists := fs.idempotencyKeys[id]; exists && stored.Response == nil {
+		delete(fs.idempotencyKeys, id)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes keys expired at the moment now.
+func (fs *FakeStorage) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var n int64
+	for id, key := range fs.idempotencyKeys {
+		if !now.Before(key.ExpiresAt) {
+			delete(fs.idempotencyKeys, id)
+			n++
+		}
+	}
+	return n, nil
+}
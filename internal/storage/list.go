@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ListSecrets returns a page of user's personal secrets selected by filter.
+// Pages use keyset pagination on (updated_at, id) or id so that they stay stable under inserts.
+// The total number of secrets matching the filter ignores the page position.
+func (store *DBStore) ListSecrets(ctx context.Context, userID string, filter models.SecretFilter) ([]models.Secret, int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	conditions, args := secretConditions(userID, filter)
+
+	var total int64
+	query := `SELECT count(*) FROM secrets WHERE ` + strings.Join(conditions, " AND ")
+	if err := store.db.QueryRow(ctx, query, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	order := "id"
+	if filter.OrderBy == models.SecretOrderUpdated {
+		order = "updated_at, id"
+		if filter.AfterID != "" {
+			args = append(args, filter.AfterUpdatedAt, filter.AfterID)
+			conditions = append(conditions, fmt.Sprintf("(updated_at, id) > ($%d, $%d)", len(args)-1, len(args)))
+		}
+	} else if filter.AfterID != "" {
+		args = append(args, filter.AfterID)
+		conditions = append(conditions, fmt.Sprintf("id > $%d", len(args)))
+	}
+	args = append(args, filter.Limit)
+
+	query = fmt.Sprintf(`SELECT %s FROM secrets WHERE %s ORDER BY %s LIMIT $%d`,
+		listedSecretColumns, strings.Join(conditions, " AND "), order, len(args))
+	rows, err := store.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	secrets := make([]models.Secret, 0, filter.Limit)
+	for rows.Next() {
+		secret, err := scanListedSecret(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, total, rows.Err()
+}
+
+// secretConditions builds WHERE conditions and their arguments selecting user's personal secrets by filter.
+// Page position and limit of the filter are not included.
+func secretConditions(userID string, filter models.SecretFilter) ([]string, []interface{}) {
+	conditions := []string{"user_id = $1", "collection_id IS NULL", "(expires_at IS NULL OR expires_at > now())"}
+	args := []interface{}{userID}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		args = append(args, filter.UpdatedAfter)
+		conditions = append(conditions, fmt.Sprintf("updated_at > $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+// listedSecretColumns are the columns scanned by scanListedSecret.
+const listedSecretColumns = `id, user_id, data, meta, expires_at, type, updated_at`
+
+// scanListedSecret scans a row of listedSecretColumns.
+func scanListedSecret(row pgx.Row) (models.Secret, error) {
+	var secret models.Secret
+	var expiresAt *time.Time
+	err := row.Scan(&secret.ID, &secret.UserID, &secret.Data, &secret.Meta, &expiresAt, &secret.Type, &secret.UpdatedAt)
+	if err != nil {
+		return models.Secret{}, err
+	}
+	secret.ExpiresAt = timeOrZero(expiresAt)
+
+	return secret, nil
+}
+
+// ListSecrets returns a page of user's personal secrets selected by filter.
+func (fs *FakeStorage) ListSecrets(ctx context.Context, userID string, filter models.SecretFilter) ([]models.Secret, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	matching := make([]models.Secret, 0)
+	for _, secret := range fs.secrets[userID] {
+		if matchesFilter(secret, filter) {
+			matching = append(matching, *secret)
+		}
+	}
+
+	byUpdated := filter.OrderBy == models.SecretOrderUpdated
+	less := func(a, b models.Secret) bool {
+		if byUpdated && !a.UpdatedAt.Equal(b.UpdatedAt) {
+			return a.UpdatedAt.Before(b.UpdatedAt)
+		}
+		return a.ID < b.ID
+	}
+	sort.Slice(matching, func(i, j int) bool { return less(matching[i], matching[j]) })
+
+	after := models.Secret{ID: filter.AfterID, UpdatedAt: filter.AfterUpdatedAt}
+	page := make([]models.Secret, 0, filter.Limit)
+	for _, secret := range matching {
+		if len(page) == filter.Limit {
+			break
+		}
+		if filter.AfterID != "" && !less(after, secret) {
+			continue
+		}
+		page = append(page, secret)
+	}
+
+	return page, int64(len(matching)), nil
+}
+
+// matchesFilter reports whether the secret is a personal not expired secret selected by filter.
+func matchesFilter(secret *models.Secret, filter models.SecretFilter) bool {
+	if secret.CollectionID != 0 || secret.Expired(time.Now()) {
+		return false
+	}
+	if filter.Type != "" && secret.Type != filter.Type {
+		return false
+	}
+	return filter.UpdatedAfter.IsZero() || secret.UpdatedAt.After(filter.UpdatedAfter)
+}
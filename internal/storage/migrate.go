@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles contains SQL migrations of every backend in migrations/<backend> directories.
+// A migration is a pair of files NNNN_name.up.sql and NNNN_name.down.sql, where NNNN is its version.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrNoMigrations is returned when rolling back a database without applied migrations.
+var ErrNoMigrations = errors.New("no applied migrations")
+
+// Migration is a versioned schema change.
+type Migration struct {
+	Version int64  // Version, migrations are applied in ascending order
+	Name    string // Name from the file name
+	Up      string // SQL applying the change
+	Down    string // SQL reverting the change
+}
+
+// MigrationStatus is a migration with the time it was applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time // Zero if the migration is pending
+}
+
+// Migrator manages versioned schema migrations of a storage.
+// Migrations are serialized with a database lock so that concurrent server replicas don't race.
+type Migrator interface {
+	// Apply all pending migrations. Returns applied migrations.
+	MigrateUp(ctx context.Context) ([]Migration, error)
+	// Revert the last applied migration. Returns reverted migration.
+	MigrateDown(ctx context.Context) (Migration, error)
+	// Returns all known migrations with their state without changing the database.
+	// Migrations of a database without the table of applied migrations are all pending.
+	MigrationStatus(ctx context.Context) ([]MigrationStatus, error)
+}
+
+// migrationSession runs migrations while holding the migration lock.
+type migrationSession interface {
+	// Returns application times of applied migrations by version.
+	applied(ctx context.Context) (map[int64]time.Time, error)
+	// Run the up or down SQL of migration and record it in schema_migrations in one transaction.
+	apply(ctx context.Context, m Migration, up bool) error
+}
+
+// loadMigrations reads migrations of the backend sorted by version.
+func loadMigrations(backend string) ([]Migration, error) {
+	dir := path.Join("migrations", backend)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, up := strings.CutSuffix(name, ".up.sql")
+		if !up {
+			var down bool
+			if base, down = strings.CutSuffix(name, ".down.sql"); !down {
+				return nil, fmt.Errorf("unexpected migration file %s", name)
+			}
+		}
+		versionStr, title, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %s must be named NNNN_name.up.sql or NNNN_name.down.sql", name)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if m.Name != title {
+			return nil, fmt.Errorf("migration %d has different names %q and %q", version, m.Name, title)
+		}
+		if up {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// migrateUp applies pending migrations in ascending order.
+func migrateUp(ctx context.Context, session migrationSession, migrations []Migration) ([]Migration, error) {
+	applied, err := session.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := session.apply(ctx, m, true); err != nil {
+			return done, fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// migrateDown reverts the applied migration with the highest version.
+func migrateDown(ctx context.Context, session migrationSession, migrations []Migration) (Migration, error) {
+	applied, err := session.applied(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if err := session.apply(ctx, m, false); err != nil {
+			return Migration{}, fmt.Errorf("rollback of migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+		return m, nil
+	}
+
+	return Migration{}, ErrNoMigrations
+}
+
+// migrationStatus returns migrations with their application times.
+func migrationStatus(ctx context.Context, session migrationSession, migrations []Migration) ([]MigrationStatus, error) {
+	applied, err := session.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status = append(status, MigrationStatus{Migration: m, AppliedAt: applied[m.Version]})
+	}
+
+	return status, nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID is the advisory lock key serializing schema migrations of server replicas.
+const migrationLockID = 0x6d696772 // "migr"
+
+// pgSchemaMigrations creates the table of applied migrations.
+const pgSchemaMigrations = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`
+
+// pgMigrationSession runs migrations on a connection holding the migration advisory lock.
+type pgMigrationSession struct {
+	conn *pgxpool.Conn
+}
+
+// MigrateUp applies all pending migrations.
+func (store *DBStore) MigrateUp(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := store.withMigrationLock(ctx, func(session migrationSession, migrations []Migration) (err error) {
+		done, err = migrateUp(ctx, session, migrations)
+		return err
+	})
+
+	return done, err
+}
+
+// MigrateDown reverts the last applied migration.
+func (store *DBStore) MigrateDown(ctx context.Context) (Migration, error) {
+	var reverted Migration
+	err := store.withMigrationLock(ctx, func(session migrationSession, migrations []Migration) (err error) {
+		reverted, err = migrateDown(ctx, session, migrations)
+		return err
+	})
+
+	return reverted, err
+}
+
+// MigrationStatus returns all migrations with their state.
+// It only reads schema_migrations without taking the migration lock, so it doesn't wait for running migrations.
+func (store *DBStore) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations("postgres")
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := store.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	return migrationStatus(ctx, &pgMigrationSession{conn: conn}, migrations)
+}
+
+// withMigrationLock calls fn holding a session-level advisory lock on a dedicated connection.
+// The lock is released when the connection is returned to the pool even if fn panics.
+func (store *DBStore) withMigrationLock(ctx context.Context, fn func(migrationSession, []Migration) error) error {
+	migrations, err := loadMigrations("postgres")
+	if err != nil {
+		return err
+	}
+
+	conn, err := store.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err := conn.Exec(ctx, pgSchemaMigrations); err != nil {
+		return err
+	}
+
+	return fn(&pgMigrationSession{conn: conn}, migrations)
+}
+
+// applied returns application times of applied migrations by version.
+// A database without schema_migrations has no applied migrations.
+func (s *pgMigrationSession) applied(ctx context.Context) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+
+	var exists bool
+	if err := s.conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := s.conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt.UTC()
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs the migration and records it in one transaction, PostgreSQL DDL is transactional.
+func (s *pgMigrationSession) apply(ctx context.Context, m Migration, up bool) error {
+	tx, err := s.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if up {
+		if _, err := tx.Exec(ctx, m.Up); err != nil {
+			return err
+		}
+		query := `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`
+		if _, err := tx.Exec(ctx, query, m.Version, m.Name, time.Now()); err != nil {
+			return err
+		}
+	} else {
+		if _, err := tx.Exec(ctx, m.Down); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// sqliteSchemaMigrations creates the table of applied migrations.
+const sqliteSchemaMigrations = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)`
+
+// sqliteMigrationSession runs migrations inside a transaction holding the database write lock.
+// Migration status is read with a session on the database itself.
+type sqliteMigrationSession struct {
+	tx interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+		QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	}
+}
+
+// MigrateUp applies all pending migrations.
+func (store *SQLiteStore) MigrateUp(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := store.withMigrationLock(ctx, func(session migrationSession, migrations []Migration) (err error) {
+		done, err = migrateUp(ctx, session, migrations)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return done, nil
+}
+
+// MigrateDown reverts the last applied migration.
+func (store *SQLiteStore) MigrateDown(ctx context.Context) (Migration, error) {
+	var reverted Migration
+	err := store.withMigrationLock(ctx, func(session migrationSession, migrations []Migration) (err error) {
+		reverted, err = migrateDown(ctx, session, migrations)
+		return err
+	})
+
+	return reverted, err
+}
+
+// MigrationStatus returns all migrations with their state.
+// It only reads schema_migrations outside of a transaction, so it doesn't take the database write lock.
+func (store *SQLiteStore) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations("sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	return migrationStatus(ctx, &sqliteMigrationSession{tx: store.db}, migrations)
+}
+
+// withMigrationLock calls fn inside one transaction. SQLite has no advisory locks, but transactions
+// begin immediately with the database write lock, so other processes wait until migrations are done.
+// SQLite DDL is transactional: the whole run is committed only if fn succeeds.
+func (store *SQLiteStore) withMigrationLock(ctx context.Context, fn func(migrationSession, []Migration) error) error {
+	migrations, err := loadMigrations("sqlite")
+	if err != nil {
+		return err
+	}
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, sqliteSchemaMigrations); err != nil {
+		return err
+	}
+	if err := fn(&sqliteMigrationSession{tx: tx}, migrations); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applied returns application times of applied migrations by version.
+// A database without schema_migrations has no applied migrations.
+func (s *sqliteMigrationSession) applied(ctx context.Context) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')`
+	if err := s.tx.QueryRowContext(ctx, query).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := s.tx.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var appliedAt sql.NullInt64
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = fromMicros(appliedAt)
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs the migration and records it in the enclosing transaction.
+func (s *sqliteMigrationSession) apply(ctx context.Context, m Migration, up bool) error {
+	if up {
+		if _, err := s.tx.ExecContext(ctx, m.Up); err != nil {
+			return err
+		}
+		query := `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`
+		_, err := s.tx.ExecContext(ctx, query, m.Version, m.Name, toMicros(time.Now()))
+		return err
+	}
+
+	if _, err := s.tx.ExecContext(ctx, m.Down); err != nil {
+		return err
+	}
+	_, err := s.tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+	return err
+}
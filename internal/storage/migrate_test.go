@@ -0,0 +1,218 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test case: migrations of SQLite are applied, listed and reverted one by one.
+func TestSQLiteMigrations(t *testing.T) {
+	path := "sqlite://" + filepath.Join(t.TempDir(), "gophkeeper.db")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, closeStore, err := storage.Open(ctx, path, 0)
+	require.NoError(t, err)
+	defer closeStore()
+
+	status, err := store.MigrationStatus(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, status)
+	for _, m := range status {
+		assert.True(t, m.AppliedAt.IsZero(), "migration %d must be pending", m.Version)
+	}
+
+	applied, err := store.MigrateUp(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, len(status))
+
+	// Applying again is a no-op.
+	applied, err = store.MigrateUp(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	user := models.User{ID: "id", Username: "user", Password: "hash"}
+	require.NoError(t, store.RegisterUser(ctx, &user))
+
+	status, err = store.MigrationStatus(ctx)
+	require.NoError(t, err)
+	for _, m := range status {
+		assert.False(t, m.AppliedAt.IsZero(), "migration %d must be applied", m.Version)
+	}
+
+	// Revert all migrations starting from the last one.
+	for i := len(status) - 1; i >= 0; i-- {
+		reverted, err := store.MigrateDown(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, status[i].Version, reverted.Version)
+	}
+	_, err = store.MigrateDown(ctx)
+	require.ErrorIs(t, err, storage.ErrNoMigrations)
+
+	// Tables are dropped with the initial migration.
+	require.Error(t, store.RegisterUser(ctx, &user))
+
+	applied, err = store.MigrateUp(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(status))
+	require.NoError(t, store.RegisterUser(ctx, &user))
+}
+
+// Test case: migration status of a database never migrated doesn't create the table of applied migrations.
+func TestSQLiteMigrationStatusReadOnly(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "gophkeeper.db")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, closeStore, err := storage.Open(ctx, "sqlite://"+file, 0)
+	require.NoError(t, err)
+	defer closeStore()
+
+	status, err := store.MigrationStatus(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, status)
+	for _, m := range status {
+		assert.True(t, m.AppliedAt.IsZero(), "migration %d must be pending", m.Version)
+	}
+
+	db, err := sql.Open("sqlite", file)
+	require.NoError(t, err)
+	defer db.Close()
+	var tables int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'`).Scan(&tables))
+	assert.Zero(t, tables)
+}
+
+// Test case: integer ids of secrets created before UUIDs are kept as legacy ids.
+func TestSQLiteSecretUUIDMigration(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "gophkeeper.db")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store, closeStore, err := storage.Open(ctx, "sqlite://"+file, 0)
+	require.NoError(t, err)
+	defer closeStore()
+
+	_, err = store.MigrateUp(ctx)
+	require.NoError(t, err)
+	reverted, err := store.MigrateDown(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "secret_uuids", reverted.Name)
+
+	// Fill the schema with integer secret ids directly.
+	db, err := sql.Open("sqlite", file+"?_pragma=foreign_keys(1)")
+	require.NoError(t, err)
+	defer db.Close()
+	for _, query := range []string{
+		`INSERT INTO users (uuid, username, password) VALUES ('owner', 'owner', 'hash'), ('recipient', 'recipient', 'hash')`,
+		`INSERT INTO secrets (id, user_id, data, meta, updated_at) VALUES (7, 'owner', 'data', 'meta', 0)`,
+		`INSERT INTO secret_tokens (token, secret_id) VALUES ('token', 7)`,
+		`INSERT INTO shares (secret_id, owner_id, recipient_id, wrapped_key, data, meta)
+		VALUES (7, 'owner', 'recipient', 'key', 'd', 'm')`,
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	_, err = store.MigrateUp(ctx)
+	require.NoError(t, err)
+
+	secret, err := store.GetSecretByLegacyID(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), secret.LegacyID)
+	assert.Equal(t, "data", secret.Data)
+	_, err = uuid.Parse(secret.ID)
+	require.NoError(t, err)
+
+	found, err := store.SearchSecrets(ctx, "owner", []string{"token"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, secret.ID, found[0].ID)
+	shared, err := store.IsSecretSharedWith(ctx, secret.ID, "recipient")
+	require.NoError(t, err)
+	assert.True(t, shared)
+
+	// Reverting gives integer ids to the secrets created with UUIDs.
+	_, err = store.AddSecret(ctx, &models.Secret{UserID: "owner", Data: "new"}, models.Quota{})
+	require.NoError(t, err)
+	_, err = store.MigrateDown(ctx)
+	require.NoError(t, err)
+	var ids []int64
+	rows, err := db.QueryContext(ctx, `SELECT id FROM secrets ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []int64{7, 8}, ids)
+}
+
+// Test case: concurrent servers apply every migration once.
+func TestSQLiteConcurrentMigrations(t *testing.T) {
+	path := "sqlite://" + filepath.Join(t.TempDir(), "gophkeeper.db")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const replicas = 4
+	counts := make([]int, replicas)
+	errs := make([]error, replicas)
+	var wg sync.WaitGroup
+	for i := 0; i < replicas; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store, closeStore, err := storage.Open(ctx, path, 0)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer closeStore()
+			applied, err := store.MigrateUp(ctx)
+			counts[i], errs[i] = len(applied), err
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := 0; i < replicas; i++ {
+		require.NoError(t, errs[i])
+		total += counts[i]
+	}
+
+	store, closeStore, err := storage.Open(ctx, path, 0)
+	require.NoError(t, err)
+	defer closeStore()
+	status, err := store.MigrationStatus(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(status), total)
+}
+
+// Test case: PostgreSQL migrations are recorded in schema_migrations.
+func TestPostgresMigrationStatus(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+	store := openStorage(t, dsn)
+
+	status, err := store.MigrationStatus(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, status)
+	for _, m := range status {
+		assert.False(t, m.AppliedAt.IsZero(), "migration %d must be applied", m.Version)
+	}
+}
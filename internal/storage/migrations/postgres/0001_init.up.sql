@@ -0,0 +1,116 @@
+-- Initial schema. Statements are idempotent so that databases created
+-- before versioned migrations are adopted without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+    uuid UUID PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS secrets (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    data TEXT NOT NULL,
+    meta TEXT
+);
+
+CREATE TABLE IF NOT EXISTS user_keys (
+    user_id UUID PRIMARY KEY REFERENCES users(uuid) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    encrypted_private_key TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS shares (
+    id SERIAL PRIMARY KEY,
+    secret_id INTEGER NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+    owner_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    recipient_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    wrapped_key TEXT NOT NULL,
+    data TEXT NOT NULL,
+    meta TEXT,
+    UNIQUE (secret_id, recipient_id)
+);
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS org_invitations (
+    id SERIAL PRIMARY KEY,
+    org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    invited_by UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    UNIQUE (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS collections (
+    id SERIAL PRIMARY KEY,
+    org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS collection_keys (
+    collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    wrapped_key TEXT NOT NULL,
+    PRIMARY KEY (collection_id, user_id)
+);
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID,
+    type TEXT NOT NULL,
+    method TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    peer_ip TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    details TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_user_time ON audit_events (user_id, created_at);
+
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS prev_hash TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE audit_events ADD COLUMN IF NOT EXISTS hash TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS secrets_expires_at ON secrets (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS sends (
+    id TEXT PRIMARY KEY,
+    owner_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    ciphertext TEXT NOT NULL,
+    views_left INTEGER NOT NULL CHECK (views_left >= 0),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS search_tokens TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS secrets_search_tokens ON secrets USING GIN (search_tokens);
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE secrets ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS secrets_user_updated ON secrets (user_id, updated_at, id);
+
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    event_id BIGINT PRIMARY KEY,
+    hash TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
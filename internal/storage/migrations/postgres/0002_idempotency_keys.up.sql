@@ -0,0 +1,12 @@
+-- Responses of mutating requests replayed for retries with the same idempotency key.
+CREATE TABLE idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    response BYTEA,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,42 @@
+-- Secrets created with UUIDs get integer ids following the largest legacy id.
+
+CREATE SEQUENCE secrets_id_seq;
+
+SELECT setval('secrets_id_seq', COALESCE((SELECT max(legacy_id) FROM secrets), 0) + 1, false);
+
+UPDATE secrets SET legacy_id = nextval('secrets_id_seq') WHERE legacy_id IS NULL;
+
+ALTER TABLE shares ADD COLUMN secret_legacy_id INTEGER;
+
+UPDATE shares SET secret_legacy_id = secrets.legacy_id FROM secrets WHERE secrets.id = shares.secret_id;
+
+ALTER TABLE shares DROP COLUMN secret_id;
+
+ALTER TABLE shares RENAME COLUMN secret_legacy_id TO secret_id;
+
+ALTER TABLE shares ALTER COLUMN secret_id SET NOT NULL;
+
+DROP INDEX IF EXISTS secrets_user_updated;
+
+ALTER TABLE secrets DROP CONSTRAINT secrets_pkey;
+
+ALTER TABLE secrets DROP COLUMN id;
+
+ALTER TABLE secrets DROP CONSTRAINT secrets_legacy_id_key;
+
+ALTER TABLE secrets RENAME COLUMN legacy_id TO id;
+
+ALTER TABLE secrets ALTER COLUMN id SET NOT NULL;
+
+ALTER TABLE secrets ALTER COLUMN id SET DEFAULT nextval('secrets_id_seq');
+
+ALTER SEQUENCE secrets_id_seq OWNED BY secrets.id;
+
+ALTER TABLE secrets ADD CONSTRAINT secrets_pkey PRIMARY KEY (id);
+
+CREATE INDEX secrets_user_updated ON secrets (user_id, updated_at, id);
+
+ALTER TABLE shares ADD CONSTRAINT shares_secret_id_fkey
+    FOREIGN KEY (secret_id) REFERENCES secrets(id) ON DELETE CASCADE;
+
+ALTER TABLE shares ADD CONSTRAINT shares_secret_id_recipient_id_key UNIQUE (secret_id, recipient_id);
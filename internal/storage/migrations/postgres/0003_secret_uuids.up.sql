@@ -0,0 +1,41 @@
+-- Secret ids become UUIDs which clients can generate before the secret reaches the server.
+-- Integer ids of existing secrets are kept in legacy_id so that they can still be looked up.
+
+ALTER TABLE secrets ADD COLUMN uuid UUID NOT NULL DEFAULT gen_random_uuid();
+
+ALTER TABLE shares ADD COLUMN secret_uuid UUID;
+
+UPDATE shares SET secret_uuid = secrets.uuid FROM secrets WHERE secrets.id = shares.secret_id;
+
+ALTER TABLE shares DROP COLUMN secret_id;
+
+ALTER TABLE shares RENAME COLUMN secret_uuid TO secret_id;
+
+ALTER TABLE shares ALTER COLUMN secret_id SET NOT NULL;
+
+DROP INDEX IF EXISTS secrets_user_updated;
+
+ALTER TABLE secrets DROP CONSTRAINT secrets_pkey;
+
+ALTER TABLE secrets RENAME COLUMN id TO legacy_id;
+
+ALTER TABLE secrets ALTER COLUMN legacy_id DROP DEFAULT;
+
+ALTER TABLE secrets ALTER COLUMN legacy_id DROP NOT NULL;
+
+DROP SEQUENCE IF EXISTS secrets_id_seq;
+
+ALTER TABLE secrets ADD CONSTRAINT secrets_legacy_id_key UNIQUE (legacy_id);
+
+ALTER TABLE secrets RENAME COLUMN uuid TO id;
+
+ALTER TABLE secrets ALTER COLUMN id DROP DEFAULT;
+
+ALTER TABLE secrets ADD CONSTRAINT secrets_pkey PRIMARY KEY (id);
+
+CREATE INDEX secrets_user_updated ON secrets (user_id, updated_at, id);
+
+ALTER TABLE shares ADD CONSTRAINT shares_secret_id_fkey
+    FOREIGN KEY (secret_id) REFERENCES secrets(id) ON DELETE CASCADE;
+
+ALTER TABLE shares ADD CONSTRAINT shares_secret_id_recipient_id_key UNIQUE (secret_id, recipient_id);
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS sends;
+DROP TABLE IF EXISTS audit_checkpoints;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS collection_keys;
+DROP TABLE IF EXISTS org_invitations;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS shares;
+DROP TABLE IF EXISTS user_keys;
+DROP TABLE IF EXISTS secret_tokens;
+DROP TABLE IF EXISTS secrets;
+DROP TABLE IF EXISTS collections;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS users;
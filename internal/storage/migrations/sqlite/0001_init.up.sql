@@ -0,0 +1,116 @@
+-- Initial schema with the same tables as in PostgreSQL.
+-- SQLite has no arrays, UUID and TIMESTAMPTZ types, so user ids are stored as TEXT,
+-- times as INTEGER microseconds since the epoch and blind index tokens of secrets
+-- in the secret_tokens table indexed by token.
+
+CREATE TABLE IF NOT EXISTS users (
+    uuid TEXT PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS collections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS secrets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    data TEXT NOT NULL,
+    meta TEXT,
+    collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE,
+    expires_at INTEGER,
+    type TEXT NOT NULL DEFAULT '',
+    updated_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS secrets_expires_at ON secrets (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS secrets_user_updated ON secrets (user_id, updated_at, id);
+
+CREATE TABLE IF NOT EXISTS secret_tokens (
+    token TEXT NOT NULL,
+    secret_id INTEGER NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+    PRIMARY KEY (token, secret_id)
+);
+
+CREATE INDEX IF NOT EXISTS secret_tokens_secret ON secret_tokens (secret_id);
+
+CREATE TABLE IF NOT EXISTS user_keys (
+    user_id TEXT PRIMARY KEY REFERENCES users(uuid) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    encrypted_private_key TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS shares (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    secret_id INTEGER NOT NULL REFERENCES secrets(id) ON DELETE CASCADE,
+    owner_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    recipient_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    wrapped_key TEXT NOT NULL,
+    data TEXT NOT NULL,
+    meta TEXT,
+    UNIQUE (secret_id, recipient_id)
+);
+
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS org_invitations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    invited_by TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    UNIQUE (org_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS collection_keys (
+    collection_id INTEGER NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    wrapped_key TEXT NOT NULL,
+    PRIMARY KEY (collection_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT,
+    type TEXT NOT NULL,
+    method TEXT NOT NULL,
+    success INTEGER NOT NULL,
+    peer_ip TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    details TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    prev_hash TEXT NOT NULL DEFAULT '',
+    hash TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_user_time ON audit_events (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    event_id INTEGER PRIMARY KEY,
+    hash TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sends (
+    id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    ciphertext TEXT NOT NULL,
+    views_left INTEGER NOT NULL CHECK (views_left >= 0),
+    expires_at INTEGER NOT NULL,
+    created_at INTEGER NOT NULL
+);
@@ -0,0 +1,13 @@
+-- Responses of mutating requests replayed for retries with the same idempotency key.
+-- Times are stored as Unix microseconds.
+CREATE TABLE idempotency_keys (
+    user_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    response BLOB,
+    expires_at INTEGER NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
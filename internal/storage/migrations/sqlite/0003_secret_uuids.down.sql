@@ -0,0 +1,70 @@
+-- Secrets created with UUIDs get integer ids following the largest legacy id.
+
+CREATE TABLE secret_ids (
+    id TEXT PRIMARY KEY,
+    legacy_id INTEGER NOT NULL UNIQUE
+);
+
+INSERT INTO secret_ids (id, legacy_id)
+SELECT id, COALESCE(legacy_id, (SELECT COALESCE(max(legacy_id), 0) FROM secrets) +
+    row_number() OVER (PARTITION BY legacy_id IS NULL ORDER BY updated_at, id))
+FROM secrets;
+
+CREATE TABLE secrets_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    data TEXT NOT NULL,
+    meta TEXT,
+    collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE,
+    expires_at INTEGER,
+    type TEXT NOT NULL DEFAULT '',
+    updated_at INTEGER NOT NULL
+);
+
+INSERT INTO secrets_old (id, user_id, data, meta, collection_id, expires_at, type, updated_at)
+SELECT i.legacy_id, s.user_id, s.data, s.meta, s.collection_id, s.expires_at, s.type, s.updated_at
+FROM secrets s JOIN secret_ids i ON i.id = s.id;
+
+CREATE TABLE secret_tokens_old (
+    token TEXT NOT NULL,
+    secret_id INTEGER NOT NULL REFERENCES secrets_old(id) ON DELETE CASCADE,
+    PRIMARY KEY (token, secret_id)
+);
+
+INSERT INTO secret_tokens_old (token, secret_id)
+SELECT t.token, i.legacy_id FROM secret_tokens t JOIN secret_ids i ON i.id = t.secret_id;
+
+CREATE TABLE shares_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    secret_id INTEGER NOT NULL REFERENCES secrets_old(id) ON DELETE CASCADE,
+    owner_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    recipient_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    wrapped_key TEXT NOT NULL,
+    data TEXT NOT NULL,
+    meta TEXT,
+    UNIQUE (secret_id, recipient_id)
+);
+
+INSERT INTO shares_old (id, secret_id, owner_id, recipient_id, wrapped_key, data, meta)
+SELECT sh.id, i.legacy_id, sh.owner_id, sh.recipient_id, sh.wrapped_key, sh.data, sh.meta
+FROM shares sh JOIN secret_ids i ON i.id = sh.secret_id;
+
+DROP TABLE secret_ids;
+
+DROP TABLE secret_tokens;
+
+DROP TABLE shares;
+
+DROP TABLE secrets;
+
+ALTER TABLE secrets_old RENAME TO secrets;
+
+ALTER TABLE secret_tokens_old RENAME TO secret_tokens;
+
+ALTER TABLE shares_old RENAME TO shares;
+
+CREATE INDEX secrets_expires_at ON secrets (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE INDEX secrets_user_updated ON secrets (user_id, updated_at, id);
+
+CREATE INDEX secret_tokens_secret ON secret_tokens (secret_id);
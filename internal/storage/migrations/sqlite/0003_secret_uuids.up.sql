@@ -0,0 +1,66 @@
+-- Secret ids become UUIDs which clients can generate before the secret reaches the server.
+-- Integer ids of existing secrets are kept in legacy_id so that they can still be looked up.
+-- SQLite can't change a primary key in place, so secrets and the tables referencing them
+-- are rebuilt. Renaming secrets_new updates the references of the new tables.
+
+CREATE TABLE secrets_new (
+    id TEXT PRIMARY KEY,
+    legacy_id INTEGER UNIQUE,
+    user_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    data TEXT NOT NULL,
+    meta TEXT,
+    collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE,
+    expires_at INTEGER,
+    type TEXT NOT NULL DEFAULT '',
+    updated_at INTEGER NOT NULL
+);
+
+-- Random version 4 UUIDs.
+INSERT INTO secrets_new (id, legacy_id, user_id, data, meta, collection_id, expires_at, type, updated_at)
+SELECT lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+    substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+    substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6))),
+    id, user_id, data, meta, collection_id, expires_at, type, updated_at
+FROM secrets;
+
+CREATE TABLE secret_tokens_new (
+    token TEXT NOT NULL,
+    secret_id TEXT NOT NULL REFERENCES secrets_new(id) ON DELETE CASCADE,
+    PRIMARY KEY (token, secret_id)
+);
+
+INSERT INTO secret_tokens_new (token, secret_id)
+SELECT t.token, s.id FROM secret_tokens t JOIN secrets_new s ON s.legacy_id = t.secret_id;
+
+CREATE TABLE shares_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    secret_id TEXT NOT NULL REFERENCES secrets_new(id) ON DELETE CASCADE,
+    owner_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    recipient_id TEXT NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
+    wrapped_key TEXT NOT NULL,
+    data TEXT NOT NULL,
+    meta TEXT,
+    UNIQUE (secret_id, recipient_id)
+);
+
+INSERT INTO shares_new (id, secret_id, owner_id, recipient_id, wrapped_key, data, meta)
+SELECT sh.id, s.id, sh.owner_id, sh.recipient_id, sh.wrapped_key, sh.data, sh.meta
+FROM shares sh JOIN secrets_new s ON s.legacy_id = sh.secret_id;
+
+DROP TABLE secret_tokens;
+
+DROP TABLE shares;
+
+DROP TABLE secrets;
+
+ALTER TABLE secrets_new RENAME TO secrets;
+
+ALTER TABLE secret_tokens_new RENAME TO secret_tokens;
+
+ALTER TABLE shares_new RENAME TO shares;
+
+CREATE INDEX secrets_expires_at ON secrets (expires_at) WHERE expires_at IS NOT NULL;
+
+CREATE INDEX secrets_user_updated ON secrets (user_id, updated_at, id);
+
+CREATE INDEX secret_tokens_secret ON secret_tokens (secret_id);
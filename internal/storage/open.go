@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	// Register the pure Go SQLite driver.
+	_ "modernc.org/sqlite"
+)
+
+// sqliteParams are the connection parameters of every SQLite connection:
+// foreign keys enforce ON DELETE CASCADE, WAL lets readers work during writes, busy timeout
+// waits for the lock held by another process and immediate transactions take the write lock
+// at once so that quota checks and audit chain appends are serialized like in PostgreSQL.
+const sqliteParams = "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+
+// Backend is a storage with versioned schema migrations.
+type Backend interface {
+	Storage
+	Migrator
+}
+
+// Open connects to the storage selected by the scheme of the connection string.
+// The schema is not changed, call MigrateUp to apply pending migrations.
+// Supported connection strings:
+//   - sqlite://path and file:path select an embedded SQLite database file;
+//   - any other string is a PostgreSQL URL or key/value DSN, e.g. "host=localhost dbname=gophkeeper".
+//
+// Every query is cancelled after queryTimeout, zero means no limit besides the caller's context.
+// The returned function closes the storage connections.
+func Open(ctx context.Context, connectionString string, queryTimeout time.Duration) (Backend, func(), error) {
+	if strings.HasPrefix(connectionString, "sqlite://") || strings.HasPrefix(connectionString, "file:") {
+		db, err := sql.Open("sqlite", sqliteDSN(connectionString))
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to open database: %w", err)
+		}
+		return NewSQLiteStore(db, queryTimeout), func() { db.Close() }, nil
+	}
+
+	cfg, err := pgxpool.ParseConfig(connectionString)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid storage connection string, expected PostgreSQL DSN, sqlite:// or file: scheme: %w", err)
+	}
+	db, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+	return NewDBStore(db, queryTimeout), db.Close, nil
+}
+
+// sqliteDSN converts sqlite:// or file: connection string to the SQLite driver DSN with sqliteParams.
+func sqliteDSN(connectionString string) string {
+	dsn := connectionString
+	if path, ok := strings.CutPrefix(connectionString, "sqlite://"); ok {
+		dsn = "file:" + path
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + sqliteParams
+	}
+	return dsn + "?" + sqliteParams
+}
@@ -0,0 +1,456 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrMemberNotFound is returned when the user isn't a member of organization.
+var ErrMemberNotFound = NewError(KindNotFound, "member not found")
+
+// ErrInvitationNotFound is returned when there is no pending invitation with requested id for the user.
+var ErrInvitationNotFound = NewError(KindNotFound, "invitation not found")
+
+// ErrCollectionNotFound is returned when there is no collection with requested id.
+var ErrCollectionNotFound = NewError(KindNotFound, "collection not found")
+
+// ErrLastOwner is returned when the operation would leave organization without owners.
+var ErrLastOwner = NewError(KindFailedPrecondition, "organization must have at least one owner")
+
+// checkOwnerLeft checks that organization with members keeps an owner after the member's role changes to role.
+// Empty role means the member is removed. Returns ErrMemberNotFound if userID isn't a member.
+func checkOwnerLeft(members map[string]models.Role, userID string, role models.Role) error {
+	current, ok := members[userID]
+	if !ok {
+		return ErrMemberNotFound
+	}
+	if current != models.RoleOwner || role == models.RoleOwner {
+		return nil
+	}
+
+	for id, r := range members {
+		if id != userID && r == models.RoleOwner {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
+
+// reassignCollectionSecretsQuery passes collection secrets created by the user $1 to the first other owner
+// of the organization, so that they don't follow the deleted creator.
+const reassignCollectionSecretsQuery = `
+	UPDATE secrets SET user_id = (
+		SELECT m.user_id FROM org_members m JOIN collections c ON c.org_id = m.org_id
+		WHERE c.id = secrets.collection_id AND m.role = 'owner' AND m.user_id <> $1
+		ORDER BY m.user_id LIMIT 1)
+	WHERE user_id = $1 AND collection_id IS NOT NULL`
+
+// lockMembers locks member rows of organization inside transaction tx and returns their roles.
+func lockMembers(ctx context.Context, tx pgx.Tx, orgID int64) (map[string]models.Role, error) {
+	rows, err := tx.Query(ctx, `SELECT user_id, role FROM org_members WHERE org_id = $1 FOR UPDATE`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make(map[string]models.Role)
+	for rows.Next() {
+		var userID string
+		var role models.Role
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		members[userID] = role
+	}
+
+	return members, rows.Err()
+}
+
+// CreateOrganization saves a new organization making user its owner.
+func (store *DBStore) CreateOrganization(ctx context.Context, org *models.Organization, ownerID string) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	err = tx.QueryRow(ctx, `INSERT INTO organizations (name) VALUES ($1) RETURNING id`, org.Name).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO org_members (org_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err = tx.Exec(ctx, query, id, ownerID, models.RoleOwner); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// GetMemberships retrieves all organizations user is member of.
+func (store *DBStore) GetMemberships(ctx context.Context, userID string) ([]models.Member, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT m.org_id, o.name, m.user_id, m.role
+	FROM org_members m JOIN organizations o ON o.id = m.org_id
+	WHERE m.user_id = $1 ORDER BY m.org_id`
+
+	return store.queryMembers(ctx, query, userID)
+}
+
+// GetMember retrieves user's membership in organization.
+func (store *DBStore) GetMember(ctx context.Context, orgID int64, userID string) (models.Member, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT m.org_id, o.name, m.user_id, u.username, m.role
+	FROM org_members m
+	JOIN organizations o ON o.id = m.org_id
+	JOIN users u ON u.uuid = m.user_id
+	WHERE m.org_id = $1 AND m.user_id = $2`
+	var member models.Member
+	err := store.db.QueryRow(ctx, query, orgID, userID).Scan(
+		&member.OrgID, &member.OrgName, &member.UserID, &member.Username, &member.Role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Member{}, ErrMemberNotFound
+		}
+		return models.Member{}, err
+	}
+
+	return member, nil
+}
+
+// GetMembers retrieves all members of organization with their public keys.
+func (store *DBStore) GetMembers(ctx context.Context, orgID int64) ([]models.Member, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT m.org_id, u.username, m.user_id, m.role, COALESCE(k.public_key, '')
+	FROM org_members m
+	JOIN users u ON u.uuid = m.user_id
+	LEFT JOIN user_keys k ON k.user_id = m.user_id
+	WHERE m.org_id = $1 ORDER BY u.username`
+	rows, err := store.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]models.Member, 0)
+	for rows.Next() {
+		var member models.Member
+		if err := rows.Scan(&member.OrgID, &member.Username, &member.UserID, &member.Role, &member.PublicKey); err != nil {
+			logging.Sugar.Errorw("failed to retrieve member", "error", err)
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// queryMembers runs a query returning org_id, org name, user_id and role of members.
+func (store *DBStore) queryMembers(ctx context.Context, query string, args ...any) ([]models.Member, error) {
+	rows, err := store.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]models.Member, 0)
+	for rows.Next() {
+		var member models.Member
+		if err := rows.Scan(&member.OrgID, &member.OrgName, &member.UserID, &member.Role); err != nil {
+			logging.Sugar.Errorw("failed to retrieve membership", "error", err)
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// SetMemberRole changes member's role in organization.
+// Member rows are locked while owners are counted, so concurrent changes can't leave organization without owners.
+func (store *DBStore) SetMemberRole(ctx context.Context, orgID int64, userID string, role models.Role) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	members, err := lockMembers(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if err := checkOwnerLeft(members, userID, role); err != nil {
+		return err
+	}
+
+	query := `UPDATE org_members SET role = $1 WHERE org_id = $2 AND user_id = $3`
+	if _, err = tx.Exec(ctx, query, role, orgID, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RemoveMember removes member from organization together with his collection keys
+// and pending invitations. The last owner of organization can't be removed.
+func (store *DBStore) RemoveMember(ctx context.Context, orgID int64, userID string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	members, err := lockMembers(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if err := checkOwnerLeft(members, userID, ""); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM org_members WHERE org_id = $1 AND user_id = $2`, orgID, userID); err != nil {
+		return err
+	}
+
+	query := `
+	DELETE FROM collection_keys
+	WHERE user_id = $1 AND collection_id IN (SELECT id FROM collections WHERE org_id = $2)`
+	if _, err = tx.Exec(ctx, query, userID, orgID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AddInvitation saves invitation of user to organization with collection keys wrapped for him.
+func (store *DBStore) AddInvitation(ctx context.Context, inv *models.Invitation, keys []models.CollectionKey) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	query := `INSERT INTO org_invitations (org_id, user_id, role, invited_by) VALUES ($1, $2, $3, $4) RETURNING id`
+	err = tx.QueryRow(ctx, query, inv.OrgID, inv.UserID, inv.Role, inv.InvitedBy).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrAlreadyExists
+		}
+		return 0, err
+	}
+
+	if err = saveCollectionKeys(ctx, tx, keys); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// GetInvitations retrieves pending invitations of user.
+func (store *DBStore) GetInvitations(ctx context.Context, userID string) ([]models.Invitation, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT i.id, i.org_id, o.name, i.user_id, i.role, u.username
+	FROM org_invitations i
+	JOIN organizations o ON o.id = i.org_id
+	JOIN users u ON u.uuid = i.invited_by
+	WHERE i.user_id = $1 ORDER BY i.id`
+	rows, err := store.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := make([]models.Invitation, 0)
+	for rows.Next() {
+		var inv models.Invitation
+		if err := rows.Scan(&inv.ID, &inv.OrgID, &inv.OrgName, &inv.UserID, &inv.Role, &inv.InvitedBy); err != nil {
+			logging.Sugar.Errorw("failed to retrieve invitation", "error", err)
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+
+	return invitations, rows.Err()
+}
+
+// AcceptInvitation makes invited user a member of organization and removes the invitation.
+func (store *DBStore) AcceptInvitation(ctx context.Context, invitationID int64, userID string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var orgID int64
+	var role models.Role
+	query := `DELETE FROM org_invitations WHERE id = $1 AND user_id = $2 RETURNING org_id, role`
+	err = tx.QueryRow(ctx, query, invitationID, userID).Scan(&orgID, &role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+
+	query = `INSERT INTO org_members (org_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err = tx.Exec(ctx, query, orgID, userID, role); err != nil {
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateCollection saves a new collection of organization with its key wrapped for members.
+func (store *DBStore) CreateCollection(ctx context.Context, collection *models.Collection, keys []models.CollectionKey) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	query := `INSERT INTO collections (org_id, name) VALUES ($1, $2) RETURNING id`
+	if err = tx.QueryRow(ctx, query, collection.OrgID, collection.Name).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	for i := range keys {
+		keys[i].CollectionID = id
+	}
+	if err = saveCollectionKeys(ctx, tx, keys); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// saveCollectionKeys saves wrapped collection keys replacing existing ones.
+func saveCollectionKeys(ctx context.Context, tx pgx.Tx, keys []models.CollectionKey) error {
+	query := `
+	INSERT INTO collection_keys (collection_id, user_id, wrapped_key) VALUES ($1, $2, $3)
+	ON CONFLICT (collection_id, user_id) DO UPDATE SET wrapped_key = EXCLUDED.wrapped_key`
+	for _, key := range keys {
+		if _, err := tx.Exec(ctx, query, key.CollectionID, key.UserID, key.WrappedKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCollection retrieves collection by its ID.
+func (store *DBStore) GetCollection(ctx context.Context, collectionID int64) (models.Collection, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT id, org_id, name FROM collections WHERE id = $1`
+	var collection models.Collection
+	err := store.db.QueryRow(ctx, query, collectionID).Scan(&collection.ID, &collection.OrgID, &collection.Name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Collection{}, ErrCollectionNotFound
+		}
+		return models.Collection{}, err
+	}
+
+	return collection, nil
+}
+
+// GetCollectionKeys retrieves collection keys wrapped for user.
+// Keys of all organizations are returned if orgID is 0.
+func (store *DBStore) GetCollectionKeys(ctx context.Context, userID string, orgID int64) ([]models.CollectionKey, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT k.collection_id, c.name, c.org_id, k.user_id, k.wrapped_key
+	FROM collection_keys k JOIN collections c ON c.id = k.collection_id
+	WHERE k.user_id = $1 AND ($2 = 0 OR c.org_id = $2) ORDER BY k.collection_id`
+	rows, err := store.db.Query(ctx, query, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.CollectionKey, 0)
+	for rows.Next() {
+		var key models.CollectionKey
+		if err := rows.Scan(&key.CollectionID, &key.CollectionName, &key.OrgID, &key.UserID, &key.WrappedKey); err != nil {
+			logging.Sugar.Errorw("failed to retrieve collection key", "error", err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// GetCollectionSecrets retrieves all secrets of collection.
+func (store *DBStore) GetCollectionSecrets(ctx context.Context, collectionID int64) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT id, user_id, data, meta, collection_id, expires_at FROM secrets
+	WHERE collection_id = $1 AND (expires_at IS NULL OR expires_at > now()) ORDER BY id`
+	rows, err := store.db.Query(ctx, query, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]models.Secret, 0)
+	for rows.Next() {
+		var secret models.Secret
+		var expiresAt *time.Time
+		err := rows.Scan(&secret.ID, &secret.UserID, &secret.Data, &secret.Meta, &secret.CollectionID, &expiresAt)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve secret", "error", err)
+			return nil, err
+		}
+		secret.ExpiresAt = timeOrZero(expiresAt)
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, rows.Err()
+}
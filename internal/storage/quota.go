@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrQuotaExceeded is returned when saving a secret exceeds user's quota.
+var ErrQuotaExceeded = NewError(KindResourceExhausted, "quota exceeded")
+
+// secretSize is the SQL expression of the size of a secrets row counted by quotas. Meta may be NULL.
+const secretSize = `octet_length(data) + COALESCE(octet_length(meta), 0)`
+
+// usageQuery selects the number of user's secrets and the total size of his secrets and sends.
+const usageQuery = `
+	SELECT COUNT(*), COALESCE(SUM(` + secretSize + `), 0) +
+		(SELECT COALESCE(SUM(octet_length(ciphertext)), 0) FROM sends WHERE owner_id = $1)
+	FROM secrets WHERE user_id = $1`
+
+// checkQuota checks that adding newSecrets secrets of deltaBytes total size to usage fits the quota.
+// secretBytes is the size of the saved secret.
+func checkQuota(quota models.Quota, usage models.Usage, newSecrets, deltaBytes, secretBytes int64) error {
+	if quota.MaxSecretBytes > 0 && secretBytes > quota.MaxSecretBytes {
+		return fmt.Errorf("%w: secret size of %d bytes exceeds the limit of %d bytes",
+			ErrQuotaExceeded, secretBytes, quota.MaxSecretBytes)
+	}
+	if newSecrets > 0 && quota.MaxSecrets > 0 && usage.Secrets+newSecrets > quota.MaxSecrets {
+		return fmt.Errorf("%w: the limit of %d secrets is reached", ErrQuotaExceeded, quota.MaxSecrets)
+	}
+	if deltaBytes > 0 && quota.MaxTotalBytes > 0 && usage.Bytes+deltaBytes > quota.MaxTotalBytes {
+		return fmt.Errorf("%w: total size of %d bytes would exceed the limit of %d bytes",
+			ErrQuotaExceeded, usage.Bytes+deltaBytes, quota.MaxTotalBytes)
+	}
+
+	return nil
+}
+
+// lockUsage locks the user row until the end of transaction and returns user's usage.
+// The lock serializes concurrent writes of the user so that they can't exceed the quota together.
+func lockUsage(ctx context.Context, tx pgx.Tx, userID string) (models.Usage, error) {
+	var usage models.Usage
+
+	err := tx.QueryRow(ctx, `SELECT 1 FROM users WHERE uuid = $1 FOR UPDATE`, userID).Scan(new(int))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return usage, ErrNotFound
+		}
+		return usage, err
+	}
+
+	err = tx.QueryRow(ctx, usageQuery, userID).Scan(&usage.Secrets, &usage.Bytes)
+	return usage, err
+}
+
+// GetUsage returns the number and the total size of user's secrets.
+func (store *DBStore) GetUsage(ctx context.Context, userID string) (models.Usage, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	var usage models.Usage
+	err := store.db.QueryRow(ctx, usageQuery, userID).Scan(&usage.Secrets, &usage.Bytes)
+
+	return usage, err
+}
+
+// GetUsage returns the number and the total size of user's secrets.
+func (fs *FakeStorage) GetUsage(ctx context.Context, userID string) (models.Usage, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Usage{}, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.usage(userID), nil
+}
+
+// usage counts user's secrets. Caller must hold the mutex.
+func (fs *FakeStorage) usage(userID string) models.Usage {
+	var usage models.Usage
+	for _, s := range fs.secrets[userID] {
+		usage.Secrets++
+		usage.Bytes += s.Size()
+	}
+	for _, send := range fs.sends {
+		if send.OwnerID == userID {
+			usage.Bytes += int64(len(send.Ciphertext))
+		}
+	}
+	return usage
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+)
+
+// SearchSecrets returns user's personal secrets having all the blind index tokens.
+// Tokens are matched by the GIN index on the search_tokens column.
+func (store *DBStore) SearchSecrets(ctx context.Context, userID string, tokens []string) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT id, user_id, data, meta, expires_at FROM secrets
+	WHERE user_id = $1 AND collection_id IS NULL AND (expires_at IS NULL OR expires_at > now())
+		AND search_tokens @> $2
+	ORDER BY id`
+	rows, err := store.db.Query(ctx, query, userID, searchTokens(tokens))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]models.Secret, 0)
+	for rows.Next() {
+		var secret models.Secret
+		var expiresAt *time.Time
+		if err := rows.Scan(&secret.ID, &secret.UserID, &secret.Data, &secret.Meta, &expiresAt); err != nil {
+			return nil, err
+		}
+		secret.ExpiresAt = timeOrZero(expiresAt)
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, rows.Err()
+}
+
+// SearchSecrets returns user's personal secrets having all the blind index tokens.
+func (fs *FakeStorage) SearchSecrets(ctx context.Context, userID string, tokens []string) ([]models.Secret, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	secrets := make([]models.Secret, 0)
+	for _, secret := range fs.secrets[userID] {
+		if secret.CollectionID != 0 || secret.Expired(time.Now()) || !containsAll(secret.SearchTokens, tokens) {
+			continue
+		}
+		secrets = append(secrets, *secret)
+	}
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].ID < secrets[j].ID })
+	return secrets, nil
+}
+
+// containsAll reports whether set contains all the values.
+func containsAll(set, values []string) bool {
+	for _, v := range values {
+		if !slices.Contains(set, v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrSendNotFound is returned when a send doesn't exist, is expired or has no views left.
+var ErrSendNotFound = NewError(KindNotFound, "send not found or already burned")
+
+// AddSend saves a new send to the database.
+// Quota check and insert are done in one transaction holding a lock on the owner like AddSecret does.
+func (store *DBStore) AddSend(ctx context.Context, send *models.Send, quota models.Quota) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	usage, err := lockUsage(ctx, tx, send.OwnerID)
+	if err != nil {
+		return err
+	}
+	if err := checkQuota(quota, usage, 0, send.Size(), send.Size()); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO sends (id, owner_id, ciphertext, views_left, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err = tx.Exec(ctx, query,
+		send.ID, send.OwnerID, send.Ciphertext, send.ViewsLeft, send.ExpiresAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// OpenSend consumes one view of the send. The view counter is decremented by a single
+// conditional UPDATE so concurrent openings can't exceed the view limit.
+// The send is deleted in the same transaction after its last view.
+func (store *DBStore) OpenSend(ctx context.Context, id string, now time.Time) (models.Send, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return models.Send{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE sends SET views_left = views_left - 1
+	WHERE id = $1 AND views_left > 0 AND expires_at > $2
+	RETURNING id, owner_id, ciphertext, views_left, expires_at, created_at`
+	var send models.Send
+	err = tx.QueryRow(ctx, query, id, now).Scan(&send.ID, &send.OwnerID, &send.Ciphertext,
+		&send.ViewsLeft, &send.ExpiresAt, &send.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Send{}, ErrSendNotFound
+		}
+		return models.Send{}, err
+	}
+
+	if send.ViewsLeft == 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM sends WHERE id = $1`, id); err != nil {
+			return models.Send{}, err
+		}
+	}
+
+	return send, tx.Commit(ctx)
+}
+
+// DeleteExpiredSends removes sends expired at the moment now from the database.
+func (store *DBStore) DeleteExpiredSends(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tag, err := store.db.Exec(ctx, `DELETE FROM sends WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// AddSend saves a new send.
+func (fs *FakeStorage) AddSend(ctx context.Context, send *models.Send, quota models.Quota) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, exists := fs.usersByID[send.OwnerID]; !exists {
+		return ErrNotFound
+	}
+	if err := checkQuota(quota, fs.usage(send.OwnerID), 0, send.Size(), send.Size()); err != nil {
+		return err
+	}
+	if _, exists := fs.sends[send.ID]; exists {
+		return ErrAlreadyExists
+	}
+	stored := *send
+	stored.CreatedAt = time.Now()
+	fs.sends[send.ID] = &stored
+	return nil
+}
+
+// OpenSend consumes one view of the send, deleting it after the last view.
+func (fs *FakeStorage) OpenSend(ctx context.Context, id string, now time.Time) (models.Send, error) {
+	if err := ctx.Err(); err != nil {
+		return models.Send{}, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	send, exists := fs.sends[id]
+	if !exists || send.ViewsLeft <= 0 || !now.Before(send.ExpiresAt) {
+		return models.Send{}, ErrSendNotFound
+	}
+
+	send.ViewsLeft--
+	if send.ViewsLeft == 0 {
+		delete(fs.sends, id)
+	}
+	return *send, nil
+}
+
+// DeleteExpiredSends removes sends expired at the moment now.
+func (fs *FakeStorage) DeleteExpiredSends(ctx context.Context, now time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var n int64
+	for id, send := range fs.sends {
+		if !now.Before(send.ExpiresAt) {
+			delete(fs.sends, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrShareNotFound is returned when the secret isn't shared with the recipient.
+var ErrShareNotFound = NewError(KindNotFound, "share not found")
+
+// ErrKeyNotFound is returned when the user has no sharing keypair.
+var ErrKeyNotFound = NewError(KindNotFound, "sharing keypair not found")
+
+// ErrSharesOutdated is returned when a shared secret is edited without copies re-encrypted for all its recipients.
+var ErrSharesOutdated = NewError(KindFailedPrecondition,
+	"secret is shared: copies of the secret re-encrypted for every recipient must be provided")
+
+// checkShareCopies checks that shares contain a copy of the edited secret for every recipient.
+// Copies for users the secret isn't shared with are ignored.
+func checkShareCopies(recipients []string, shares []models.Share) error {
+	copies := make(map[string]bool, len(shares))
+	for _, share := range shares {
+		copies[share.RecipientID] = true
+	}
+
+	for _, recipient := range recipients {
+		if !copies[recipient] {
+			return ErrSharesOutdated
+		}
+	}
+
+	return nil
+}
+
+// replaceShareCopies replaces the copies of the secret shared with its recipients by shares inside transaction tx.
+// The caller must hold the lock on the secret row: AddShare takes the same lock,
+// so the secret can't be shared with another user until the transaction ends.
+func replaceShareCopies(ctx context.Context, tx pgx.Tx, secretID string, shares []models.Share) error {
+	rows, err := tx.Query(ctx, `SELECT recipient_id FROM shares WHERE secret_id = $1 FOR UPDATE`, secretID)
+	if err != nil {
+		return err
+	}
+	recipients, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+	if err := checkShareCopies(recipients, shares); err != nil {
+		return err
+	}
+
+	query := `UPDATE shares SET wrapped_key = $1, data = $2, meta = $3 WHERE secret_id = $4 AND recipient_id = $5`
+	for _, share := range shares {
+		_, err := tx.Exec(ctx, query, share.WrappedKey, share.Data, share.Meta, secretID, share.RecipientID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replaceSQLiteShareCopies replaces the copies of the secret shared with its recipients by shares inside transaction tx.
+// It relies on transactions beginning with the write lock (_txlock=immediate),
+// so the secret can't be shared with another user until the transaction ends.
+func replaceSQLiteShareCopies(ctx context.Context, tx *sql.Tx, secretID string, shares []models.Share) error {
+	rows, err := tx.QueryContext(ctx, `SELECT recipient_id FROM shares WHERE secret_id = $1`, secretID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var recipients []string
+	for rows.Next() {
+		var recipient string
+		if err := rows.Scan(&recipient); err != nil {
+			return err
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := checkShareCopies(recipients, shares); err != nil {
+		return err
+	}
+
+	query := `UPDATE shares SET wrapped_key = $1, data = $2, meta = $3 WHERE secret_id = $4 AND recipient_id = $5`
+	for _, share := range shares {
+		_, err := tx.ExecContext(ctx, query, share.WrappedKey, share.Data, share.Meta, secretID, share.RecipientID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,577 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteStore implements Storage interface using an embedded SQLite database.
+// It is meant for single-user installs and tests where running PostgreSQL is an overkill.
+type SQLiteStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewSQLiteStore initializes and returns a pointer to a new instance of SQLiteStore.
+// Every query is cancelled after queryTimeout, zero means no limit besides the caller's context.
+func NewSQLiteStore(db *sql.DB, queryTimeout time.Duration) *SQLiteStore {
+	return &SQLiteStore{db: db, queryTimeout: queryTimeout}
+}
+
+// queryContext derives the context of a single query from the caller's context.
+func (store *SQLiteStore) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withQueryTimeout(ctx, store.queryTimeout)
+}
+
+// toMicros converts time to microseconds since the epoch.
+func toMicros(t time.Time) int64 {
+	return t.UnixMicro()
+}
+
+// nullableMicros converts zero time to NULL.
+func nullableMicros(t time.Time) *int64 {
+	if t.IsZero() {
+		return nil
+	}
+	us := t.UnixMicro()
+	return &us
+}
+
+// fromMicros converts microseconds since the epoch to time, NULL to zero time.
+func fromMicros(us sql.NullInt64) time.Time {
+	if !us.Valid {
+		return time.Time{}
+	}
+	return time.UnixMicro(us.Int64).UTC()
+}
+
+// isSQLiteUniqueViolation reports whether err is a SQLite unique or primary key constraint violation.
+func isSQLiteUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// RegisterUser adds a new user to the database.
+func (store *SQLiteStore) RegisterUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO users (uuid, username, password) VALUES ($1, $2, $3)`
+	_, err := store.db.ExecContext(ctx, query, user.ID, user.Username, user.Password)
+	if isSQLiteUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+
+	return err
+}
+
+// GetUser retrieves users data by his username.
+func (store *SQLiteStore) GetUser(ctx context.Context, username string) (models.User, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	var user models.User
+	query := `SELECT uuid, username, password FROM users WHERE username = $1`
+	err := store.db.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// GetUserByID retrieves users data by his ID.
+func (store *SQLiteStore) GetUserByID(ctx context.Context, userID string) (models.User, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	var user models.User
+	query := `SELECT uuid, username, password FROM users WHERE uuid = $1`
+	err := store.db.QueryRowContext(ctx, query, userID).Scan(&user.ID, &user.Username, &user.Password)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// DeleteUser removes the user from the database.
+// Users secrets are removed by ON DELETE CASCADE. Collection secrets created by the user stay in their
+// collections and pass to an owner of the organization, organizations of the user alone are removed.
+// The user can't be deleted while being the last owner of an organization with other members.
+func (store *SQLiteStore) DeleteUser(ctx context.Context, userID string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT org_id FROM org_members WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	var orgIDs []int64
+	for rows.Next() {
+		var orgID int64
+		if err := rows.Scan(&orgID); err != nil {
+			rows.Close()
+			return err
+		}
+		orgIDs = append(orgIDs, orgID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, orgID := range orgIDs {
+		members, err := sqliteMembers(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		if len(members) == 1 {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM organizations WHERE id = $1`, orgID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := checkOwnerLeft(members, userID, ""); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, reassignCollectionSecretsQuery, userID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM users WHERE uuid = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if err := notFoundIfNone(res, ErrNotFound); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// notFoundIfNone returns errNotFound if the statement affected no rows.
+func notFoundIfNone(res sql.Result, errNotFound error) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotFound
+	}
+
+	return nil
+}
+
+// sqliteUsage returns user's usage inside transaction tx, failing if the user doesn't exist.
+// Transactions begin with an immediate write lock, so concurrent writes can't exceed the quota together.
+func sqliteUsage(ctx context.Context, tx *sql.Tx, userID string) (models.Usage, error) {
+	var usage models.Usage
+
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM users WHERE uuid = $1`, userID).Scan(new(int))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return usage, ErrNotFound
+		}
+		return usage, err
+	}
+
+	err = tx.QueryRowContext(ctx, sqliteUsageQuery, userID).Scan(&usage.Secrets, &usage.Bytes)
+	return usage, err
+}
+
+// sqliteSecretSize is secretSize for SQLite: length of text counts characters unless it's cast to BLOB.
+const sqliteSecretSize = `length(CAST(data AS BLOB)) + COALESCE(length(CAST(meta AS BLOB)), 0)`
+
+// sqliteUsageQuery selects the number of user's secrets and the total size of his secrets and sends.
+const sqliteUsageQuery = `
+	SELECT COUNT(*), COALESCE(SUM(` + sqliteSecretSize + `), 0) +
+		(SELECT COALESCE(SUM(length(CAST(ciphertext AS BLOB))), 0) FROM sends WHERE owner_id = $1)
+	FROM secrets WHERE user_id = $1`
+
+// GetUsage returns the number and the total size of user's secrets.
+func (store *SQLiteStore) GetUsage(ctx context.Context, userID string) (models.Usage, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	var usage models.Usage
+	err := store.db.QueryRowContext(ctx, sqliteUsageQuery, userID).Scan(&usage.Secrets, &usage.Bytes)
+
+	return usage, err
+}
+
+// saveSearchTokens replaces blind index tokens of the secret.
+func saveSearchTokens(ctx context.Context, tx *sql.Tx, secretID string, tokens []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM secret_tokens WHERE secret_id = $1`, secretID); err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		query := `INSERT INTO secret_tokens (token, secret_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, token, secretID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddSecret saves users secret to the database.
+// Quota check and insert are done in one transaction.
+func (store *SQLiteStore) AddSecret(ctx context.Context, secret *models.Secret, quota models.Quota) (string, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	usage, err := sqliteUsage(ctx, tx, secret.UserID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkQuota(quota, usage, 1, secret.Size(), secret.Size()); err != nil {
+		return "", err
+	}
+
+	if secret.ID == "" {
+		secret.ID = uuid.NewString()
+	}
+	query := `
+	INSERT INTO secrets (id, user_id, data, meta, collection_id, expires_at, type, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = tx.ExecContext(ctx, query, secret.ID, secret.UserID, secret.Data, secret.Meta,
+		nullableID(secret.CollectionID), nullableMicros(secret.ExpiresAt), secret.Type, toMicros(time.Now()))
+	if isSQLiteUniqueViolation(err) {
+		return "", ErrSecretExists
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := saveSearchTokens(ctx, tx, secret.ID, secret.SearchTokens); err != nil {
+		return "", err
+	}
+
+	return secret.ID, tx.Commit()
+}
+
+// EditSecret updates users secret in the database.
+// Quota check, update and replacement of the shared copies are done in one transaction.
+func (store *SQLiteStore) EditSecret(ctx context.Context, secret *models.Secret, shares []models.Share, quota models.Quota) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	usage, err := sqliteUsage(ctx, tx, secret.UserID)
+	if err != nil {
+		return err
+	}
+
+	var oldSize int64
+	query := `SELECT ` + sqliteSecretSize + ` FROM secrets WHERE id = $1`
+	if err := tx.QueryRowContext(ctx, query, secret.ID).Scan(&oldSize); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSecretNotFound
+		}
+		return err
+	}
+	if err := checkQuota(quota, usage, 0, secret.Size()-oldSize, secret.Size()); err != nil {
+		return err
+	}
+	if err := replaceSQLiteShareCopies(ctx, tx, secret.ID, shares); err != nil {
+		return err
+	}
+
+	query = `
+	UPDATE secrets SET data = $1, meta = $2, expires_at = $3, type = $4, updated_at = $5
+	WHERE id = $6`
+	_, err = tx.ExecContext(ctx, query, secret.Data, secret.Meta, nullableMicros(secret.ExpiresAt), secret.Type,
+		toMicros(time.Now()), secret.ID)
+	if err != nil {
+		return err
+	}
+	if err := saveSearchTokens(ctx, tx, secret.ID, secret.SearchTokens); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetSecretByID returns secret by its id.
+func (store *SQLiteStore) GetSecretByID(ctx context.Context, secretID string) (*models.Secret, error) {
+	return store.getSecret(ctx, `id = $1`, secretID)
+}
+
+// GetSecretByLegacyID returns secret by the integer id it had before secret ids became UUIDs.
+func (store *SQLiteStore) GetSecretByLegacyID(ctx context.Context, legacyID int64) (*models.Secret, error) {
+	return store.getSecret(ctx, `legacy_id = $1`, legacyID)
+}
+
+// getSecret returns secret selected by the condition on its id.
+func (store *SQLiteStore) getSecret(ctx context.Context, cond string, id any) (*models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT id, COALESCE(legacy_id, 0), user_id, data, meta, COALESCE(collection_id, 0), expires_at, type, updated_at
+	FROM secrets WHERE ` + cond
+	var secret models.Secret
+	var expiresAt, updatedAt sql.NullInt64
+	err := store.db.QueryRowContext(ctx, query, id).Scan(
+		&secret.ID, &secret.LegacyID, &secret.UserID, &secret.Data, &secret.Meta, &secret.CollectionID, &expiresAt,
+		&secret.Type, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSecretNotFound
+		}
+		return nil, err
+	}
+	secret.ExpiresAt = fromMicros(expiresAt)
+	secret.UpdatedAt = fromMicros(updatedAt)
+	return &secret, nil
+}
+
+// GetSecrets retrives and returns all users credentials.
+// Secrets of organization collections and expired secrets waiting for deletion are not included.
+func (store *SQLiteStore) GetSecrets(ctx context.Context, userID string) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT ` + listedSecretColumns + ` FROM secrets
+	WHERE user_id = $1 AND collection_id IS NULL AND (expires_at IS NULL OR expires_at > $2)`
+	rows, err := store.db.QueryContext(ctx, query, userID, toMicros(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]models.Secret, 0)
+	for rows.Next() {
+		secret, err := scanSQLiteSecret(rows)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve secret", "error", err)
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	if err = rows.Err(); err != nil {
+		logging.Sugar.Errorw("failed to retrieve secret", "error", err)
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// scanSQLiteSecret scans a row of listedSecretColumns.
+func scanSQLiteSecret(row interface{ Scan(dest ...any) error }) (models.Secret, error) {
+	var secret models.Secret
+	var expiresAt, updatedAt sql.NullInt64
+	err := row.Scan(&secret.ID, &secret.UserID, &secret.Data, &secret.Meta, &expiresAt, &secret.Type, &updatedAt)
+	if err != nil {
+		return models.Secret{}, err
+	}
+	secret.ExpiresAt = fromMicros(expiresAt)
+	secret.UpdatedAt = fromMicros(updatedAt)
+
+	return secret, nil
+}
+
+// DeleteExpiredSecrets removes secrets expired at the moment now from the database.
+func (store *SQLiteStore) DeleteExpiredSecrets(ctx context.Context, now time.Time) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	DELETE FROM secrets WHERE expires_at <= $1
+	RETURNING id, user_id, COALESCE(collection_id, 0), expires_at`
+	rows, err := store.db.QueryContext(ctx, query, toMicros(now))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := make([]models.Secret, 0)
+	for rows.Next() {
+		var secret models.Secret
+		var expiresAt sql.NullInt64
+		if err := rows.Scan(&secret.ID, &secret.UserID, &secret.CollectionID, &expiresAt); err != nil {
+			return nil, err
+		}
+		secret.ExpiresAt = fromMicros(expiresAt)
+		deleted = append(deleted, secret)
+	}
+
+	return deleted, rows.Err()
+}
+
+// SearchSecrets returns user's personal secrets having all the blind index tokens.
+// Tokens are matched by the primary key index of the secret_tokens table.
+func (store *SQLiteStore) SearchSecrets(ctx context.Context, userID string, tokens []string) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	conditions, args := sqliteSecretConditions(userID, models.SecretFilter{})
+	for _, token := range tokens {
+		args = append(args, token)
+		conditions = append(conditions,
+			fmt.Sprintf("id IN (SELECT secret_id FROM secret_tokens WHERE token = $%d)", len(args)))
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM secrets WHERE %s ORDER BY id`,
+		listedSecretColumns, strings.Join(conditions, " AND "))
+	rows, err := store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]models.Secret, 0)
+	for rows.Next() {
+		secret, err := scanSQLiteSecret(rows)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, rows.Err()
+}
+
+// sqliteSecretConditions builds WHERE conditions and their arguments selecting user's personal secrets by filter.
+// Page position and limit of the filter are not included.
+func sqliteSecretConditions(userID string, filter models.SecretFilter) ([]string, []interface{}) {
+	conditions := []string{"user_id = $1", "collection_id IS NULL", "(expires_at IS NULL OR expires_at > $2)"}
+	args := []interface{}{userID, toMicros(time.Now())}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		args = append(args, toMicros(filter.UpdatedAfter))
+		conditions = append(conditions, fmt.Sprintf("updated_at > $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+// ListSecrets returns a page of user's personal secrets selected by filter.
+// Pages use keyset pagination on (updated_at, id) or id so that they stay stable under inserts.
+// The total number of secrets matching the filter ignores the page position.
+func (store *SQLiteStore) ListSecrets(ctx context.Context, userID string, filter models.SecretFilter) ([]models.Secret, int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	conditions, args := sqliteSecretConditions(userID, filter)
+
+	var total int64
+	query := `SELECT count(*) FROM secrets WHERE ` + strings.Join(conditions, " AND ")
+	if err := store.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	secrets, err := store.secretsPage(ctx, userID, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return secrets, total, nil
+}
+
+// secretsPage returns a page of user's personal secrets selected by filter without counting them.
+func (store *SQLiteStore) secretsPage(ctx context.Context, userID string, filter models.SecretFilter) ([]models.Secret, error) {
+	conditions, args := sqliteSecretConditions(userID, filter)
+
+	order := "id"
+	if filter.OrderBy == models.SecretOrderUpdated {
+		order = "updated_at, id"
+		if filter.AfterID != "" {
+			args = append(args, toMicros(filter.AfterUpdatedAt), filter.AfterID)
+			conditions = append(conditions, fmt.Sprintf("(updated_at, id) > ($%d, $%d)", len(args)-1, len(args)))
+		}
+	} else if filter.AfterID != "" {
+		args = append(args, filter.AfterID)
+		conditions = append(conditions, fmt.Sprintf("id > $%d", len(args)))
+	}
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(`SELECT %s FROM secrets WHERE %s ORDER BY %s LIMIT $%d`,
+		listedSecretColumns, strings.Join(conditions, " AND "), order, len(args))
+	rows, err := store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]models.Secret, 0, filter.Limit)
+	for rows.Next() {
+		secret, err := scanSQLiteSecret(rows)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, rows.Err()
+}
+
+// ScanSecrets calls fn for every user's personal secret selected by filter in ID order,
+// stopping on the first error. Secrets are read by keyset pages of scanBatchSize rows and
+// the connection is released before fn is called, so a slow consumer doesn't block writers.
+// The query timeout limits every page rather than the whole scan, which lasts as long as ctx.
+// Page position and limit of the filter are ignored.
+func (store *SQLiteStore) ScanSecrets(ctx context.Context, userID string, filter models.SecretFilter, fn func(secret models.Secret) error) error {
+	filter.OrderBy = models.SecretOrderID
+	filter.AfterID = ""
+	filter.Limit = scanBatchSize
+	for {
+		pageCtx, cancel := store.queryContext(ctx)
+		secrets, err := store.secretsPage(pageCtx, userID, filter)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, secret := range secrets {
+			if err := fn(secret); err != nil {
+				return err
+			}
+		}
+		if len(secrets) < scanBatchSize {
+			return nil
+		}
+		filter.AfterID = secrets[len(secrets)-1].ID
+	}
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+)
+
+// AddAuditEvent appends event to the audit log chaining it to the last event by hash.
+// Transactions take the database write lock when they begin, so concurrent appends build a single chain.
+func (store *SQLiteStore) AddAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&event.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	event.Hash = event.ComputeHash()
+
+	var userID *string
+	if event.UserID != "" {
+		userID = &event.UserID
+	}
+
+	query := `
+	INSERT INTO audit_events (user_id, type, method, success, peer_ip, user_agent, details, created_at, prev_hash, hash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+	err = tx.QueryRowContext(ctx, query, userID, event.Type, event.Method, event.Success, event.PeerIP,
+		event.UserAgent, event.Details, toMicros(event.CreatedAt), event.PrevHash, event.Hash).Scan(&event.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sqliteAuditColumns are the columns of audit_events scanned by scanSQLiteAuditEvent.
+const sqliteAuditColumns = `id, COALESCE(user_id, ''), type, method, success, peer_ip, user_agent, details, created_at, prev_hash, hash`
+
+// scanSQLiteAuditEvent scans a row selected with sqliteAuditColumns.
+func scanSQLiteAuditEvent(rows *sql.Rows) (models.AuditEvent, error) {
+	var event models.AuditEvent
+	var createdAt sql.NullInt64
+	err := rows.Scan(&event.ID, &event.UserID, &event.Type, &event.Method, &event.Success, &event.PeerIP,
+		&event.UserAgent, &event.Details, &createdAt, &event.PrevHash, &event.Hash)
+	event.CreatedAt = fromMicros(createdAt)
+
+	return event, err
+}
+
+// GetAuditEvents retrieves user's audit events created in [from, to) ordered by time.
+func (store *SQLiteStore) GetAuditEvents(ctx context.Context, userID string, from, to time.Time, limit int) ([]models.AuditEvent, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT ` + sqliteAuditColumns + `
+	FROM audit_events
+	WHERE user_id = $1
+		AND ($2 IS NULL OR created_at >= $2)
+		AND ($3 IS NULL OR created_at < $3)
+	ORDER BY id
+	LIMIT $4`
+	// Negative limit means no limit in SQLite.
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := store.db.QueryContext(ctx, query, userID, nullableMicros(from), nullableMicros(to), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	for rows.Next() {
+		event, err := scanSQLiteAuditEvent(rows)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve audit event", "error", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// ScanAuditEvents calls fn for every audit event ordered by ID.
+func (store *SQLiteStore) ScanAuditEvents(ctx context.Context, fn func(event models.AuditEvent) error) error {
+	rows, err := store.db.QueryContext(ctx, `SELECT `+sqliteAuditColumns+` FROM audit_events ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanSQLiteAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// AddAuditCheckpoint saves signed checkpoint of the audit log.
+func (store *SQLiteStore) AddAuditCheckpoint(ctx context.Context, cp *models.AuditCheckpoint) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `INSERT INTO audit_checkpoints (event_id, hash, signature, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := store.db.ExecContext(ctx, query, cp.EventID, cp.Hash, cp.Signature, toMicros(cp.CreatedAt))
+
+	return err
+}
+
+// GetAuditCheckpoints retrieves all audit log checkpoints ordered by event ID.
+func (store *SQLiteStore) GetAuditCheckpoints(ctx context.Context) ([]models.AuditCheckpoint, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	rows, err := store.db.QueryContext(ctx, `SELECT event_id, hash, signature, created_at FROM audit_checkpoints ORDER BY event_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := make([]models.AuditCheckpoint, 0)
+	for rows.Next() {
+		var cp models.AuditCheckpoint
+		var createdAt sql.NullInt64
+		if err := rows.Scan(&cp.EventID, &cp.Hash, &cp.Signature, &createdAt); err != nil {
+			return nil, err
+		}
+		cp.CreatedAt = fromMicros(createdAt)
+		checkpoints = append(checkpoints, cp)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+// AddSend saves a new send to the database.
+func (store *SQLiteStore) AddSend(ctx context.Context, send *models.Send, quota models.Quota) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	usage, err := sqliteUsage(ctx, tx, send.OwnerID)
+	if err != nil {
+		return err
+	}
+	if err := checkQuota(quota, usage, 0, send.Size(), send.Size()); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO sends (id, owner_id, ciphertext, views_left, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.ExecContext(ctx, query, send.ID, send.OwnerID, send.Ciphertext, send.ViewsLeft,
+		toMicros(send.ExpiresAt), toMicros(time.Now()))
+	if isSQLiteUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// OpenSend consumes one view of the send with a single conditional UPDATE
+// and deletes the send in the same transaction after its last view.
+func (store *SQLiteStore) OpenSend(ctx context.Context, id string, now time.Time) (models.Send, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Send{}, err
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE sends SET views_left = views_left - 1
+	WHERE id = $1 AND views_left > 0 AND expires_at > $2
+	RETURNING id, owner_id, ciphertext, views_left, expires_at, created_at`
+	var send models.Send
+	var expiresAt, createdAt sql.NullInt64
+	err = tx.QueryRowContext(ctx, query, id, toMicros(now)).Scan(&send.ID, &send.OwnerID, &send.Ciphertext,
+		&send.ViewsLeft, &expiresAt, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Send{}, ErrSendNotFound
+		}
+		return models.Send{}, err
+	}
+	send.ExpiresAt = fromMicros(expiresAt)
+	send.CreatedAt = fromMicros(createdAt)
+
+	if send.ViewsLeft == 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sends WHERE id = $1`, id); err != nil {
+			return models.Send{}, err
+		}
+	}
+
+	return send, tx.Commit()
+}
+
+// DeleteExpiredSends removes sends expired at the moment now from the database.
+func (store *SQLiteStore) DeleteExpiredSends(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	res, err := store.db.ExecContext(ctx, `DELETE FROM sends WHERE expires_at <= $1`, toMicros(now))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// MutateSecrets applies mutations to user's personal secrets in one transaction.
+// Every mutation checks ownership and quota before writing, so failed mutations leave no changes.
+func (store *SQLiteStore) MutateSecrets(ctx context.Context, userID string, mutations []models.SecretMutation,
+	quota models.Quota, atomic bool) ([]models.MutationResult, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	usage, err := sqliteUsage(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	results, applied, err := applyMutations(mutations, atomic, func(m models.SecretMutation) (string, error) {
+		return mutateSQLiteSecret(ctx, tx, userID, m, quota, &usage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !applied && atomic {
+		return results, nil
+	}
+
+	return results, tx.Commit()
+}
+
+// mutateSQLiteSecret applies mutation to user's secret inside transaction tx and updates usage.
+func mutateSQLiteSecret(ctx context.Context, tx *sql.Tx, userID string, m models.SecretMutation,
+	quota models.Quota, usage *models.Usage) (string, error) {
+	secret := m.Secret
+	switch m.Op {
+	case models.MutationCreate:
+		if err := checkQuota(quota, *usage, 1, secret.Size(), secret.Size()); err != nil {
+			return "", err
+		}
+
+		if secret.ID == "" {
+			secret.ID = uuid.NewString()
+		}
+		query := `
+		INSERT INTO secrets (id, user_id, data, meta, expires_at, type, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (id) DO NOTHING`
+		res, err := tx.ExecContext(ctx, query, secret.ID, userID, secret.Data, secret.Meta,
+			nullableMicros(secret.ExpiresAt), secret.Type, toMicros(time.Now()))
+		if err != nil {
+			return "", err
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return "", err
+		}
+		if inserted == 0 {
+			return "", ErrSecretExists
+		}
+		if err := saveSearchTokens(ctx, tx, secret.ID, secret.SearchTokens); err != nil {
+			return "", err
+		}
+		usage.Secrets++
+		usage.Bytes += secret.Size()
+		return secret.ID, nil
+
+	case models.MutationUpdate:
+		oldSize, err := personalSecretSize(ctx, tx, userID, secret.ID)
+		if err != nil {
+			return "", err
+		}
+		if err := checkQuota(quota, *usage, 0, secret.Size()-oldSize, secret.Size()); err != nil {
+			return "", err
+		}
+		if err := replaceSQLiteShareCopies(ctx, tx, secret.ID, m.Shares); err != nil {
+			return "", err
+		}
+
+		query := `
+		UPDATE secrets SET data = $1, meta = $2, type = COALESCE(NULLIF($3, ''), type),
+			expires_at = CASE WHEN $4 THEN expires_at ELSE $5 END, updated_at = $6
+		WHERE id = $7`
+		_, err = tx.ExecContext(ctx, query, secret.Data, secret.Meta, secret.Type, m.KeepExpiresAt,
+			nullableMicros(secret.ExpiresAt), toMicros(time.Now()), secret.ID)
+		if err != nil {
+			return "", err
+		}
+		if err := saveSearchTokens(ctx, tx, secret.ID, secret.SearchTokens); err != nil {
+			return "", err
+		}
+		usage.Bytes += secret.Size() - oldSize
+		return secret.ID, nil
+
+	case models.MutationDelete:
+		size, err := personalSecretSize(ctx, tx, userID, secret.ID)
+		if err != nil {
+			return "", err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM secrets WHERE id = $1`, secret.ID); err != nil {
+			return "", err
+		}
+		usage.Secrets--
+		usage.Bytes -= size
+		return secret.ID, nil
+
+	default:
+		return "", ErrInvalidMutation
+	}
+}
+
+// personalSecretSize returns the size of user's personal secret, failing if the user doesn't own it.
+func personalSecretSize(ctx context.Context, tx *sql.Tx, userID, secretID string) (int64, error) {
+	var size int64
+	query := `
+	SELECT ` + sqliteSecretSize + ` FROM secrets
+	WHERE id = $1 AND user_id = $2 AND collection_id IS NULL`
+	if err := tx.QueryRowContext(ctx, query, secretID, userID).Scan(&size); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrSecretNotFound
+		}
+		return 0, err
+	}
+	return size, nil
+}
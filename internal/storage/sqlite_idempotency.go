@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+)
+
+// ReserveIdempotencyKey saves the key of a request in progress unless a live key with the same name exists.
+// Keys expired at the moment now are replaced. Returns the existing key and false if the key is live.
+func (store *SQLiteStore) ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey, now time.Time) (models.IdempotencyKey, bool, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO idempotency_keys (user_id, key, method, request_hash, expires_at) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (user_id, key) DO UPDATE SET method = excluded.method, request_hash = excluded.request_hash,
+		response = NULL, expires_at = excluded.expires_at
+	WHERE idempotency_keys.expires_at <= $6`
+	res, err := store.db.ExecContext(ctx, query, key.UserID, key.Key, key.Method, key.RequestHash,
+		toMicros(key.ExpiresAt), toMicros(now))
+	if err != nil {
+		return models.IdempotencyKey{}, false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return *key, err == nil, err
+	}
+
+	existing := models.IdempotencyKey{UserID: key.UserID, Key: key.Key}
+	var expiresAt sql.NullInt64
+	query = `SELECT method, request_hash, response, expires_at FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+	err = store.db.QueryRowContext(ctx, query, key.UserID, key.Key).Scan(&existing.Method, &existing.RequestHash,
+		&existing.Response, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The request holding the key failed and released it after the insert, report it as in progress.
+		return models.IdempotencyKey{UserID: key.UserID, Key: key.Key, Method: key.Method,
+			RequestHash: key.RequestHash}, false, nil
+	}
+	if err != nil {
+		return models.IdempotencyKey{}, false, err
+	}
+	existing.ExpiresAt = fromMicros(expiresAt)
+
+	return existing, false, nil
+}
+
+// CompleteIdempotencyKey saves the response of the request holding the key and extends the key until expiresAt.
+func (store *SQLiteStore) CompleteIdempotencyKey(ctx context.Context, userID, key string, response []byte, expiresAt time.Time) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `UPDATE idempotency_keys SET response = $3, expires_at = $4 WHERE user_id = $1 AND key = $2`
+	_, err := store.db.ExecContext(ctx, query, userID, key, response, toMicros(expiresAt))
+
+	return err
+}
+
+// ReleaseIdempotencyKey deletes the key of a failed request so that it can be retried.
+// Keys with saved responses are kept.
+func (store *SQLiteStore) ReleaseIdempotencyKey(ctx context.Context, userID, key string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND response IS NULL`
+	_, err := store.db.ExecContext(ctx, query, userID, key)
+
+	return err
+}
+
+// DeleteExpiredIdempotencyKeys removes keys expired at the moment now from the database.
+func (store *SQLiteStore) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	res, err := store.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, toMicros(now))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
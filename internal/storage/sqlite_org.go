@@ -0,0 +1,573 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+)
+
+// SetUserKeys saves users sharing keypair to the database.
+func (store *SQLiteStore) SetUserKeys(ctx context.Context, keys *models.UserKeys) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO user_keys (user_id, public_key, encrypted_private_key) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET public_key = excluded.public_key, encrypted_private_key = excluded.encrypted_private_key`
+	_, err := store.db.ExecContext(ctx, query, keys.UserID, keys.PublicKey, keys.EncryptedPrivateKey)
+
+	return err
+}
+
+// GetUserKeys retrieves users sharing keypair.
+func (store *SQLiteStore) GetUserKeys(ctx context.Context, userID string) (*models.UserKeys, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT user_id, public_key, encrypted_private_key FROM user_keys WHERE user_id = $1`
+	var keys models.UserKeys
+	err := store.db.QueryRowContext(ctx, query, userID).Scan(&keys.UserID, &keys.PublicKey, &keys.EncryptedPrivateKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &keys, nil
+}
+
+// AddShare saves a share of the secret to the database.
+func (store *SQLiteStore) AddShare(ctx context.Context, share *models.Share) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO shares (secret_id, owner_id, recipient_id, wrapped_key, data, meta) VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (secret_id, recipient_id) DO UPDATE
+	SET wrapped_key = excluded.wrapped_key, data = excluded.data, meta = excluded.meta
+	RETURNING id`
+	var id int64
+	err := store.db.QueryRowContext(ctx, query,
+		share.SecretID, share.OwnerID, share.RecipientID, share.WrappedKey, share.Data, share.Meta).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetSharesForRecipient retrives and returns all secrets shared with user.
+func (store *SQLiteStore) GetSharesForRecipient(ctx context.Context, userID string) ([]models.Share, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT s.id, s.secret_id, s.owner_id, u.username, s.recipient_id, s.wrapped_key, s.data, s.meta
+	FROM shares s JOIN users u ON u.uuid = s.owner_id
+	WHERE s.recipient_id = $1 ORDER BY s.id`
+	rows, err := store.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make([]models.Share, 0)
+	for rows.Next() {
+		var share models.Share
+		err := rows.Scan(&share.ID, &share.SecretID, &share.OwnerID, &share.OwnerName,
+			&share.RecipientID, &share.WrappedKey, &share.Data, &share.Meta)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve share", "error", err)
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+// GetSharesForOwner retrives shares of user's secrets without the shared data.
+func (store *SQLiteStore) GetSharesForOwner(ctx context.Context, userID string) ([]models.Share, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT s.id, s.secret_id, s.owner_id, s.recipient_id, u.username
+	FROM shares s JOIN users u ON u.uuid = s.recipient_id
+	WHERE s.owner_id = $1 ORDER BY s.id`
+	rows, err := store.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make([]models.Share, 0)
+	for rows.Next() {
+		var share models.Share
+		err := rows.Scan(&share.ID, &share.SecretID, &share.OwnerID, &share.RecipientID, &share.RecipientName)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve share", "error", err)
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+// DeleteShare removes a share of the secret from the database.
+func (store *SQLiteStore) DeleteShare(ctx context.Context, secretID, recipientID string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	res, err := store.db.ExecContext(ctx, `DELETE FROM shares WHERE secret_id = $1 AND recipient_id = $2`, secretID, recipientID)
+	if err != nil {
+		return err
+	}
+
+	return notFoundIfNone(res, ErrShareNotFound)
+}
+
+// IsSecretSharedWith reports whether the secret is shared with user.
+func (store *SQLiteStore) IsSecretSharedWith(ctx context.Context, secretID, userID string) (bool, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM shares WHERE secret_id = $1 AND recipient_id = $2)`
+	err := store.db.QueryRowContext(ctx, query, secretID, userID).Scan(&exists)
+
+	return exists, err
+}
+
+// CreateOrganization saves a new organization making user its owner.
+func (store *SQLiteStore) CreateOrganization(ctx context.Context, org *models.Organization, ownerID string) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	if err = tx.QueryRowContext(ctx, `INSERT INTO organizations (name) VALUES ($1) RETURNING id`, org.Name).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO org_members (org_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err = tx.ExecContext(ctx, query, id, ownerID, models.RoleOwner); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// GetMemberships retrieves all organizations user is member of.
+func (store *SQLiteStore) GetMemberships(ctx context.Context, userID string) ([]models.Member, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT m.org_id, o.name, m.user_id, m.role
+	FROM org_members m JOIN organizations o ON o.id = m.org_id
+	WHERE m.user_id = $1 ORDER BY m.org_id`
+	rows, err := store.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]models.Member, 0)
+	for rows.Next() {
+		var member models.Member
+		if err := rows.Scan(&member.OrgID, &member.OrgName, &member.UserID, &member.Role); err != nil {
+			logging.Sugar.Errorw("failed to retrieve membership", "error", err)
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// GetMember retrieves user's membership in organization.
+func (store *SQLiteStore) GetMember(ctx context.Context, orgID int64, userID string) (models.Member, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT m.org_id, o.name, m.user_id, u.username, m.role
+	FROM org_members m
+	JOIN organizations o ON o.id = m.org_id
+	JOIN users u ON u.uuid = m.user_id
+	WHERE m.org_id = $1 AND m.user_id = $2`
+	var member models.Member
+	err := store.db.QueryRowContext(ctx, query, orgID, userID).Scan(
+		&member.OrgID, &member.OrgName, &member.UserID, &member.Username, &member.Role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Member{}, ErrMemberNotFound
+		}
+		return models.Member{}, err
+	}
+
+	return member, nil
+}
+
+// GetMembers retrieves all members of organization with their public keys.
+func (store *SQLiteStore) GetMembers(ctx context.Context, orgID int64) ([]models.Member, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT m.org_id, u.username, m.user_id, m.role, COALESCE(k.public_key, '')
+	FROM org_members m
+	JOIN users u ON u.uuid = m.user_id
+	LEFT JOIN user_keys k ON k.user_id = m.user_id
+	WHERE m.org_id = $1 ORDER BY u.username`
+	rows, err := store.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]models.Member, 0)
+	for rows.Next() {
+		var member models.Member
+		if err := rows.Scan(&member.OrgID, &member.Username, &member.UserID, &member.Role, &member.PublicKey); err != nil {
+			logging.Sugar.Errorw("failed to retrieve member", "error", err)
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// SetMemberRole changes member's role in organization.
+// Owners are counted in the same transaction, which holds the write lock, so organization keeps an owner.
+func (store *SQLiteStore) SetMemberRole(ctx context.Context, orgID int64, userID string, role models.Role) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	members, err := sqliteMembers(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if err := checkOwnerLeft(members, userID, role); err != nil {
+		return err
+	}
+
+	query := `UPDATE org_members SET role = $1 WHERE org_id = $2 AND user_id = $3`
+	if _, err = tx.ExecContext(ctx, query, role, orgID, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveMember removes member from organization together with his collection keys.
+// The last owner of organization can't be removed.
+func (store *SQLiteStore) RemoveMember(ctx context.Context, orgID int64, userID string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	members, err := sqliteMembers(ctx, tx, orgID)
+	if err != nil {
+		return err
+	}
+	if err := checkOwnerLeft(members, userID, ""); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM org_members WHERE org_id = $1 AND user_id = $2`, orgID, userID); err != nil {
+		return err
+	}
+
+	query := `
+	DELETE FROM collection_keys
+	WHERE user_id = $1 AND collection_id IN (SELECT id FROM collections WHERE org_id = $2)`
+	if _, err = tx.ExecContext(ctx, query, userID, orgID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddInvitation saves invitation of user to organization with collection keys wrapped for him.
+func (store *SQLiteStore) AddInvitation(ctx context.Context, inv *models.Invitation, keys []models.CollectionKey) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	query := `INSERT INTO org_invitations (org_id, user_id, role, invited_by) VALUES ($1, $2, $3, $4) RETURNING id`
+	err = tx.QueryRowContext(ctx, query, inv.OrgID, inv.UserID, inv.Role, inv.InvitedBy).Scan(&id)
+	if err != nil {
+		if isSQLiteUniqueViolation(err) {
+			return 0, ErrAlreadyExists
+		}
+		return 0, err
+	}
+
+	if err = saveSQLiteCollectionKeys(ctx, tx, keys); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// GetInvitations retrieves pending invitations of user.
+func (store *SQLiteStore) GetInvitations(ctx context.Context, userID string) ([]models.Invitation, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT i.id, i.org_id, o.name, i.user_id, i.role, u.username
+	FROM org_invitations i
+	JOIN organizations o ON o.id = i.org_id
+	JOIN users u ON u.uuid = i.invited_by
+	WHERE i.user_id = $1 ORDER BY i.id`
+	rows, err := store.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := make([]models.Invitation, 0)
+	for rows.Next() {
+		var inv models.Invitation
+		if err := rows.Scan(&inv.ID, &inv.OrgID, &inv.OrgName, &inv.UserID, &inv.Role, &inv.InvitedBy); err != nil {
+			logging.Sugar.Errorw("failed to retrieve invitation", "error", err)
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+
+	return invitations, rows.Err()
+}
+
+// AcceptInvitation makes invited user a member of organization and removes the invitation.
+func (store *SQLiteStore) AcceptInvitation(ctx context.Context, invitationID int64, userID string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var orgID int64
+	var role models.Role
+	query := `DELETE FROM org_invitations WHERE id = $1 AND user_id = $2 RETURNING org_id, role`
+	if err = tx.QueryRowContext(ctx, query, invitationID, userID).Scan(&orgID, &role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+
+	query = `INSERT INTO org_members (org_id, user_id, role) VALUES ($1, $2, $3)`
+	if _, err = tx.ExecContext(ctx, query, orgID, userID, role); err != nil {
+		if isSQLiteUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateCollection saves a new collection of organization with its key wrapped for members.
+func (store *SQLiteStore) CreateCollection(ctx context.Context, collection *models.Collection, keys []models.CollectionKey) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	query := `INSERT INTO collections (org_id, name) VALUES ($1, $2) RETURNING id`
+	if err = tx.QueryRowContext(ctx, query, collection.OrgID, collection.Name).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	for i := range keys {
+		keys[i].CollectionID = id
+	}
+	if err = saveSQLiteCollectionKeys(ctx, tx, keys); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// saveSQLiteCollectionKeys saves wrapped collection keys replacing existing ones.
+func saveSQLiteCollectionKeys(ctx context.Context, tx *sql.Tx, keys []models.CollectionKey) error {
+	query := `
+	INSERT INTO collection_keys (collection_id, user_id, wrapped_key) VALUES ($1, $2, $3)
+	ON CONFLICT (collection_id, user_id) DO UPDATE SET wrapped_key = excluded.wrapped_key`
+	for _, key := range keys {
+		if _, err := tx.ExecContext(ctx, query, key.CollectionID, key.UserID, key.WrappedKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetCollection retrieves collection by its ID.
+func (store *SQLiteStore) GetCollection(ctx context.Context, collectionID int64) (models.Collection, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT id, org_id, name FROM collections WHERE id = $1`
+	var collection models.Collection
+	err := store.db.QueryRowContext(ctx, query, collectionID).Scan(&collection.ID, &collection.OrgID, &collection.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Collection{}, ErrCollectionNotFound
+		}
+		return models.Collection{}, err
+	}
+
+	return collection, nil
+}
+
+// GetCollectionKeys retrieves collection keys wrapped for user.
+// Keys of all organizations are returned if orgID is 0.
+func (store *SQLiteStore) GetCollectionKeys(ctx context.Context, userID string, orgID int64) ([]models.CollectionKey, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT k.collection_id, c.name, c.org_id, k.user_id, k.wrapped_key
+	FROM collection_keys k JOIN collections c ON c.id = k.collection_id
+	WHERE k.user_id = $1 AND ($2 = 0 OR c.org_id = $2) ORDER BY k.collection_id`
+	rows, err := store.db.QueryContext(ctx, query, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.CollectionKey, 0)
+	for rows.Next() {
+		var key models.CollectionKey
+		if err := rows.Scan(&key.CollectionID, &key.CollectionName, &key.OrgID, &key.UserID, &key.WrappedKey); err != nil {
+			logging.Sugar.Errorw("failed to retrieve collection key", "error", err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// GetCollectionSecrets retrieves all secrets of collection.
+func (store *SQLiteStore) GetCollectionSecrets(ctx context.Context, collectionID int64) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	// Negative limit means no limit in SQLite.
+	return store.collectionSecretsPage(ctx, collectionID, "", -1)
+}
+
+// ScanCollectionSecrets calls fn for every unexpired secret of the collection in ID order,
+// stopping on the first error. Secrets are read by keyset pages like ScanSecrets does.
+func (store *SQLiteStore) ScanCollectionSecrets(ctx context.Context, collectionID int64, fn func(secret models.Secret) error) error {
+	var afterID string
+	for {
+		pageCtx, cancel := store.queryContext(ctx)
+		secrets, err := store.collectionSecretsPage(pageCtx, collectionID, afterID, scanBatchSize)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, secret := range secrets {
+			if err := fn(secret); err != nil {
+				return err
+			}
+		}
+		if len(secrets) < scanBatchSize {
+			return nil
+		}
+		afterID = secrets[len(secrets)-1].ID
+	}
+}
+
+// collectionSecretsPage returns up to limit unexpired secrets of the collection with IDs greater than afterID.
+func (store *SQLiteStore) collectionSecretsPage(ctx context.Context, collectionID int64, afterID string, limit int) ([]models.Secret, error) {
+	query := `
+	SELECT id, user_id, data, meta, collection_id, expires_at FROM secrets
+	WHERE collection_id = $1 AND (expires_at IS NULL OR expires_at > $2) AND id > $3 ORDER BY id LIMIT $4`
+	rows, err := store.db.QueryContext(ctx, query, collectionID, toMicros(time.Now()), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]models.Secret, 0)
+	for rows.Next() {
+		var secret models.Secret
+		var expiresAt sql.NullInt64
+		err := rows.Scan(&secret.ID, &secret.UserID, &secret.Data, &secret.Meta, &secret.CollectionID, &expiresAt)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve secret", "error", err)
+			return nil, err
+		}
+		secret.ExpiresAt = fromMicros(expiresAt)
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, rows.Err()
+}
+
+// sqliteMembers returns roles of organization members read inside transaction tx.
+// Transactions begin with the write lock (_txlock=immediate), so the roles can't change until tx ends.
+func sqliteMembers(ctx context.Context, tx *sql.Tx, orgID int64) (map[string]models.Role, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT user_id, role FROM org_members WHERE org_id = $1`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make(map[string]models.Role)
+	for rows.Next() {
+		var userID string
+		var role models.Role
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		members[userID] = role
+	}
+
+	return members, rows.Err()
+}
@@ -2,89 +2,176 @@ package storage
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"fmt"
+	"time"
 
 	"github.com/KirillZiborov/GophKeeper/internal/logging"
 	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // ErrNotFound is returned when there is no data found.
-var ErrNotFound = errors.New("user not found")
+var ErrNotFound = NewError(KindNotFound, "user not found")
+
+// ErrSecretNotFound is returned when there is no secret with requested id.
+var ErrSecretNotFound = NewError(KindNotFound, "secret not found")
 
 // ErrAlreadyExists is returned when the data already exists.
-var ErrAlreadyExists = errors.New("user already exists")
+var ErrAlreadyExists = NewError(KindAlreadyExists, "user already exists")
+
+// ErrSecretExists is returned when a secret with the client-generated id already exists.
+var ErrSecretExists = NewError(KindAlreadyExists, "secret already exists")
 
 // Storage defines interface for using PostgreSQL database.
+// Every method stops and returns the context error when ctx is cancelled or its deadline is exceeded.
 type Storage interface {
 	// Register a new user.
-	RegisterUser(user *models.User) error
+	RegisterUser(ctx context.Context, user *models.User) error
 	// Authentificate user by his username and password. Returns User structure.
-	GetUser(username string) (models.User, error)
-	// Add new secret data for user with userID.
-	AddSecret(secret *models.Secret) (int64, error)
-	// Edit an existing secret data by his ID.
-	EditSecret(secret *models.Secret) error
+	GetUser(ctx context.Context, username string) (models.User, error)
+	// Returns User structure by user's ID.
+	GetUserByID(ctx context.Context, userID string) (models.User, error)
+	// Delete user with all his data.
+	DeleteUser(ctx context.Context, userID string) error
+	// Add new secret data for user with userID if it fits user's quota.
+	// A random UUID is generated if the secret has no ID. Returns the ID of the secret.
+	AddSecret(ctx context.Context, secret *models.Secret, quota models.Quota) (string, error)
+	// Edit an existing secret data by his ID if it fits owner's quota.
+	// Copies of the secret shared with other users are replaced by shares in the same transaction,
+	// the edit fails with ErrSharesOutdated unless shares have a copy for every recipient.
+	EditSecret(ctx context.Context, secret *models.Secret, shares []models.Share, quota models.Quota) error
+	// Apply mutations to user's personal secrets in one transaction, checking the quota after every one.
+	// Atomic batch is committed only if every mutation succeeds. Returns per-mutation results.
+	// Updates replace the copies of shared secrets as EditSecret does.
+	MutateSecrets(ctx context.Context, userID string, mutations []models.SecretMutation, quota models.Quota, atomic bool) ([]models.MutationResult, error)
+	// Returns user's storage usage.
+	GetUsage(ctx context.Context, userID string) (models.Usage, error)
+	// Delete secrets expired at the moment now. Returns deleted secrets without data.
+	DeleteExpiredSecrets(ctx context.Context, now time.Time) ([]models.Secret, error)
+
+	// Save a new send if its ciphertext fits the owner's quota of total size.
+	AddSend(ctx context.Context, send *models.Send, quota models.Quota) error
+	// Atomically consume one view of a send not expired at the moment now, deleting it after the last view.
+	OpenSend(ctx context.Context, id string, now time.Time) (models.Send, error)
+	// Delete sends expired at the moment now. Returns the number of deleted sends.
+	DeleteExpiredSends(ctx context.Context, now time.Time) (int64, error)
 	// Returns a list of users secret data.
-	GetSecrets(userID string) ([]models.Secret, error)
+	GetSecrets(ctx context.Context, userID string) ([]models.Secret, error)
 	// Returns a secret by its ID.
-	GetSecretByID(secretID int64) (*models.Secret, error)
-}
-
-// CreateURLTable initializes the 'users' table in the PostgreSQL database if it does not already exist
-// as well as the 'secret'database.
-// It defines the schema for storing users and their secrets.
-//
-// Parameters:
-// - ctx: The context for managing cancellation and timeouts.
-// - db: The PostgreSQL connection pool.
-//
-// Returns:
-// - An error if the table creation fails; otherwise, nil.
-func CreateTables(ctx context.Context, db *pgxpool.Pool) error {
-	query := `
-    CREATE TABLE IF NOT EXISTS users (
-    		uuid UUID PRIMARY KEY,
-    		username TEXT NOT NULL UNIQUE,
-    		password TEXT NOT NULL)`
-	_, err := db.Exec(ctx, query)
-	if err != nil {
-		return fmt.Errorf("unable to create table: %w", err)
-	}
+	GetSecretByID(ctx context.Context, secretID string) (*models.Secret, error)
+	// Returns a secret by the integer ID it had before secret IDs became UUIDs.
+	GetSecretByLegacyID(ctx context.Context, legacyID int64) (*models.Secret, error)
+	// Returns user's secrets having all the blind index tokens.
+	SearchSecrets(ctx context.Context, userID string, tokens []string) ([]models.Secret, error)
+	// Returns a page of user's secrets selected by filter and the total number of secrets matching it.
+	ListSecrets(ctx context.Context, userID string, filter models.SecretFilter) ([]models.Secret, int64, error)
+	// Call fn for every user's secret selected by filter in ID order, stopping on the first error.
+	ScanSecrets(ctx context.Context, userID string, filter models.SecretFilter, fn func(secret models.Secret) error) error
 
-	query = `
-    CREATE TABLE IF NOT EXISTS secrets (
-			id SERIAL PRIMARY KEY,
-			user_id UUID NOT NULL REFERENCES users(uuid) ON DELETE CASCADE,
-			data TEXT NOT NULL,
-			meta TEXT
-		)`
-	_, err = db.Exec(ctx, query)
-	if err != nil {
-		return fmt.Errorf("unable to create table: %w", err)
-	}
-	return nil
+	// Save the key of a request in progress unless a key not expired at the moment now exists.
+	// Returns the existing key and false if the key is already used.
+	ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey, now time.Time) (models.IdempotencyKey, bool, error)
+	// Save the response of the request holding the key and keep the key until expiresAt.
+	CompleteIdempotencyKey(ctx context.Context, userID, key string, response []byte, expiresAt time.Time) error
+	// Delete the key of a failed request unless its response is saved.
+	ReleaseIdempotencyKey(ctx context.Context, userID, key string) error
+	// Delete idempotency keys expired at the moment now. Returns the number of deleted keys.
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+
+	// Save user's sharing keypair, replacing the previous one.
+	SetUserKeys(ctx context.Context, keys *models.UserKeys) error
+	// Returns user's sharing keypair.
+	GetUserKeys(ctx context.Context, userID string) (*models.UserKeys, error)
+	// Share a secret with recipient, replacing the previous share of the secret with him.
+	AddShare(ctx context.Context, share *models.Share) (int64, error)
+	// Returns a list of secrets shared with user.
+	GetSharesForRecipient(ctx context.Context, userID string) ([]models.Share, error)
+	// Returns shares of user's secrets with recipients' names, without the shared data.
+	GetSharesForOwner(ctx context.Context, userID string) ([]models.Share, error)
+	// Revoke a share of the secret from recipient.
+	DeleteShare(ctx context.Context, secretID, recipientID string) error
+	// Reports whether the secret is shared with user.
+	IsSecretSharedWith(ctx context.Context, secretID, userID string) (bool, error)
+
+	// Create organization with user as its owner.
+	CreateOrganization(ctx context.Context, org *models.Organization, ownerID string) (int64, error)
+	// Returns memberships of user in organizations.
+	GetMemberships(ctx context.Context, userID string) ([]models.Member, error)
+	// Returns member of organization by user's ID.
+	GetMember(ctx context.Context, orgID int64, userID string) (models.Member, error)
+	// Returns all members of organization.
+	GetMembers(ctx context.Context, orgID int64) ([]models.Member, error)
+	// Change member's role in organization. The last owner can't be demoted.
+	SetMemberRole(ctx context.Context, orgID int64, userID string, role models.Role) error
+	// Remove member with his collection keys from organization. The last owner can't be removed.
+	RemoveMember(ctx context.Context, orgID int64, userID string) error
+	// Invite user to organization saving collection keys wrapped for him.
+	AddInvitation(ctx context.Context, inv *models.Invitation, keys []models.CollectionKey) (int64, error)
+	// Returns pending invitations of user.
+	GetInvitations(ctx context.Context, userID string) ([]models.Invitation, error)
+	// Accept invitation making user a member of organization.
+	AcceptInvitation(ctx context.Context, invitationID int64, userID string) error
+	// Create collection saving its key wrapped for members.
+	CreateCollection(ctx context.Context, collection *models.Collection, keys []models.CollectionKey) (int64, error)
+	// Returns collection by its ID.
+	GetCollection(ctx context.Context, collectionID int64) (models.Collection, error)
+	// Returns collection keys wrapped for user, of all organizations if orgID is 0.
+	GetCollectionKeys(ctx context.Context, userID string, orgID int64) ([]models.CollectionKey, error)
+	// Returns a list of collection secrets.
+	GetCollectionSecrets(ctx context.Context, collectionID int64) ([]models.Secret, error)
+	// Call fn for every unexpired secret of collection in ID order, stopping on the first error.
+	ScanCollectionSecrets(ctx context.Context, collectionID int64, fn func(secret models.Secret) error) error
+
+	// Append event to the audit log chaining it to the last event by hash.
+	AddAuditEvent(ctx context.Context, event *models.AuditEvent) error
+	// Call fn for every audit event in the order of the chain, stopping on the first error.
+	ScanAuditEvents(ctx context.Context, fn func(event models.AuditEvent) error) error
+	// Save signed checkpoint of the audit log.
+	AddAuditCheckpoint(ctx context.Context, cp *models.AuditCheckpoint) error
+	// Returns all audit log checkpoints ordered by event ID.
+	GetAuditCheckpoints(ctx context.Context) ([]models.AuditCheckpoint, error)
+	// Returns user's audit events created in [from, to), zero time means no bound, limit <= 0 means no limit.
+	GetAuditEvents(ctx context.Context, userID string, from, to time.Time, limit int) ([]models.AuditEvent, error)
 }
 
 // DBStore represents a database store for URL records.
 // It encapsulates the PostgreSQL connection pool to perform database operations.
 type DBStore struct {
-	db *pgxpool.Pool
+	db           *pgxpool.Pool
+	queryTimeout time.Duration
 }
 
 // NewDBStore initializes and returns a pointer to a new instance of DBStore.
-func NewDBStore(db *pgxpool.Pool) *DBStore {
-	return &DBStore{db: db}
+// Every query is cancelled after queryTimeout, zero means no limit besides the caller's context.
+func NewDBStore(db *pgxpool.Pool, queryTimeout time.Duration) *DBStore {
+	return &DBStore{db: db, queryTimeout: queryTimeout}
+}
+
+// queryContext derives the context of a single query from the caller's context.
+func (store *DBStore) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withQueryTimeout(ctx, store.queryTimeout)
+}
+
+// withQueryTimeout limits ctx by timeout if it is positive.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 // RegisterUser adds a new user to the database.
-func (store *DBStore) RegisterUser(user *models.User) error {
+func (store *DBStore) RegisterUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`
 
-	err := store.db.QueryRow(context.Background(), query, user.Username).Scan(&exists)
+	err := store.db.QueryRow(ctx, query, user.Username).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -92,9 +179,12 @@ func (store *DBStore) RegisterUser(user *models.User) error {
 		return ErrAlreadyExists
 	}
 
+	// A concurrent registration of the same username can pass the check above.
 	query = `INSERT INTO users (uuid, username, password) VALUES ($1, $2, $3)`
-	_, err = store.db.Exec(context.Background(), query, user.ID, user.Username, user.Password)
-
+	_, err = store.db.Exec(ctx, query, user.ID, user.Username, user.Password)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
 	if err != nil {
 		return err
 	}
@@ -103,13 +193,16 @@ func (store *DBStore) RegisterUser(user *models.User) error {
 }
 
 // GetUser retrieves users data by his username.
-func (store *DBStore) GetUser(username string) (models.User, error) {
+func (store *DBStore) GetUser(ctx context.Context, username string) (models.User, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
 	var user models.User
 	query := `SELECT * FROM users WHERE username=$1`
-	err := store.db.QueryRow(context.Background(), query, username).Scan(&user.ID, &user.Username, &user.Password)
+	err := store.db.QueryRow(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password)
 
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return models.User{}, ErrNotFound
 		}
 		return models.User{}, err
@@ -118,50 +211,209 @@ func (store *DBStore) GetUser(username string) (models.User, error) {
 	return user, nil
 }
 
+// GetUserByID retrieves users data by his ID.
+func (store *DBStore) GetUserByID(ctx context.Context, userID string) (models.User, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	var user models.User
+	query := `SELECT uuid, username, password FROM users WHERE uuid=$1`
+	err := store.db.QueryRow(ctx, query, userID).Scan(&user.ID, &user.Username, &user.Password)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, ErrNotFound
+		}
+		return models.User{}, err
+	}
+
+	return user, nil
+}
+
+// DeleteUser removes the user from the database.
+// Users secrets are removed by ON DELETE CASCADE. Collection secrets created by the user stay in their
+// collections and pass to an owner of the organization, organizations of the user alone are removed.
+// The user can't be deleted while being the last owner of an organization with other members.
+func (store *DBStore) DeleteUser(ctx context.Context, userID string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Organizations are locked in ID order so that concurrent deletions don't deadlock.
+	rows, err := tx.Query(ctx, `SELECT org_id FROM org_members WHERE user_id = $1 ORDER BY org_id`, userID)
+	if err != nil {
+		return err
+	}
+	orgIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return err
+	}
+	for _, orgID := range orgIDs {
+		members, err := lockMembers(ctx, tx, orgID)
+		if err != nil {
+			return err
+		}
+		if len(members) == 1 {
+			if _, err := tx.Exec(ctx, `DELETE FROM organizations WHERE id = $1`, orgID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := checkOwnerLeft(members, userID, ""); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, reassignCollectionSecretsQuery, userID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM users WHERE uuid = $1`, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit(ctx)
+}
+
 // AddSecret saves users secret to the database.
-func (store *DBStore) AddSecret(secret *models.Secret) (int64, error) {
-	query := `INSERT INTO secrets (user_id, data, meta) VALUES ($1, $2, $3) RETURNING id`
-	var id int64
-	err := store.db.QueryRow(context.Background(), query, secret.UserID, secret.Data, secret.Meta).Scan(&id)
+// Quota check and insert are done in one transaction holding a lock on the user.
+func (store *DBStore) AddSecret(ctx context.Context, secret *models.Secret, quota models.Quota) (string, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
 
+	tx, err := store.db.Begin(ctx)
 	if err != nil {
-		return 0, err
+		return "", err
 	}
+	defer tx.Rollback(ctx)
 
-	return id, nil
+	usage, err := lockUsage(ctx, tx, secret.UserID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkQuota(quota, usage, 1, secret.Size(), secret.Size()); err != nil {
+		return "", err
+	}
+
+	if secret.ID == "" {
+		secret.ID = uuid.NewString()
+	}
+	query := `
+	INSERT INTO secrets (id, user_id, data, meta, collection_id, expires_at, search_tokens, type, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())`
+	_, err = tx.Exec(ctx, query, secret.ID, secret.UserID, secret.Data, secret.Meta,
+		nullableID(secret.CollectionID), nullableTime(secret.ExpiresAt), searchTokens(secret.SearchTokens),
+		secret.Type)
+
+	if isUniqueViolation(err) {
+		return "", ErrSecretExists
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return secret.ID, tx.Commit(ctx)
 }
 
 // EditSecret updates users secret in the database.
-func (store *DBStore) EditSecret(secret *models.Secret) error {
-	query := `UPDATE secrets SET data = $1, meta = $2 WHERE id = $3`
-	_, err := store.db.Exec(context.Background(), query, secret.Data, secret.Meta, secret.ID)
+// Quota check, update and replacement of the shared copies are done in one transaction holding locks on the secret and its owner.
+func (store *DBStore) EditSecret(ctx context.Context, secret *models.Secret, shares []models.Share, quota models.Quota) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
 
+	tx, err := store.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	return nil
+	usage, err := lockUsage(ctx, tx, secret.UserID)
+	if err != nil {
+		return err
+	}
+
+	var oldSize int64
+	// The secret row is locked, so it can't be shared while its shared copies are replaced.
+	query := `SELECT ` + secretSize + ` FROM secrets WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, secret.ID).Scan(&oldSize); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSecretNotFound
+		}
+		return err
+	}
+	if err := checkQuota(quota, usage, 0, secret.Size()-oldSize, secret.Size()); err != nil {
+		return err
+	}
+
+	if err := replaceShareCopies(ctx, tx, secret.ID, shares); err != nil {
+		return err
+	}
+
+	query = `
+	UPDATE secrets SET data = $1, meta = $2, expires_at = $3, search_tokens = $4, type = $5, updated_at = now()
+	WHERE id = $6`
+	_, err = tx.Exec(ctx, query, secret.Data, secret.Meta, nullableTime(secret.ExpiresAt),
+		searchTokens(secret.SearchTokens), secret.Type, secret.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetSecretByID retrieves a secret by its ID.
+func (store *DBStore) GetSecretByID(ctx context.Context, secretID string) (*models.Secret, error) {
+	return store.getSecret(ctx, `id = $1`, secretID)
 }
 
-func (store *DBStore) GetSecretByID(secretID int64) (*models.Secret, error) {
-	query := "SELECT id, user_id, data, meta FROM secrets WHERE id = $1"
+// GetSecretByLegacyID retrieves a secret by the integer ID it had before secret IDs became UUIDs.
+func (store *DBStore) GetSecretByLegacyID(ctx context.Context, legacyID int64) (*models.Secret, error) {
+	return store.getSecret(ctx, `legacy_id = $1`, legacyID)
+}
+
+// getSecret retrieves a secret selected by the condition on its ID.
+func (store *DBStore) getSecret(ctx context.Context, cond string, id any) (*models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT id, COALESCE(legacy_id, 0), user_id, data, meta, COALESCE(collection_id, 0), expires_at, type, updated_at
+	FROM secrets WHERE ` + cond
 	var secret models.Secret
-	err := store.db.QueryRow(context.Background(), query, secretID).Scan(&secret.ID, &secret.UserID, &secret.Data, &secret.Meta)
+	var expiresAt *time.Time
+	err := store.db.QueryRow(ctx, query, id).Scan(
+		&secret.ID, &secret.LegacyID, &secret.UserID, &secret.Data, &secret.Meta, &secret.CollectionID, &expiresAt,
+		&secret.Type, &secret.UpdatedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSecretNotFound
+		}
 		return nil, err
 	}
+	secret.ExpiresAt = timeOrZero(expiresAt)
 	return &secret, nil
 }
 
 // GetSecrets retrives and returns all users credentials.
-func (store *DBStore) GetSecrets(userID string) ([]models.Secret, error) {
-	query := `SELECT id, user_id, data, meta FROM secrets WHERE user_id=$1`
-	rows, err := store.db.Query(context.Background(), query, userID)
+// Secrets of organization collections and expired secrets waiting for deletion are not included.
+func (store *DBStore) GetSecrets(ctx context.Context, userID string) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
 
+	query := `
+	SELECT id, user_id, data, meta, expires_at, type, updated_at FROM secrets
+	WHERE user_id=$1 AND collection_id IS NULL AND (expires_at IS NULL OR expires_at > now())`
+	rows, err := store.db.Query(ctx, query, userID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrNotFound
-		}
 		return nil, err
 	}
 	defer rows.Close()
@@ -169,11 +421,13 @@ func (store *DBStore) GetSecrets(userID string) ([]models.Secret, error) {
 	secret := make([]models.Secret, 0)
 	for rows.Next() {
 		var cred models.Secret
-		err := rows.Scan(&cred.ID, &cred.UserID, &cred.Data, &cred.Meta)
+		var expiresAt *time.Time
+		err := rows.Scan(&cred.ID, &cred.UserID, &cred.Data, &cred.Meta, &expiresAt, &cred.Type, &cred.UpdatedAt)
 		if err != nil {
 			logging.Sugar.Errorw("failed to retrieve secret", "error", err)
 			return nil, err
 		}
+		cred.ExpiresAt = timeOrZero(expiresAt)
 		secret = append(secret, cred)
 	}
 
@@ -184,3 +438,227 @@ func (store *DBStore) GetSecrets(userID string) ([]models.Secret, error) {
 
 	return secret, nil
 }
+
+// DeleteExpiredSecrets removes secrets expired at the moment now from the database.
+func (store *DBStore) DeleteExpiredSecrets(ctx context.Context, now time.Time) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	DELETE FROM secrets WHERE expires_at <= $1
+	RETURNING id, user_id, COALESCE(collection_id, 0), expires_at`
+	rows, err := store.db.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deleted := make([]models.Secret, 0)
+	for rows.Next() {
+		var secret models.Secret
+		err := rows.Scan(&secret.ID, &secret.UserID, &secret.CollectionID, &secret.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, secret)
+	}
+
+	return deleted, rows.Err()
+}
+
+// nullableTime converts zero time to NULL.
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// timeOrZero converts NULL time to zero time.
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// nullableID converts zero id to NULL.
+func nullableID(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// searchTokens converts nil tokens to an empty array for the NOT NULL column.
+func searchTokens(tokens []string) []string {
+	if tokens == nil {
+		return []string{}
+	}
+	return tokens
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// SetUserKeys saves users sharing keypair to the database.
+func (store *DBStore) SetUserKeys(ctx context.Context, keys *models.UserKeys) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO user_keys (user_id, public_key, encrypted_private_key) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET public_key = EXCLUDED.public_key, encrypted_private_key = EXCLUDED.encrypted_private_key`
+	_, err := store.db.Exec(ctx, query, keys.UserID, keys.PublicKey, keys.EncryptedPrivateKey)
+
+	return err
+}
+
+// GetUserKeys retrieves users sharing keypair.
+func (store *DBStore) GetUserKeys(ctx context.Context, userID string) (*models.UserKeys, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `SELECT user_id, public_key, encrypted_private_key FROM user_keys WHERE user_id = $1`
+	var keys models.UserKeys
+	err := store.db.QueryRow(ctx, query, userID).Scan(&keys.UserID, &keys.PublicKey, &keys.EncryptedPrivateKey)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &keys, nil
+}
+
+// AddShare saves a share of the secret to the database.
+func (store *DBStore) AddShare(ctx context.Context, share *models.Share) (int64, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// The secret row is locked, so the share isn't added while the shared copies of the secret are replaced.
+	err = tx.QueryRow(ctx, `SELECT 1 FROM secrets WHERE id = $1 FOR UPDATE`, share.SecretID).Scan(new(int))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrSecretNotFound
+		}
+		return 0, err
+	}
+
+	query := `
+	INSERT INTO shares (secret_id, owner_id, recipient_id, wrapped_key, data, meta) VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (secret_id, recipient_id) DO UPDATE
+	SET wrapped_key = EXCLUDED.wrapped_key, data = EXCLUDED.data, meta = EXCLUDED.meta
+	RETURNING id`
+	var id int64
+	err = tx.QueryRow(ctx, query,
+		share.SecretID, share.OwnerID, share.RecipientID, share.WrappedKey, share.Data, share.Meta).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// GetSharesForRecipient retrives and returns all secrets shared with user.
+func (store *DBStore) GetSharesForRecipient(ctx context.Context, userID string) ([]models.Share, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT s.id, s.secret_id, s.owner_id, u.username, s.recipient_id, s.wrapped_key, s.data, s.meta
+	FROM shares s JOIN users u ON u.uuid = s.owner_id
+	WHERE s.recipient_id = $1 ORDER BY s.id`
+	rows, err := store.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make([]models.Share, 0)
+	for rows.Next() {
+		var share models.Share
+		err := rows.Scan(&share.ID, &share.SecretID, &share.OwnerID, &share.OwnerName,
+			&share.RecipientID, &share.WrappedKey, &share.Data, &share.Meta)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve share", "error", err)
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	if err = rows.Err(); err != nil {
+		logging.Sugar.Errorw("failed to retrieve share", "error", err)
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+// GetSharesForOwner retrives shares of user's secrets without the shared data.
+func (store *DBStore) GetSharesForOwner(ctx context.Context, userID string) ([]models.Share, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `
+	SELECT s.id, s.secret_id, s.owner_id, s.recipient_id, u.username
+	FROM shares s JOIN users u ON u.uuid = s.recipient_id
+	WHERE s.owner_id = $1 ORDER BY s.id`
+	rows, err := store.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make([]models.Share, 0)
+	for rows.Next() {
+		var share models.Share
+		err := rows.Scan(&share.ID, &share.SecretID, &share.OwnerID, &share.RecipientID, &share.RecipientName)
+		if err != nil {
+			logging.Sugar.Errorw("failed to retrieve share", "error", err)
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+// DeleteShare removes a share of the secret from the database.
+func (store *DBStore) DeleteShare(ctx context.Context, secretID, recipientID string) error {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	query := `DELETE FROM shares WHERE secret_id = $1 AND recipient_id = $2`
+	tag, err := store.db.Exec(ctx, query, secretID, recipientID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrShareNotFound
+	}
+
+	return nil
+}
+
+// IsSecretSharedWith reports whether the secret is shared with user.
+func (store *DBStore) IsSecretSharedWith(ctx context.Context, secretID, userID string) (bool, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM shares WHERE secret_id = $1 AND recipient_id = $2)`
+	err := store.db.QueryRow(ctx, query, secretID, userID).Scan(&exists)
+
+	return exists, err
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// scanBatchSize is the number of rows fetched from the cursor at once.
+const scanBatchSize = 100
+
+// ScanSecrets calls fn for every user's personal secret selected by filter in ID order,
+// stopping on the first error. Rows are fetched from a server-side cursor in small batches,
+// so neither the database driver nor the caller hold the whole vault in memory.
+// The query timeout limits every batch rather than the whole scan, which lasts as long as ctx.
+// Page position and limit of the filter are ignored.
+func (store *DBStore) ScanSecrets(ctx context.Context, userID string, filter models.SecretFilter, fn func(secret models.Secret) error) error {
+	conditions, args := secretConditions(userID, filter)
+	query := fmt.Sprintf(`SELECT %s FROM secrets WHERE %s ORDER BY id`, listedSecretColumns, strings.Join(conditions, " AND "))
+
+	return store.scanCursor(ctx, query, args, scanListedSecret, fn)
+}
+
+// ScanCollectionSecrets calls fn for every unexpired secret of the collection in ID order,
+// stopping on the first error. Rows are fetched from a server-side cursor like ScanSecrets does.
+func (store *DBStore) ScanCollectionSecrets(ctx context.Context, collectionID int64, fn func(secret models.Secret) error) error {
+	query := `
+	SELECT id, user_id, data, meta, collection_id, expires_at FROM secrets
+	WHERE collection_id = $1 AND (expires_at IS NULL OR expires_at > now()) ORDER BY id`
+
+	return store.scanCursor(ctx, query, []any{collectionID}, scanCollectionSecret, fn)
+}
+
+// scanCollectionSecret scans a row of a collection secret.
+func scanCollectionSecret(row pgx.Row) (models.Secret, error) {
+	var secret models.Secret
+	var expiresAt *time.Time
+	if err := row.Scan(&secret.ID, &secret.UserID, &secret.Data, &secret.Meta, &secret.CollectionID, &expiresAt); err != nil {
+		return models.Secret{}, err
+	}
+	secret.ExpiresAt = timeOrZero(expiresAt)
+
+	return secret, nil
+}
+
+// scanCursor declares a cursor for the query and calls fn for every secret read from it by scan.
+func (store *DBStore) scanCursor(ctx context.Context, query string, args []any, scan func(row pgx.Row) (models.Secret, error), fn func(secret models.Secret) error) error {
+	// Cursors live inside a transaction only.
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	declareCtx, cancel := store.queryContext(ctx)
+	_, err = tx.Exec(declareCtx, `DECLARE secrets_cursor NO SCROLL CURSOR FOR `+query, args...)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for {
+		secrets, err := store.fetchSecrets(ctx, tx, scan)
+		if err != nil {
+			return err
+		}
+		for _, secret := range secrets {
+			if err := fn(secret); err != nil {
+				return err
+			}
+		}
+
+		if len(secrets) < scanBatchSize {
+			return tx.Commit(ctx)
+		}
+	}
+}
+
+// fetchSecrets fetches the next batch of secrets from the cursor declared by scanCursor.
+func (store *DBStore) fetchSecrets(ctx context.Context, tx pgx.Tx, scan func(row pgx.Row) (models.Secret, error)) ([]models.Secret, error) {
+	ctx, cancel := store.queryContext(ctx)
+	defer cancel()
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`FETCH %d FROM secrets_cursor`, scanBatchSize))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	secrets := make([]models.Secret, 0, scanBatchSize)
+	for rows.Next() {
+		secret, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, rows.Err()
+}
+
+// ScanSecrets calls fn for every user's personal secret selected by filter in ID order.
+func (fs *FakeStorage) ScanSecrets(ctx context.Context, userID string, filter models.SecretFilter, fn func(secret models.Secret) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	secrets := make([]models.Secret, 0)
+	for _, secret := range fs.secrets[userID] {
+		if matchesFilter(secret, filter) {
+			secrets = append(secrets, *secret)
+		}
+	}
+	fs.mu.Unlock()
+
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].ID < secrets[j].ID })
+	for _, secret := range secrets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanCollectionSecrets calls fn for every unexpired secret of the collection in ID order.
+func (fs *FakeStorage) ScanCollectionSecrets(ctx context.Context, collectionID int64, fn func(secret models.Secret) error) error {
+	secrets, err := fs.GetCollectionSecrets(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range secrets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
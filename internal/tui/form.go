@@ -0,0 +1,272 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/pkg/otp"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/uuid"
+)
+
+// fileField is the form field with the path of the file saved as a binary secret.
+const fileField = "file"
+
+// labels are the names of form fields shown to the user.
+var labels = map[string]string{
+	"number":          "Card number",
+	"date":            "Expires MM/YY",
+	"holder":          "Holder",
+	"code":            "Code",
+	"login":           "Login",
+	"password":        "Password",
+	"text":            "Text",
+	"otp":             "URI or seed",
+	fileField:         "File",
+	vault.FieldFolder: "Folder",
+	vault.FieldTags:   "Tags",
+	vault.FieldNote:   "Note",
+}
+
+// sensitive are the form fields hidden while typing unless revealed.
+var sensitive = map[string]bool{
+	"number":   true,
+	"code":     true,
+	"password": true,
+	"otp":      true,
+}
+
+// form creates a new secret or edits an existing one.
+type form struct {
+	// secret is the edited secret, a new one has no id.
+	secret client.Secret
+	kind   string
+	names  []string
+	inputs []textinput.Model
+	// focus is the focused row, the type selector is the first row of a new secret.
+	focus    int
+	revealed bool
+}
+
+// newForm creates a form of a new secret of the first type.
+func newForm() *form {
+	f := &form{kind: secretTypes[0]}
+	f.setKind(f.kind, nil)
+	f.focusRow(0)
+	return f
+}
+
+// editForm creates a form filled with the fields of the secret.
+func editForm(it item) *form {
+	f := &form{secret: it.secret, kind: it.kind}
+	f.setKind(it.kind, it.fields)
+	f.focusRow(0)
+	return f
+}
+
+// isNew reports whether the form creates a new secret.
+func (f *form) isNew() bool {
+	return f.secret.ID == ""
+}
+
+// setKind replaces the inputs with the fields of the secret type keeping the common ones.
+func (f *form) setKind(kind string, fields vault.Fields) {
+	if fields == nil {
+		fields = f.values()
+	}
+
+	names := append([]string(nil), typedFields[kind]...)
+	if kind == typeBin {
+		names = []string{fileField}
+	}
+	names = append(names, vault.FieldFolder, vault.FieldTags, vault.FieldNote)
+
+	f.kind = kind
+	f.names = names
+	f.inputs = make([]textinput.Model, len(names))
+	for i, name := range names {
+		input := textinput.New()
+		input.Prompt = ""
+		input.SetValue(fields[name])
+		switch name {
+		case fileField:
+			if !f.isNew() {
+				input.Placeholder = "keep current content"
+			}
+		case "otp":
+			input.Placeholder = "otpauth://totp/… or base32 seed"
+		case vault.FieldTags:
+			input.Placeholder = "comma separated"
+		case vault.FieldFolder:
+			input.Placeholder = "e.g. work/aws"
+		}
+		f.inputs[i] = input
+	}
+	f.setRevealed(f.revealed)
+}
+
+// values returns the values of the inputs by field name.
+func (f *form) values() vault.Fields {
+	values := make(vault.Fields, len(f.names))
+	for i, name := range f.names {
+		values[name] = f.inputs[i].Value()
+	}
+	return values
+}
+
+// setRevealed shows or hides the values of sensitive inputs.
+func (f *form) setRevealed(revealed bool) {
+	f.revealed = revealed
+	for i, name := range f.names {
+		f.inputs[i].EchoMode = textinput.EchoNormal
+		if sensitive[name] && !revealed {
+			f.inputs[i].EchoMode = textinput.EchoPassword
+			f.inputs[i].EchoCharacter = '•'
+		}
+	}
+}
+
+// rows returns the number of form rows.
+func (f *form) rows() int {
+	if f.isNew() {
+		return len(f.inputs) + 1
+	}
+	return len(f.inputs)
+}
+
+// input returns the index of the input of the row, -1 for the type selector.
+func (f *form) input(row int) int {
+	if f.isNew() {
+		return row - 1
+	}
+	return row
+}
+
+// focusRow moves the focus to the row.
+func (f *form) focusRow(row int) tea.Cmd {
+	f.focus = (row + f.rows()) % f.rows()
+	var cmd tea.Cmd
+	for i := range f.inputs {
+		if i == f.input(f.focus) {
+			cmd = f.inputs[i].Focus()
+			continue
+		}
+		f.inputs[i].Blur()
+	}
+	return cmd
+}
+
+// cycleKind switches a new secret to the next or the previous type.
+func (f *form) cycleKind(step int) {
+	for i, kind := range secretTypes {
+		if kind == f.kind {
+			f.setKind(secretTypes[(i+step+len(secretTypes))%len(secretTypes)], nil)
+			return
+		}
+	}
+}
+
+// last reports whether the focused row is the last one.
+func (f *form) last() bool {
+	return f.focus == f.rows()-1
+}
+
+// update passes the message to the focused input or the type selector.
+func (f *form) update(msg tea.Msg) tea.Cmd {
+	i := f.input(f.focus)
+	if i < 0 {
+		if key, ok := msg.(tea.KeyMsg); ok {
+			switch key.String() {
+			case "left", "h":
+				f.cycleKind(-1)
+			case "right", "l", " ":
+				f.cycleKind(1)
+			}
+		}
+		return nil
+	}
+
+	var cmd tea.Cmd
+	f.inputs[i], cmd = f.inputs[i].Update(msg)
+	return cmd
+}
+
+// build returns the secret with the form values, a new one gets a client-generated id.
+func (f *form) build() (client.Secret, error) {
+	values := f.values()
+
+	fields := make(vault.Fields)
+	folder := cleanFolder(values[vault.FieldFolder])
+	if strings.Contains(folder, ";") {
+		return client.Secret{}, errors.New("folder must not contain ';'")
+	}
+	fields[vault.FieldFolder] = folder
+
+	var tags []string
+	for _, tag := range strings.Split(values[vault.FieldTags], ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.Contains(tag, ";") {
+			return client.Secret{}, fmt.Errorf("invalid tag: %q", tag)
+		}
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	fields[vault.FieldTags] = strings.Join(tags, ",")
+	fields[vault.FieldNote] = values[vault.FieldNote]
+
+	for _, name := range typedFields[f.kind] {
+		fields[name] = values[name]
+	}
+	if f.kind == typeOTP {
+		key, err := otp.Parse(values["otp"])
+		if err != nil {
+			return client.Secret{}, err
+		}
+		fields["otp"] = key.URI()
+	}
+	if f.kind == typeBin {
+		content, err := f.binContent(values[fileField])
+		if err != nil {
+			return client.Secret{}, err
+		}
+		fields["bin"] = content
+	}
+
+	secret := f.secret
+	if f.isNew() {
+		secret.ID = uuid.New().String()
+		secret.Type = f.kind
+	}
+	secret.Data, secret.Note = fields.Format()
+
+	return secret, nil
+}
+
+// binContent reads the file of a binary secret as hex, an edited secret keeps its content without a file.
+func (f *form) binContent(file string) (string, error) {
+	file = strings.TrimSpace(file)
+	if file == "" {
+		if f.isNew() {
+			return "", errors.New("file of the binary secret is required")
+		}
+		return vault.ParseFields(f.secret.Data, f.secret.Note)["bin"], nil
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return fmt.Sprintf("%x", content), nil
+}
+
+// cleanFolder returns folder path without leading and trailing slashes, empty for the root folder.
+func cleanFolder(folder string) string {
+	return strings.Trim(path.Clean("/"+strings.TrimSpace(folder)), "/")
+}
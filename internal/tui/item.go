@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/pkg/otp"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+)
+
+// Types of secrets the UI renders and edits field by field.
+const (
+	typeCard        = "card"
+	typeCredentials = "credentials"
+	typeText        = "text"
+	typeBin         = "bin"
+	typeOTP         = "otp"
+)
+
+// secretTypes are the types of new secrets in the order they are cycled in the form.
+var secretTypes = []string{typeCredentials, typeCard, typeText, typeBin, typeOTP}
+
+// typedFields are the fields of the secret plaintext by secret type.
+var typedFields = map[string][]string{
+	typeCard:        {"number", "date", "holder", "code"},
+	typeCredentials: {"login", "password"},
+	typeText:        {"text"},
+	typeBin:         {"bin"},
+	typeOTP:         {"otp"},
+}
+
+// mask replaces sensitive values until they are revealed, it doesn't depend on the value length.
+const mask = "••••••••"
+
+// binPreview is the number of bytes of binary secrets shown in the detail pane.
+const binPreview = 32
+
+// item is a decrypted secret split into fields for display.
+type item struct {
+	secret client.Secret
+	fields vault.Fields
+	// kind is the type of the secret, detected by its fields for secrets saved without a type.
+	kind string
+}
+
+// newItem parses the decrypted secret.
+func newItem(s client.Secret) item {
+	it := item{secret: s, fields: vault.ParseFields(s.Data, s.Note), kind: s.Type}
+	if it.kind != "" {
+		return it
+	}
+
+	it.kind = "unknown"
+	for kind, names := range typedFields {
+		if _, ok := it.fields[names[0]]; ok {
+			it.kind = kind
+			break
+		}
+	}
+
+	return it
+}
+
+// editable reports whether the secret has a layout the form can edit.
+func (it item) editable() bool {
+	names, ok := typedFields[it.kind]
+	if !ok {
+		return false
+	}
+	_, ok = it.fields[names[0]]
+	return ok
+}
+
+// tags returns the tags of the secret.
+func (it item) tags() []string {
+	if it.fields[vault.FieldTags] == "" {
+		return nil
+	}
+	return strings.Split(it.fields[vault.FieldTags], ",")
+}
+
+// title is the name of the secret in the list, made of its non-sensitive fields.
+func (it item) title() string {
+	var title string
+	switch it.kind {
+	case typeCredentials:
+		title = it.fields["login"]
+	case typeCard:
+		title = strings.TrimSpace(it.fields["holder"] + " " + cardNumber(it.fields["number"], false))
+	case typeText:
+		title, _, _ = strings.Cut(it.fields["text"], "\n")
+	case typeBin:
+		title = fmt.Sprintf("%d bytes", len(it.fields["bin"])/2)
+	case typeOTP:
+		if key, err := otp.Parse(it.fields["otp"]); err == nil {
+			title = strings.Trim(key.Issuer+":"+key.Account, ":")
+		}
+	}
+	if title == "" {
+		title, _, _ = strings.Cut(it.fields[vault.FieldNote], "\n")
+	}
+	if title == "" {
+		title = it.secret.ID
+	}
+
+	return title
+}
+
+// path is the title of the secret prefixed with its folder.
+func (it item) path() string {
+	if folder := it.fields[vault.FieldFolder]; folder != "" {
+		return folder + "/" + it.title()
+	}
+	return it.title()
+}
+
+// matches reports whether the lowercase query is found in the non-sensitive fields of the secret.
+func (it item) matches(query string) bool {
+	text := strings.ToLower(strings.Join([]string{
+		it.kind,
+		it.path(),
+		it.fields[vault.FieldTags],
+		it.fields[vault.FieldNote],
+		it.fields["holder"],
+		it.secret.ID,
+	}, "\n"))
+
+	return strings.Contains(text, query)
+}
+
+// row is a labeled value of the detail pane.
+type row struct {
+	label string
+	value string
+}
+
+// details returns the fields of the secret rendered by its type, sensitive values are masked unless revealed.
+func (it item) details(revealed bool) []row {
+	secret := func(value string) string {
+		if revealed || value == "" {
+			return value
+		}
+		return mask
+	}
+
+	rows := []row{{"Type", it.kind}}
+	switch it.kind {
+	case typeCredentials:
+		rows = append(rows,
+			row{"Login", it.fields["login"]},
+			row{"Password", secret(it.fields["password"])})
+	case typeCard:
+		rows = append(rows,
+			row{"Number", cardNumber(it.fields["number"], revealed)},
+			row{"Expires", it.fields["date"]},
+			row{"Holder", it.fields["holder"]},
+			row{"Code", secret(it.fields["code"])})
+	case typeText:
+		rows = append(rows, row{"Text", it.fields["text"]})
+	case typeBin:
+		content := it.fields["bin"]
+		rows = append(rows, row{"Size", fmt.Sprintf("%d bytes", len(content)/2)})
+		if len(content) > 2*binPreview {
+			content = content[:2*binPreview] + "…"
+		}
+		rows = append(rows, row{"Content", secret(content)})
+	case typeOTP:
+		key, err := otp.Parse(it.fields["otp"])
+		if err != nil {
+			rows = append(rows, row{"Key", secret(it.fields["otp"])})
+			break
+		}
+		rows = append(rows,
+			row{"Issuer", key.Issuer},
+			row{"Account", key.Account},
+			row{"Parameters", fmt.Sprintf("%s, %d digits, %ds", key.Algorithm, key.Digits, key.Period)},
+			row{"Key", secret(it.fields["otp"])})
+	default:
+		rows = append(rows, row{"Data", secret(it.fields[vault.FieldData])})
+	}
+
+	rows = append(rows,
+		row{"Folder", it.fields[vault.FieldFolder]},
+		row{"Tags", strings.Join(it.tags(), ", ")},
+		row{"Note", it.fields[vault.FieldNote]})
+	if it.secret.ExpiresAt != nil {
+		rows = append(rows, row{"Expires at", it.secret.ExpiresAt.Local().Format(time.DateTime)})
+	}
+	if !it.secret.UpdatedAt.IsZero() {
+		rows = append(rows, row{"Updated", it.secret.UpdatedAt.Local().Format(time.DateTime)})
+	}
+	rows = append(rows, row{"ID", it.secret.ID})
+
+	return rows
+}
+
+// cardNumber masks the card number but its last four digits unless revealed.
+func cardNumber(number string, revealed bool) string {
+	if revealed || number == "" {
+		return number
+	}
+
+	digits := []rune(strings.ReplaceAll(number, " ", ""))
+	if len(digits) <= 4 {
+		return mask
+	}
+	return "•••• " + string(digits[len(digits)-4:])
+}
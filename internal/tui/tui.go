@@ -0,0 +1,420 @@
+// Package tui implements the interactive terminal UI of the GophKeeper client:
+// a searchable list of decrypted secrets, a detail pane rendered by secret type
+// and forms to create, edit and delete secrets.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultRefreshInterval is the period of refreshing secrets from the store in the background.
+const DefaultRefreshInterval = 30 * time.Second
+
+// Store loads and saves the decrypted secrets shown in the UI.
+type Store interface {
+	// List returns all personal secrets. cachedAt is the time of the local copy
+	// the secrets are read from when the server is unavailable, zero for secrets received from the server.
+	List(ctx context.Context) (secrets []client.Secret, cachedAt time.Time, err error)
+	// Create saves a new secret.
+	Create(ctx context.Context, s client.Secret) error
+	// Update saves the new content of the secret.
+	Update(ctx context.Context, s client.Secret) error
+	// Delete deletes the secret.
+	Delete(ctx context.Context, id string) error
+}
+
+// mode is the state of the UI deciding how keys are handled.
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeSearch
+	modeForm
+	modeDelete
+)
+
+// loadedMsg carries the secrets loaded from the store.
+type loadedMsg struct {
+	secrets  []client.Secret
+	cachedAt time.Time
+	err      error
+}
+
+// refreshMsg triggers the background refresh.
+type refreshMsg struct{}
+
+// savedMsg reports the result of a change of the secret.
+type savedMsg struct {
+	id     string
+	action string
+	err    error
+}
+
+// Model is the bubbletea model of the UI.
+type Model struct {
+	store    Store
+	interval time.Duration
+
+	items []item
+	// visible are the indexes of items matching the search query.
+	visible []int
+	cursor  int
+	// revealed shows sensitive fields of the selected secret.
+	revealed bool
+
+	mode   mode
+	search textinput.Model
+	form   *form
+	// busy is set while a change is being saved, loading is set while secrets are being loaded.
+	busy    bool
+	loading bool
+
+	loadedAt time.Time
+	cachedAt time.Time
+	status   string
+	failed   bool
+
+	width  int
+	height int
+}
+
+// New creates the UI showing secrets of the store refreshed every interval.
+func New(store Store, interval time.Duration) Model {
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search"
+
+	return Model{
+		store:    store,
+		interval: interval,
+		search:   search,
+		loading:  true,
+		width:    80,
+		height:   24,
+	}
+}
+
+// Run shows the UI in the full terminal screen until the user quits.
+func Run(store Store, interval time.Duration) error {
+	_, err := tea.NewProgram(New(store, interval), tea.WithAltScreen()).Run()
+	return err
+}
+
+// Init loads the secrets and starts the background refresh.
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.load(), m.tick())
+}
+
+// load requests all secrets from the store.
+func (m Model) load() tea.Cmd {
+	store := m.store
+	return func() tea.Msg {
+		secrets, cachedAt, err := store.List(context.Background())
+		return loadedMsg{secrets: secrets, cachedAt: cachedAt, err: err}
+	}
+}
+
+// tick schedules the next background refresh.
+func (m Model) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(time.Time) tea.Msg {
+		return refreshMsg{}
+	})
+}
+
+// refresh loads the secrets unless they are being loaded already.
+func (m *Model) refresh() tea.Cmd {
+	if m.loading {
+		return nil
+	}
+	m.loading = true
+	return m.load()
+}
+
+// save runs the change of the secret in the background.
+func (m *Model) save(id, action string, change func(ctx context.Context) error) tea.Cmd {
+	m.busy = true
+	return func() tea.Msg {
+		return savedMsg{id: id, action: action, err: change(context.Background())}
+	}
+}
+
+// setStatus shows the message in the status line.
+func (m *Model) setStatus(failed bool, format string, args ...any) {
+	m.status = fmt.Sprintf(format, args...)
+	m.failed = failed
+}
+
+// Update handles the message and returns the next state of the UI.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case loadedMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.setStatus(true, "Failed to load secrets: %v", msg.err)
+			return m, nil
+		}
+		m.setItems(msg.secrets)
+		m.loadedAt, m.cachedAt = time.Now(), msg.cachedAt
+		return m, nil
+
+	case refreshMsg:
+		return m, tea.Batch(m.refresh(), m.tick())
+
+	case savedMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.setStatus(true, "Failed to save secret: %v", msg.err)
+			return m, nil
+		}
+		if m.mode == modeForm {
+			m.mode, m.form = modeBrowse, nil
+		}
+		m.setStatus(false, "Secret %s %s", msg.id, msg.action)
+		return m, m.refresh()
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeSearch:
+			return m.updateSearch(msg)
+		case modeForm:
+			return m.updateForm(msg)
+		case modeDelete:
+			return m.updateDelete(msg)
+		default:
+			return m.updateBrowse(msg)
+		}
+	}
+
+	// Other messages such as cursor blinks go to the focused input.
+	var cmd tea.Cmd
+	switch m.mode {
+	case modeSearch:
+		m.search, cmd = m.search.Update(msg)
+	case modeForm:
+		cmd = m.form.update(msg)
+	}
+	return m, cmd
+}
+
+// updateBrowse handles keys of the secret list.
+func (m Model) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.moveCursor(-1)
+	case "down", "j":
+		m.moveCursor(1)
+	case "pgup":
+		m.moveCursor(-m.listHeight())
+	case "pgdown":
+		m.moveCursor(m.listHeight())
+	case "home", "g":
+		m.moveCursor(-len(m.visible))
+	case "end", "G":
+		m.moveCursor(len(m.visible))
+	case "r":
+		m.revealed = !m.revealed
+	case "/":
+		m.mode = modeSearch
+		return m, m.search.Focus()
+	case "esc":
+		m.search.SetValue("")
+		m.filter()
+	case "ctrl+r":
+		return m, m.refresh()
+	case "n":
+		m.mode, m.form = modeForm, newForm()
+	case "e":
+		it, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+		if !it.editable() {
+			m.setStatus(true, "Secret %s has an unknown layout and can't be edited here", it.secret.ID)
+			return m, nil
+		}
+		m.mode, m.form = modeForm, editForm(it)
+		return m, m.form.focusRow(0)
+	case "d":
+		if _, ok := m.selected(); ok {
+			m.mode = modeDelete
+		}
+	}
+
+	return m, nil
+}
+
+// updateSearch handles keys typed into the search query, the list is filtered as the query changes.
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.search.SetValue("")
+		fallthrough
+	case "enter":
+		m.mode = modeBrowse
+		m.search.Blur()
+		m.filter()
+		return m, nil
+	case "up", "down":
+		return m.updateBrowse(msg)
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.filter()
+	return m, cmd
+}
+
+// updateForm handles keys of the create and edit form.
+func (m Model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.busy {
+		return m, nil
+	}
+
+	f := m.form
+	switch msg.String() {
+	case "esc":
+		m.mode, m.form = modeBrowse, nil
+		return m, nil
+	case "tab", "down":
+		return m, f.focusRow(f.focus + 1)
+	case "shift+tab", "up":
+		return m, f.focusRow(f.focus - 1)
+	case "ctrl+r":
+		f.setRevealed(!f.revealed)
+		return m, nil
+	case "enter":
+		if !f.last() {
+			return m, f.focusRow(f.focus + 1)
+		}
+		return m.submit()
+	case "ctrl+s":
+		return m.submit()
+	}
+
+	return m, f.update(msg)
+}
+
+// submit saves the secret of the form.
+func (m Model) submit() (tea.Model, tea.Cmd) {
+	secret, err := m.form.build()
+	if err != nil {
+		m.setStatus(true, "Invalid secret: %v", err)
+		return m, nil
+	}
+
+	store := m.store
+	if m.form.isNew() {
+		return m, m.save(secret.ID, "created", func(ctx context.Context) error {
+			return store.Create(ctx, secret)
+		})
+	}
+	return m, m.save(secret.ID, "updated", func(ctx context.Context) error {
+		return store.Update(ctx, secret)
+	})
+}
+
+// updateDelete handles the confirmation of deleting the selected secret.
+func (m Model) updateDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+	it, ok := m.selected()
+	if !ok || msg.String() != "y" {
+		return m, nil
+	}
+
+	store, id := m.store, it.secret.ID
+	return m, m.save(id, "deleted", func(ctx context.Context) error {
+		return store.Delete(ctx, id)
+	})
+}
+
+// setItems replaces the secrets keeping the selected one.
+func (m *Model) setItems(secrets []client.Secret) {
+	selectedID := m.selectedID()
+
+	m.items = make([]item, 0, len(secrets))
+	for _, s := range secrets {
+		m.items = append(m.items, newItem(s))
+	}
+	sort.SliceStable(m.items, func(i, j int) bool {
+		pi, pj := strings.ToLower(m.items[i].path()), strings.ToLower(m.items[j].path())
+		if pi != pj {
+			return pi < pj
+		}
+		return m.items[i].secret.ID < m.items[j].secret.ID
+	})
+
+	m.visible = nil
+	m.applyFilter(selectedID)
+	if m.selectedID() != selectedID {
+		m.revealed = false
+	}
+}
+
+// filter selects the items matching the search query keeping the selected one if it still matches.
+func (m *Model) filter() {
+	selectedID := m.selectedID()
+	m.applyFilter(selectedID)
+	if m.selectedID() != selectedID {
+		m.revealed = false
+	}
+}
+
+// applyFilter selects the items matching the search query and moves the cursor to the secret with the id.
+func (m *Model) applyFilter(selectedID string) {
+	query := strings.ToLower(strings.TrimSpace(m.search.Value()))
+
+	m.visible = m.visible[:0]
+	m.cursor = 0
+	for i, it := range m.items {
+		if query != "" && !it.matches(query) {
+			continue
+		}
+		if it.secret.ID == selectedID {
+			m.cursor = len(m.visible)
+		}
+		m.visible = append(m.visible, i)
+	}
+}
+
+// selectedID returns the id of the secret under the cursor, empty if there is none.
+func (m Model) selectedID() string {
+	it, ok := m.selected()
+	if !ok {
+		return ""
+	}
+	return it.secret.ID
+}
+
+// selected returns the secret under the cursor.
+func (m Model) selected() (item, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return item{}, false
+	}
+	return m.items[m.visible[m.cursor]], true
+}
+
+// moveCursor moves the cursor by the number of rows, sensitive fields are hidden again.
+func (m *Model) moveCursor(rows int) {
+	cursor := min(max(m.cursor+rows, 0), max(len(m.visible)-1, 0))
+	if cursor != m.cursor {
+		m.revealed = false
+	}
+	m.cursor = cursor
+}
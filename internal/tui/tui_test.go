@@ -0,0 +1,261 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore keeps secrets in memory.
+type fakeStore struct {
+	secrets  []client.Secret
+	cachedAt time.Time
+	err      error
+}
+
+func (s *fakeStore) List(ctx context.Context) ([]client.Secret, time.Time, error) {
+	return append([]client.Secret(nil), s.secrets...), s.cachedAt, s.err
+}
+
+func (s *fakeStore) Create(ctx context.Context, secret client.Secret) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.secrets = append(s.secrets, secret)
+	return nil
+}
+
+func (s *fakeStore) Update(ctx context.Context, secret client.Secret) error {
+	for i := range s.secrets {
+		if s.secrets[i].ID == secret.ID {
+			s.secrets[i] = secret
+			return nil
+		}
+	}
+	return errors.New("secret not found")
+}
+
+func (s *fakeStore) Delete(ctx context.Context, id string) error {
+	for i := range s.secrets {
+		if s.secrets[i].ID == id {
+			s.secrets = append(s.secrets[:i], s.secrets[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("secret not found")
+}
+
+// keys converts the keys to messages: special keys by name, other strings as typed runes.
+func keys(names ...string) []tea.Msg {
+	special := map[string]tea.KeyType{
+		"enter":     tea.KeyEnter,
+		"esc":       tea.KeyEsc,
+		"tab":       tea.KeyTab,
+		"shift+tab": tea.KeyShiftTab,
+		"up":        tea.KeyUp,
+		"down":      tea.KeyDown,
+		"right":     tea.KeyRight,
+		"ctrl+s":    tea.KeyCtrlS,
+		"ctrl+r":    tea.KeyCtrlR,
+		"backspace": tea.KeyBackspace,
+	}
+
+	var msgs []tea.Msg
+	for _, name := range names {
+		if t, ok := special[name]; ok {
+			msgs = append(msgs, tea.KeyMsg{Type: t})
+			continue
+		}
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)})
+	}
+	return msgs
+}
+
+// send passes the messages to the model running the store requests they start.
+// Other commands such as cursor blinks and the background refresh are skipped.
+func send(t *testing.T, m Model, msgs ...tea.Msg) Model {
+	t.Helper()
+
+	for len(msgs) > 0 {
+		msg := msgs[0]
+		msgs = msgs[1:]
+
+		next, cmd := m.Update(msg)
+		started := next.(Model)
+		if cmd != nil && (started.busy && !m.busy || started.loading && !m.loading) {
+			msgs = append([]tea.Msg{cmd()}, msgs...)
+		}
+		m = started
+	}
+
+	return m
+}
+
+// loaded creates the model with the secrets of the store loaded.
+func loaded(t *testing.T, store Store) Model {
+	m := New(store, time.Hour)
+	m.width, m.height = 120, 30
+	return send(t, m, m.load()())
+}
+
+func testSecrets() []client.Secret {
+	return []client.Secret{
+		{ID: "1", Type: "credentials", Data: "folder:work/aws;tags:prod;login:admin;password:s3cret", Note: "console"},
+		{ID: "2", Type: "card", Data: "number:4111111111111111;date:12/30;holder:ALICE;code:123"},
+		{ID: "3", Type: "text", Data: "text:hello world"},
+		{ID: "4", Type: "bin", Data: "bin:cafebabe"},
+	}
+}
+
+func TestBrowse(t *testing.T) {
+	m := loaded(t, &fakeStore{secrets: testSecrets()})
+
+	view := m.View()
+	assert.Contains(t, view, "4 secrets")
+	assert.Contains(t, view, "work/aws/admin")
+	assert.Contains(t, view, "ALICE •••• 1111")
+	assert.Contains(t, view, "hello world")
+	assert.Contains(t, view, "4 bytes")
+
+	// Secrets are sorted by path, the card of ALICE follows the binary secret.
+	m = send(t, m, keys("down")...)
+	assert.NotContains(t, m.View(), "4111111111111111")
+	assert.NotContains(t, m.View(), "123")
+	m = send(t, m, keys("r")...)
+	assert.Contains(t, m.View(), "4111111111111111")
+
+	// Moving to another secret masks the fields again.
+	m = send(t, m, keys("down", "down")...)
+	it, ok := m.selected()
+	require.True(t, ok)
+	assert.Equal(t, "1", it.secret.ID)
+	assert.NotContains(t, m.View(), "s3cret")
+	m = send(t, m, keys("r")...)
+	assert.Contains(t, m.View(), "s3cret")
+
+	// Search matches folders, tags and notes but not sensitive fields.
+	m = send(t, m, keys("/", "p", "r", "o", "d", "enter")...)
+	assert.Len(t, m.visible, 1)
+	m = send(t, m, keys("/", "backspace", "backspace", "backspace", "backspace", "s", "3", "c", "enter")...)
+	assert.Empty(t, m.visible)
+	m = send(t, m, keys("esc")...)
+	assert.Len(t, m.visible, 4)
+}
+
+func TestRefreshKeepsSelection(t *testing.T) {
+	store := &fakeStore{secrets: testSecrets()}
+	m := loaded(t, store)
+	m = send(t, m, keys("down", "down")...)
+	selected := m.selectedID()
+
+	store.secrets = append(store.secrets, client.Secret{ID: "5", Type: "text", Data: "text:aaa"})
+	m = send(t, m, m.load()())
+	assert.Len(t, m.visible, 5)
+	assert.Equal(t, selected, m.selectedID())
+
+	// Failed refresh keeps the secrets and shows the cached state.
+	store.err = errors.New("server is down")
+	m = send(t, m, m.load()())
+	assert.Len(t, m.visible, 5)
+	assert.Contains(t, m.View(), "server is down")
+
+	store.err = nil
+	store.cachedAt = time.Now()
+	m = send(t, m, m.load()())
+	assert.Contains(t, m.View(), "offline, cached at")
+}
+
+func TestCreateSecret(t *testing.T) {
+	store := &fakeStore{}
+	m := loaded(t, store)
+
+	// The form starts with credentials, the card is the next type.
+	m = send(t, m, keys("n", "right", "tab")...)
+	m = send(t, m, keys("4242424242424242", "tab", "01/31", "tab", "BOB", "tab", "999", "tab", "/bank/visa/", "tab", "money, main", "tab", "my card", "ctrl+s")...)
+	require.Len(t, store.secrets, 1)
+	created := store.secrets[0]
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "card", created.Type)
+	assert.Equal(t, "folder:bank/visa;tags:money,main;number:4242424242424242;date:01/31;holder:BOB;code:999", created.Data)
+	assert.Equal(t, "my card", created.Note)
+	assert.Equal(t, modeBrowse, m.mode)
+	assert.Contains(t, m.View(), "bank/visa/BOB •••• 4242")
+
+	// Binary secrets are read from the file.
+	file := filepath.Join(t.TempDir(), "key.bin")
+	require.NoError(t, os.WriteFile(file, []byte{0xde, 0xad}, 0o600))
+	m = send(t, m, keys("n", "right", "right", "right", "tab", file, "ctrl+s")...)
+	require.Len(t, store.secrets, 2)
+	assert.Equal(t, "bin:dead", store.secrets[1].Data)
+
+	// Invalid values and failed saves keep the form open.
+	m = send(t, m, keys("n", "tab", "tab", "tab", "a;b", "ctrl+s")...)
+	assert.Equal(t, modeForm, m.mode)
+	assert.Contains(t, m.View(), "folder must not contain ';'")
+
+	store.err = errors.New("storage limit reached")
+	m = send(t, m, keys("shift+tab", "shift+tab", "root", "tab", "pass", "tab", "backspace", "backspace", "backspace", "ctrl+s")...)
+	assert.Equal(t, modeForm, m.mode)
+	assert.Contains(t, m.View(), "storage limit reached")
+	m = send(t, m, keys("esc")...)
+	assert.Equal(t, modeBrowse, m.mode)
+	assert.Len(t, store.secrets, 2)
+}
+
+func TestOTPSecret(t *testing.T) {
+	store := &fakeStore{}
+	m := loaded(t, store)
+
+	// The otp type is the last one, raw seeds are saved as normalized URIs.
+	m = send(t, m, keys("n", "left", "tab", "not-base32!", "ctrl+s")...)
+	assert.Equal(t, modeForm, m.mode)
+	assert.Contains(t, m.View(), "secret must be base32 encoded")
+
+	m = send(t, m, keys("esc", "n", "left", "tab", "otpauth://totp/ACME:bot?secret=GEZDGNBVGY3TQOJQ&digits=8", "ctrl+s")...)
+	require.Len(t, store.secrets, 1)
+	assert.Equal(t, "otp", store.secrets[0].Type)
+	assert.Equal(t, "otp:otpauth://totp/ACME:bot?algorithm=SHA1&digits=8&issuer=ACME&period=30&secret=GEZDGNBVGY3TQOJQ", store.secrets[0].Data)
+
+	view := m.View()
+	assert.Contains(t, view, "ACME:bot")
+	assert.Contains(t, view, "SHA1, 8 digits, 30s")
+	assert.NotContains(t, view, "GEZDGNBVGY3TQOJQ")
+	m = send(t, m, keys("r")...)
+	assert.Contains(t, m.View(), "GEZDGNBVGY3TQOJQ")
+}
+
+func TestEditAndDeleteSecret(t *testing.T) {
+	store := &fakeStore{secrets: testSecrets()}
+	m := loaded(t, store)
+
+	// The credentials are the last secret in the list.
+	m = send(t, m, keys("down", "down", "down")...)
+	m = send(t, m, keys("e", "tab")...)
+	for range len("s3cret") {
+		m = send(t, m, keys("backspace")...)
+	}
+	m = send(t, m, keys("n3w", "enter", "enter", "enter", "enter")...)
+	assert.Equal(t, modeBrowse, m.mode)
+	assert.Equal(t, "folder:work/aws;tags:prod;login:admin;password:n3w", store.secrets[0].Data)
+	assert.Equal(t, "console", store.secrets[0].Note)
+
+	// Binary secrets keep their content without a new file.
+	m = send(t, m, keys("up", "up", "up", "e", "tab", "tab", "tab", "notes", "ctrl+s")...)
+	assert.Equal(t, "bin:cafebabe", store.secrets[3].Data)
+	assert.Equal(t, "notes", store.secrets[3].Note)
+
+	// Deletion is confirmed.
+	m = send(t, m, keys("d", "n")...)
+	assert.Len(t, store.secrets, 4)
+	m = send(t, m, keys("d", "y")...)
+	assert.Len(t, store.secrets, 3)
+	assert.Contains(t, m.View(), "Secret 4 deleted")
+}
@@ -0,0 +1,179 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles of the UI elements.
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	labelStyle    = lipgloss.NewStyle().Faint(true)
+	focusedStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+	paneStyle     = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).PaddingLeft(1)
+)
+
+// labelWidth is the width of field labels in the detail pane and the form.
+const labelWidth = 14
+
+// Help lines by UI mode.
+var help = map[mode]string{
+	modeBrowse: "↑/↓ move · / search · r reveal · n new · e edit · d delete · ctrl+r refresh · q quit",
+	modeSearch: "type to filter · ↑/↓ move · enter done · esc clear",
+	modeForm:   "tab/↓ next · shift+tab/↑ previous · ←/→ type · ctrl+r reveal · ctrl+s save · esc cancel",
+	modeDelete: "y delete · any other key cancels",
+}
+
+// View renders the UI.
+func (m Model) View() string {
+	height := m.listHeight()
+	listWidth := max(m.width*2/5, 24)
+	paneWidth := max(m.width-listWidth-2, 20)
+
+	list := lipgloss.NewStyle().Width(listWidth).MaxWidth(listWidth).Height(height).MaxHeight(height).Render(m.listView(height, listWidth))
+	var pane string
+	if m.mode == modeForm {
+		pane = m.formView(paneWidth)
+	} else {
+		pane = m.detailView(paneWidth)
+	}
+	pane = paneStyle.Width(paneWidth).Height(height).MaxHeight(height).Render(pane)
+
+	return strings.Join([]string{
+		m.headerView(),
+		lipgloss.JoinHorizontal(lipgloss.Top, list, pane),
+		m.statusView(),
+		helpStyle.Render(help[m.mode]),
+	}, "\n")
+}
+
+// listHeight returns the number of secrets fitting the screen besides the header, status and help lines.
+func (m Model) listHeight() int {
+	return max(m.height-4, 1)
+}
+
+// headerView renders the title and the state of the loaded secrets.
+func (m Model) headerView() string {
+	var state string
+	switch {
+	case m.loadedAt.IsZero():
+		state = "loading…"
+	case !m.cachedAt.IsZero():
+		state = fmt.Sprintf("%d secrets · offline, cached at %s", len(m.items), m.cachedAt.Local().Format(time.DateTime))
+	default:
+		state = fmt.Sprintf("%d secrets · updated at %s", len(m.items), m.loadedAt.Format(time.TimeOnly))
+	}
+
+	return headerStyle.Render("GophKeeper") + "  " + labelStyle.Render(state)
+}
+
+// listView renders the window of the visible secrets around the cursor.
+func (m Model) listView(height, width int) string {
+	if len(m.visible) == 0 {
+		if m.loadedAt.IsZero() {
+			return ""
+		}
+		return labelStyle.Render("no secrets")
+	}
+
+	offset := max(m.cursor-height+1, 0)
+	lines := make([]string, 0, height)
+	for i := offset; i < len(m.visible) && i < offset+height; i++ {
+		it := m.items[m.visible[i]]
+		line := truncate(fmt.Sprintf("%-11s %s", it.kind, it.path()), width)
+		if i == m.cursor {
+			line = selectedStyle.Render(line + strings.Repeat(" ", max(width-lipgloss.Width(line), 0)))
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// detailView renders the fields of the selected secret or the delete confirmation.
+func (m Model) detailView(width int) string {
+	it, ok := m.selected()
+	if !ok {
+		return ""
+	}
+
+	var sb strings.Builder
+	if m.mode == modeDelete {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("Delete %s? (y/n)", it.path())) + "\n\n")
+	}
+	valueStyle := lipgloss.NewStyle().Width(max(width-labelWidth, 10))
+	for _, r := range it.details(m.revealed) {
+		label := labelStyle.Width(labelWidth).Render(r.label)
+		sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, label, valueStyle.Render(r.value)) + "\n")
+	}
+
+	return sb.String()
+}
+
+// formView renders the create or edit form.
+func (m Model) formView(width int) string {
+	f := m.form
+
+	var sb strings.Builder
+	if f.isNew() {
+		sb.WriteString(headerStyle.Render("New secret") + "\n\n")
+		kind := "< " + f.kind + " >"
+		if f.focus == 0 {
+			kind = focusedStyle.Render(kind)
+		}
+		sb.WriteString(labelStyle.Width(labelWidth).Render("Type") + kind + "\n")
+	} else {
+		sb.WriteString(headerStyle.Render("Edit "+f.kind+" "+f.secret.ID) + "\n\n")
+	}
+
+	for i, name := range f.names {
+		label := labelStyle.Width(labelWidth)
+		if f.input(f.focus) == i {
+			label = focusedStyle.Width(labelWidth)
+		}
+		input := f.inputs[i]
+		input.Width = max(width-labelWidth-2, 10)
+		sb.WriteString(label.Render(labels[name]) + input.View() + "\n")
+	}
+	if m.busy {
+		sb.WriteString("\n" + labelStyle.Render("saving…"))
+	}
+
+	return sb.String()
+}
+
+// statusView renders the search query and the result of the last action.
+func (m Model) statusView() string {
+	var parts []string
+	if m.mode == modeSearch || m.search.Value() != "" {
+		parts = append(parts, m.search.View())
+	}
+	if m.status != "" {
+		style := infoStyle
+		if m.failed {
+			style = errorStyle
+		}
+		parts = append(parts, style.Render(m.status))
+	}
+
+	return strings.Join(parts, "  ")
+}
+
+// truncate cuts the line to the width.
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Export is the data of the user's account.
+type Export struct {
+	UserID   string
+	Username string
+	// Keys is the sharing keypair, the private key stays encrypted with the encryption key. Nil if the user has none.
+	Keys *proto.UserKeys
+	// Secrets are the personal secrets of the user.
+	Secrets []ExportedSecret
+	// Shares are the secrets shared with the user.
+	Shares []ExportedShare
+	// Organizations are the memberships of the user with his roles.
+	Organizations []*proto.Organization
+	// Collections are the keys of the collections the user has access to, wrapped with his public key.
+	Collections []*proto.CollectionKey
+	// CollectionSecrets are the secrets of the collections with CollectionID set.
+	CollectionSecrets []ExportedSecret
+}
+
+// ExportedSecret is a secret of the account export.
+type ExportedSecret struct {
+	Secret
+	// Encrypted reports that Data and Note keep the ciphertexts stored on the server:
+	// the export is raw or the secret failed to decrypt.
+	Encrypted bool
+}
+
+// ExportedShare is a secret shared with the user in the account export.
+type ExportedShare struct {
+	Share
+	// WrappedKey is the data key of the share wrapped with the user's public key.
+	WrappedKey string
+	// Encrypted reports that Data and Note keep the ciphertexts stored on the server:
+	// the export is raw or the share failed to decrypt.
+	Encrypted bool
+}
+
+// ExportAccount downloads the user row, sharing keypair, personal secrets, secrets shared with the user,
+// organization memberships and collections the user has access to.
+// Secrets and shares are decrypted unless raw is set. The ones failed to decrypt keep the ciphertexts
+// stored on the server and are marked Encrypted, they are reported in DecryptError returned along with the export.
+// The stream is limited by the context only.
+func (c *Client) ExportAccount(ctx context.Context, raw bool) (*Export, error) {
+	var key string
+	if !raw {
+		var err error
+		if key, err = c.encryptionKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	stream, err := c.keeper.ExportAccount(ctx, &proto.ExportAccountRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	export := &Export{Secrets: []ExportedSecret{}}
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch it := item.Item.(type) {
+		case *proto.ExportItem_User:
+			export.UserID = it.User.Id
+			export.Username = it.User.Username
+		case *proto.ExportItem_Keys:
+			export.Keys = it.Keys
+		case *proto.ExportItem_Secret:
+			export.Secrets = append(export.Secrets, exportedSecret(it.Secret))
+		case *proto.ExportItem_Share:
+			export.Shares = append(export.Shares, ExportedShare{
+				Share: Share{
+					SecretID: it.Share.SecretId,
+					Owner:    it.Share.Owner,
+					Data:     it.Share.Secret.GetData(),
+					Note:     it.Share.Secret.GetMeta(),
+				},
+				WrappedKey: it.Share.WrappedKey,
+				Encrypted:  true,
+			})
+		case *proto.ExportItem_Organization:
+			export.Organizations = append(export.Organizations, it.Organization)
+		case *proto.ExportItem_CollectionKey:
+			export.Collections = append(export.Collections, it.CollectionKey)
+		case *proto.ExportItem_CollectionSecret:
+			secret := exportedSecret(it.CollectionSecret.Secret)
+			secret.CollectionID = it.CollectionSecret.CollectionId
+			export.CollectionSecrets = append(export.CollectionSecrets, secret)
+		}
+	}
+
+	if raw {
+		return export, nil
+	}
+
+	return export, export.decrypt(key)
+}
+
+// exportedSecret returns the received secret with the ciphertexts as stored on the server.
+func exportedSecret(s *proto.CountedSecret) ExportedSecret {
+	return ExportedSecret{
+		Secret:    newSecret(s.Id, s.Secret, s.Secret.GetData(), s.Secret.GetMeta()),
+		Encrypted: true,
+	}
+}
+
+// decrypt decrypts the secrets of the export in place, personal ones with the encryption key,
+// shares and collection secrets with the keys wrapped for the user. Items failed to decrypt
+// are left encrypted and reported in DecryptError.
+func (e *Export) decrypt(key string) error {
+	var decryptErr DecryptError
+	for i := range e.Secrets {
+		if err := e.Secrets[i].decrypt(key); err != nil {
+			decryptErr.add(e.Secrets[i].ID, err)
+		}
+	}
+
+	// Keys of shares and collections are wrapped with the public key of the user.
+	var privateKey string
+	keyErr := ErrNoKeys
+	if e.Keys != nil {
+		var err error
+		if privateKey, err = encryption.DecryptWithKey(e.Keys.EncryptedPrivateKey, key); err != nil {
+			keyErr = fmt.Errorf("failed to decrypt private key: %w", err)
+		} else {
+			keyErr = nil
+		}
+	}
+	unwrap := func(wrappedKey string) (string, error) {
+		if keyErr != nil {
+			return "", keyErr
+		}
+		dataKey, err := encryption.UnwrapKey(wrappedKey, e.Keys.PublicKey, privateKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to unwrap key: %w", err)
+		}
+		return dataKey, nil
+	}
+
+	for i := range e.Shares {
+		sh := &e.Shares[i]
+		dataKey, err := unwrap(sh.WrappedKey)
+		if err == nil {
+			sh.Data, sh.Note, err = decryptFields(sh.Data, sh.Note, dataKey)
+		}
+		if err != nil {
+			decryptErr.add(sh.SecretID, err)
+			continue
+		}
+		sh.Encrypted = false
+	}
+
+	collectionKeys := make(map[int64]string, len(e.Collections))
+	collectionErrs := make(map[int64]error)
+	for _, k := range e.Collections {
+		collectionKey, err := unwrap(k.WrappedKey)
+		if err != nil {
+			collectionErrs[k.CollectionId] = fmt.Errorf("collection key: %w", err)
+		}
+		collectionKeys[k.CollectionId] = collectionKey
+	}
+	for i := range e.CollectionSecrets {
+		s := &e.CollectionSecrets[i]
+		collectionKey, ok := collectionKeys[s.CollectionID]
+		err := collectionErrs[s.CollectionID]
+		switch {
+		case !ok:
+			err = fmt.Errorf("no key of collection (id: %d)", s.CollectionID)
+		case err == nil:
+			err = s.decrypt(collectionKey)
+		}
+		if err != nil {
+			decryptErr.add(s.ID, err)
+		}
+	}
+
+	return decryptErr.err()
+}
+
+// decrypt decrypts the data and note of the secret with the key, the secret is unchanged on failure.
+func (s *ExportedSecret) decrypt(key string) error {
+	data, note, err := decryptFields(s.Data, s.Note, key)
+	if err != nil {
+		return err
+	}
+	s.Data, s.Note, s.Encrypted = data, note, false
+
+	return nil
+}
+
+// decryptFields decrypts the data and note ciphertexts with the key.
+func decryptFields(data, note, key string) (string, string, error) {
+	data, err := encryption.DecryptWithKey(data, key)
+	if err != nil {
+		return "", "", err
+	}
+	note, err = encryption.DecryptWithKey(note, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return data, note, nil
+}
+
+// DeleteAccount permanently deletes the user with all secrets and clears the saved token.
+// The current password is required.
+func (c *Client) DeleteAccount(ctx context.Context, password string) error {
+	if _, err := c.keeper.DeleteAccount(ctx, &proto.DeleteAccountRequest{Password: password}); err != nil {
+		return err
+	}
+
+	// Token of the deleted user is useless now.
+	return c.setToken("")
+}
+
+// Usage returns the number and the total size of user's secrets along with the limits set by the server.
+func (c *Client) Usage(ctx context.Context) (*proto.GetUsageResponse, error) {
+	return c.keeper.GetUsage(ctx, &proto.GetUsageRequest{})
+}
+
+// AuditEvents returns security-relevant events of the user's account in the time range,
+// zero times don't limit the range and zero limit returns all events.
+func (c *Client) AuditEvents(ctx context.Context, from, to time.Time, limit int32) ([]*proto.AuditEvent, error) {
+	req := &proto.ListAuditEventsRequest{Limit: limit}
+	if !from.IsZero() {
+		req.From = timestamppb.New(from)
+	}
+	if !to.IsZero() {
+		req.To = timestamppb.New(to)
+	}
+
+	resp, err := c.keeper.ListAuditEvents(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Events, nil
+}
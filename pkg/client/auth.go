@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Register signs up a new user and saves the issued token to the token storage.
+func (c *Client) Register(ctx context.Context, username, password string) (string, error) {
+	var header metadata.MD
+	_, err := c.keeper.Register(ctx, &proto.RegisterRequest{
+		UserData: &proto.User{Username: username, Password: password},
+	}, grpc.Header(&header))
+	if err != nil {
+		return "", err
+	}
+
+	return c.saveToken(header)
+}
+
+// Login signs in the user and saves the issued token to the token storage.
+func (c *Client) Login(ctx context.Context, username, password string) (string, error) {
+	var header metadata.MD
+	_, err := c.keeper.Login(ctx, &proto.LoginRequest{
+		UserData: &proto.User{Username: username, Password: password},
+	}, grpc.Header(&header))
+	if err != nil {
+		return "", err
+	}
+
+	return c.saveToken(header)
+}
+
+// saveToken extracts the token from the response header of registration or login and saves it.
+func (c *Client) saveToken(header metadata.MD) (string, error) {
+	tokens := header.Get("token")
+	if len(tokens) == 0 {
+		return "", errors.New("token not found in response header")
+	}
+
+	return tokens[0], c.setToken(tokens[0])
+}
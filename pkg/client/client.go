@@ -0,0 +1,340 @@
+// Package client is the Go client of the GophKeeper service.
+// Secrets are encrypted and decrypted on the client side, the server stores ciphertexts only.
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/token"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Default settings of the client.
+const (
+	DefaultTimeout   = 5 * time.Second        // Timeout of a single request
+	DefaultRetries   = 3                      // Number of retries of mutating requests after the first attempt
+	DefaultBackoff   = 500 * time.Millisecond // Delay before the first retry, doubled for every next one
+	DefaultUserAgent = "gophkeeper-go"
+)
+
+// refreshBefore is the time before the token expiration when it is renewed by logging in again.
+const refreshBefore = time.Minute
+
+// ErrNoToken is returned by requests requiring authentification when there is no token and no credentials to login.
+var ErrNoToken = errors.New("please login first: no token")
+
+// ErrNoEncryptionKey is returned by requests encrypting or decrypting secrets when the encryption key is not set.
+var ErrNoEncryptionKey = errors.New("encryption key is not set")
+
+// publicMethods lists methods called without the token.
+var publicMethods = map[string]bool{
+	proto.Keeper_Register_FullMethodName: true,
+	proto.Keeper_Login_FullMethodName:    true,
+	proto.Keeper_OpenSend_FullMethodName: true,
+}
+
+// options are the settings of the client.
+type options struct {
+	tokens      token.Storage
+	key         string
+	username    string
+	password    string
+	timeout     time.Duration
+	retries     int
+	backoff     time.Duration
+	userAgent   string
+	dialOptions []grpc.DialOption
+}
+
+// Option configures the client.
+type Option func(*options)
+
+// WithTokenStorage sets the storage the access token is loaded from and saved to after login.
+// By default the token is kept in memory only.
+func WithTokenStorage(s token.Storage) Option {
+	return func(o *options) {
+		o.tokens = s
+	}
+}
+
+// WithEncryptionKey sets the key secrets are encrypted with.
+func WithEncryptionKey(key string) Option {
+	return func(o *options) {
+		o.key = key
+	}
+}
+
+// WithCredentials sets the credentials used to login again when the token expires or is rejected by the server.
+func WithCredentials(username, password string) Option {
+	return func(o *options) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithTimeout sets the timeout of a single request, streams are limited by the caller's context only.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithRetries sets the number of retries of mutating requests failed with transient errors
+// and the delay before the first retry, doubled for every next one.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retries = retries
+		o.backoff = backoff
+	}
+}
+
+// WithUserAgent sets the user agent identifying the client in the server's audit log.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithDialOptions adds options of the gRPC connection, e.g. transport credentials or a custom dialer.
+// The connection is insecure by default.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// memoryStorage keeps the token in memory.
+type memoryStorage struct {
+	token string
+}
+
+// Load returns the saved token.
+func (s *memoryStorage) Load() (string, error) {
+	return s.token, nil
+}
+
+// Save saves the token.
+func (s *memoryStorage) Save(accessToken string) error {
+	s.token = accessToken
+	return nil
+}
+
+// Client is a client of the GophKeeper server.
+// Its methods return gRPC status errors of failed requests as is, so they can be checked with status.Code.
+type Client struct {
+	address string
+	conn    *grpc.ClientConn
+	keeper  proto.KeeperClient
+	opts    options
+
+	mu    sync.Mutex
+	token string
+}
+
+// New creates a client of the GophKeeper server at the address. Caller must close the client.
+func New(address string, opts ...Option) (*Client, error) {
+	c := &Client{
+		address: address,
+		opts: options{
+			tokens:    &memoryStorage{},
+			timeout:   DefaultTimeout,
+			retries:   DefaultRetries,
+			backoff:   DefaultBackoff,
+			userAgent: DefaultUserAgent,
+		},
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+
+	dialOptions := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUserAgent(c.opts.userAgent),
+		grpc.WithChainUnaryInterceptor(c.unaryInterceptor),
+		grpc.WithChainStreamInterceptor(c.streamInterceptor),
+	}, c.opts.dialOptions...)
+
+	conn, err := grpc.NewClient(address, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	c.keeper = proto.NewKeeperClient(conn)
+
+	return c, nil
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Keeper returns the raw gRPC client sharing the connection, authentification and retries of the client.
+func (c *Client) Keeper() proto.KeeperClient {
+	return c.keeper
+}
+
+// unaryInterceptor adds the token and the timeout to requests, logs in again if the token is rejected
+// and retries idempotent requests failed with transient errors with the same idempotency key.
+func (c *Client) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if publicMethods[method] {
+		ctx, cancel := context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	md := metadata.MD{}
+	if proto.IdempotentMethods[method] {
+		md.Set(proto.IdempotencyKeyHeader, uuid.New().String())
+	}
+
+	relogged := false
+	backoff := c.opts.backoff
+	for attempt := 0; ; attempt++ {
+		accessToken, err := c.accessToken(ctx)
+		if err != nil {
+			return err
+		}
+		md.Set("token", accessToken)
+
+		attemptCtx, cancel := context.WithTimeout(metadata.NewOutgoingContext(ctx, md), c.opts.timeout)
+		err = invoker(attemptCtx, method, req, reply, cc, opts...)
+		cancel()
+
+		// A token rejected by the server is renewed once, the request wasn't applied.
+		if status.Code(err) == codes.Unauthenticated && !relogged && c.canLogin() {
+			relogged = true
+			if err := c.relogin(ctx); err != nil {
+				return err
+			}
+			attempt--
+			continue
+		}
+		if err == nil || !proto.IdempotentMethods[method] || attempt == c.opts.retries || !Retryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// streamInterceptor adds the token to streaming requests.
+func (c *Client) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	accessToken, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return streamer(metadata.AppendToOutgoingContext(ctx, "token", accessToken), desc, cc, method, opts...)
+}
+
+// Retryable reports whether a failed request may succeed if retried.
+// Aborted means the previous attempt with the same idempotency key is still in progress.
+func Retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.Unavailable, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// Offline reports whether the request failed because the server can't be reached.
+func Offline(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// canLogin reports whether the client has credentials to login again.
+func (c *Client) canLogin() bool {
+	return c.opts.username != "" && c.opts.password != ""
+}
+
+// accessToken returns the current token, loading it from the storage on first use.
+// The token expiring soon is renewed if the client has credentials.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if c.token == "" {
+		saved, err := c.opts.tokens.Load()
+		if err != nil {
+			c.mu.Unlock()
+			return "", err
+		}
+		c.token = strings.TrimSpace(saved)
+	}
+	accessToken := c.token
+	c.mu.Unlock()
+
+	if !c.canLogin() {
+		if accessToken == "" {
+			return "", ErrNoToken
+		}
+		return accessToken, nil
+	}
+	if accessToken != "" && time.Until(tokenExpiration(accessToken)) > refreshBefore {
+		return accessToken, nil
+	}
+
+	if err := c.relogin(ctx); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.token, nil
+}
+
+// tokenExpiration returns the expiration time of the token, zero if it is unknown.
+// The signature can't be verified on the client and isn't checked.
+func tokenExpiration(accessToken string) time.Time {
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(accessToken, claims); err != nil || claims.ExpiresAt == nil {
+		return time.Time{}
+	}
+
+	return claims.ExpiresAt.Time
+}
+
+// relogin logs in with the credentials of the client.
+func (c *Client) relogin(ctx context.Context) error {
+	_, err := c.Login(ctx, c.opts.username, c.opts.password)
+	return err
+}
+
+// setToken saves the token received from the server.
+func (c *Client) setToken(accessToken string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = accessToken
+
+	return c.opts.tokens.Save(accessToken)
+}
+
+// encryptionKey returns the key secrets are encrypted with.
+func (c *Client) encryptionKey() (string, error) {
+	if c.opts.key == "" {
+		return "", ErrNoEncryptionKey
+	}
+
+	return c.opts.key, nil
+}
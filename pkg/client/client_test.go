@@ -0,0 +1,363 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/internal/app"
+	"github.com/KirillZiborov/GophKeeper/internal/auth"
+	"github.com/KirillZiborov/GophKeeper/internal/grpcapi"
+	"github.com/KirillZiborov/GophKeeper/internal/logging"
+	"github.com/KirillZiborov/GophKeeper/internal/storage"
+	"github.com/KirillZiborov/GophKeeper/pkg/client"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// memoryTokens is a token storage of a test client.
+type memoryTokens struct {
+	token string
+}
+
+func (m *memoryTokens) Load() (string, error) { return m.token, nil }
+
+func (m *memoryTokens) Save(token string) error {
+	m.token = token
+	return nil
+}
+
+// startServer starts GophKeeper server with fake storage on bufconn
+// and returns the option connecting clients to it.
+func startServer(t *testing.T) client.Option {
+	t.Helper()
+	require.NoError(t, logging.Initialize())
+	auth.SetTokenConfig("test-secret", "2h")
+
+	svc := app.KeeperService{
+		Store: storage.NewFakeStorage(),
+	}
+
+	lis := bufconn.Listen(bufSize)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcapi.ErrorInterceptor(), auth.AuthInterceptor(), grpcapi.IdempotencyInterceptor(&svc)),
+		grpc.ChainStreamInterceptor(grpcapi.StreamErrorInterceptor(), auth.StreamAuthInterceptor()),
+	)
+	proto.RegisterKeeperServer(grpcServer, grpcapi.NewGRPCKeeperServer(&svc))
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Errorf("gRPC server exited with error")
+		}
+	}()
+	t.Cleanup(grpcServer.GracefulStop)
+
+	resolver.SetDefaultScheme("passthrough")
+	return client.WithDialOptions(grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+}
+
+// newClient creates a client of the test server.
+func newClient(t *testing.T, dial client.Option, opts ...client.Option) *client.Client {
+	t.Helper()
+
+	c, err := client.New("bufnet", append([]client.Option{dial}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+// Test case: secrets are encrypted on the client and decrypted back by CRUD methods.
+func TestSecretsClient(t *testing.T) {
+	dial := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tokens := &memoryTokens{}
+	c := newClient(t, dial, client.WithTokenStorage(tokens), client.WithEncryptionKey("my-secret-key"))
+
+	// Requests without a token fail before reaching the server.
+	_, err := c.ListSecrets(ctx, client.Filter{})
+	require.ErrorIs(t, err, client.ErrNoToken)
+
+	token, err := c.Register(ctx, "testuser", "testpassword")
+	require.NoError(t, err)
+	assert.Equal(t, token, tokens.token)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	id, err := c.CreateSecret(ctx, client.Secret{
+		Type:      "credentials",
+		Data:      "folder:work/aws;tags:prod;login:admin;password:pass",
+		Note:      "aws",
+		ExpiresAt: &expiresAt,
+	})
+	require.NoError(t, err)
+	_, err = c.CreateSecret(ctx, client.Secret{Type: "text", Data: "text:hello"})
+	require.NoError(t, err)
+
+	// The server stores ciphertexts only.
+	raw, err := c.Keeper().GetSecret(ctx, &proto.GetSecretRequest{})
+	require.NoError(t, err)
+	require.Len(t, raw.Secret, 2)
+	for _, s := range raw.Secret {
+		assert.NotContains(t, s.Secret.Data, "hello")
+		assert.NotContains(t, s.Secret.Data, "admin")
+	}
+
+	secrets, err := c.ListSecrets(ctx, client.Filter{Type: "credentials"})
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, id, secrets[0].ID)
+	assert.Equal(t, "folder:work/aws;tags:prod;login:admin;password:pass", secrets[0].Data)
+	assert.Equal(t, "aws", secrets[0].Note)
+	assert.Equal(t, expiresAt, secrets[0].ExpiresAt.UTC())
+
+	// Blind indexes are computed from the plaintext.
+	found, err := c.SearchSecrets(ctx, "admin", "work", nil)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, id, found[0].ID)
+	found, err = c.SearchSecrets(ctx, "", "", []string{"dev"})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	require.NoError(t, c.UpdateSecret(ctx, client.Secret{ID: id, Type: "credentials", Data: "login:admin;password:new"}, true))
+
+	var streamed []client.Secret
+	require.NoError(t, c.StreamSecrets(ctx, client.Filter{}, func(s client.Secret) error {
+		streamed = append(streamed, s)
+		return nil
+	}))
+	require.Len(t, streamed, 2)
+	for _, s := range streamed {
+		if s.ID == id {
+			assert.Equal(t, "login:admin;password:new", s.Data)
+			assert.Nil(t, s.ExpiresAt)
+		}
+	}
+
+	// Batch mutations are encrypted the same way.
+	results, committed, err := c.MutateSecrets(ctx, []client.Mutation{
+		{Op: client.MutationCreate, Secret: client.Secret{Type: "text", Data: "text:batch"}},
+		{Op: client.MutationDelete, Secret: client.Secret{ID: id}},
+	}, false)
+	require.NoError(t, err)
+	assert.True(t, committed)
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.NoError(t, results[1].Err)
+
+	require.NoError(t, c.DeleteSecret(ctx, results[0].ID))
+	assert.Equal(t, codes.NotFound, status.Code(c.DeleteSecret(ctx, results[0].ID)))
+
+	// Secrets encrypted with another key are reported and skipped.
+	other := newClient(t, dial, client.WithTokenStorage(tokens), client.WithEncryptionKey("another-key"))
+	_, err = other.CreateSecret(ctx, client.Secret{Type: "text", Data: "text:other"})
+	require.NoError(t, err)
+	secrets, err = c.ListSecrets(ctx, client.Filter{})
+	var decryptErr *client.DecryptError
+	require.ErrorAs(t, err, &decryptErr)
+	assert.Len(t, decryptErr.Failed, 1)
+	assert.Len(t, secrets, 1)
+
+	// Export keeps secrets failed to decrypt as stored on the server.
+	export, err := c.ExportAccount(ctx, false)
+	require.ErrorAs(t, err, &decryptErr)
+	assert.Len(t, decryptErr.Failed, 1)
+	assert.Equal(t, "testuser", export.Username)
+	require.Len(t, export.Secrets, 2)
+	encrypted := 0
+	for _, s := range export.Secrets {
+		if s.Encrypted {
+			encrypted++
+			assert.NotEqual(t, "text:other", s.Data)
+			assert.Contains(t, decryptErr.Failed, s.ID)
+		}
+	}
+	assert.Equal(t, 1, encrypted)
+
+	require.NoError(t, c.DeleteAccount(ctx, "testpassword"))
+	assert.Empty(t, tokens.token)
+}
+
+// Test case: the client logs in with its credentials when the token is missing or rejected.
+func TestReloginClient(t *testing.T) {
+	dial := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := newClient(t, dial).Register(ctx, "testuser", "testpassword")
+	require.NoError(t, err)
+
+	tokens := &memoryTokens{token: "expired-token"}
+	c := newClient(t, dial, client.WithTokenStorage(tokens), client.WithEncryptionKey("my-secret-key"),
+		client.WithCredentials("testuser", "testpassword"))
+
+	_, err = c.CreateSecret(ctx, client.Secret{Type: "text", Data: "text:hello"})
+	require.NoError(t, err)
+	assert.NotEqual(t, "expired-token", tokens.token)
+
+	// Token that isn't expiring yet but is rejected by the server is renewed once.
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte("another-secret"))
+	require.NoError(t, err)
+	tokens.token = forged
+	c = newClient(t, dial, client.WithTokenStorage(tokens), client.WithEncryptionKey("my-secret-key"),
+		client.WithCredentials("testuser", "testpassword"))
+	secrets, err := c.ListSecrets(ctx, client.Filter{})
+	require.NoError(t, err)
+	assert.Len(t, secrets, 1)
+	assert.NotEqual(t, forged, tokens.token)
+
+	// Rejected token without credentials is returned as is.
+	tokens.token = "expired-token"
+	c = newClient(t, dial, client.WithTokenStorage(tokens), client.WithEncryptionKey("my-secret-key"))
+	_, err = c.ListSecrets(ctx, client.Filter{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Wrong credentials fail the request.
+	c = newClient(t, dial, client.WithTokenStorage(&memoryTokens{}), client.WithEncryptionKey("my-secret-key"),
+		client.WithCredentials("testuser", "wrong"))
+	_, err = c.ListSecrets(ctx, client.Filter{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// Test case: secrets shared with another user and kept in organization collections are decrypted with wrapped keys.
+func TestSharingClient(t *testing.T) {
+	dial := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alice := newClient(t, dial, client.WithEncryptionKey("alice-key"))
+	_, err := alice.Register(ctx, "alice", "alicepassword")
+	require.NoError(t, err)
+	bob := newClient(t, dial, client.WithEncryptionKey("bob-key"))
+	_, err = bob.Register(ctx, "bob", "bobpassword")
+	require.NoError(t, err)
+
+	require.NoError(t, alice.InitKeys(ctx, false))
+	require.ErrorIs(t, alice.InitKeys(ctx, false), client.ErrKeysExist)
+
+	id, err := alice.CreateSecret(ctx, client.Secret{Type: "text", Data: "folder:private;text:shared", Note: "note"})
+	require.NoError(t, err)
+
+	// Bob needs sharing keys to receive secrets.
+	assert.Equal(t, codes.NotFound, status.Code(alice.ShareSecret(ctx, id, "bob")))
+	require.NoError(t, bob.InitKeys(ctx, false))
+	require.ErrorIs(t, alice.ShareSecret(ctx, "unknown", "bob"), client.ErrSecretNotFound)
+	require.NoError(t, alice.ShareSecret(ctx, id, "bob"))
+
+	shares, err := bob.ListSharedWithMe(ctx)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	assert.Equal(t, client.Share{SecretID: id, Owner: "alice", Data: "folder:private;text:shared", Note: "note"}, shares[0])
+
+	// Owner's edits are visible to the recipient.
+	require.NoError(t, alice.UpdateSecret(ctx, client.Secret{ID: id, Type: "text", Data: "text:edited", Note: "new note"}, false))
+	shares, err = bob.ListSharedWithMe(ctx)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	assert.Equal(t, client.Share{SecretID: id, Owner: "alice", Data: "text:edited", Note: "new note"}, shares[0])
+
+	results, _, err := alice.MutateSecrets(ctx, []client.Mutation{
+		{Op: client.MutationUpdate, Secret: client.Secret{ID: id, Type: "text", Data: "text:batch", Note: "note"}},
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, results[0].Err)
+	shares, err = bob.ListSharedWithMe(ctx)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	assert.Equal(t, "text:batch", shares[0].Data)
+
+	require.NoError(t, alice.RevokeShare(ctx, id, "bob"))
+	shares, err = bob.ListSharedWithMe(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, shares)
+
+	orgID, err := alice.CreateOrganization(ctx, "team")
+	require.NoError(t, err)
+	collectionID, skipped, err := alice.CreateCollection(ctx, orgID, "servers")
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	_, err = alice.CreateSecret(ctx, client.Secret{Type: "text", Data: "text:team", CollectionID: collectionID})
+	require.NoError(t, err)
+
+	// The invited member gets the collection key wrapped for him.
+	invitationID, err := alice.InviteMember(ctx, orgID, "bob", "member")
+	require.NoError(t, err)
+	_, err = bob.CollectionSecrets(ctx, collectionID)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.NoError(t, bob.AcceptInvitation(ctx, invitationID))
+
+	secrets, err := bob.CollectionSecrets(ctx, collectionID)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "text:team", secrets[0].Data)
+	assert.Equal(t, collectionID, secrets[0].CollectionID)
+
+	// Export decrypts shares and collection secrets with the keys wrapped for the user.
+	require.NoError(t, alice.ShareSecret(ctx, id, "bob"))
+	export, err := bob.ExportAccount(ctx, false)
+	require.NoError(t, err)
+	require.NotNil(t, export.Keys)
+	assert.Empty(t, export.Secrets)
+	require.Len(t, export.Shares, 1)
+	assert.Equal(t, client.Share{SecretID: id, Owner: "alice", Data: "text:batch", Note: "note"}, export.Shares[0].Share)
+	assert.False(t, export.Shares[0].Encrypted)
+	require.Len(t, export.Organizations, 1)
+	assert.Equal(t, "member", export.Organizations[0].Role)
+	require.Len(t, export.Collections, 1)
+	require.Len(t, export.CollectionSecrets, 1)
+	assert.Equal(t, "text:team", export.CollectionSecrets[0].Data)
+	assert.Equal(t, collectionID, export.CollectionSecrets[0].CollectionID)
+	assert.False(t, export.CollectionSecrets[0].Encrypted)
+
+	// Raw export keeps everything encrypted.
+	export, err = bob.ExportAccount(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, export.Shares, 1)
+	assert.True(t, export.Shares[0].Encrypted)
+	assert.NotEqual(t, "note", export.Shares[0].Note)
+	require.Len(t, export.CollectionSecrets, 1)
+	assert.True(t, export.CollectionSecrets[0].Encrypted)
+}
+
+// Test case: sends are encrypted with the key from the link and opened without an account.
+func TestSendClient(t *testing.T) {
+	dial := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := newClient(t, dial)
+	_, err := c.Register(ctx, "testuser", "testpassword")
+	require.NoError(t, err)
+
+	link, err := c.CreateSend(ctx, "one-time payload", 1, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(link, "gophkeeper://bufnet/send/"))
+
+	payload, viewsLeft, err := client.OpenSend(ctx, link, dial)
+	require.NoError(t, err)
+	assert.Equal(t, "one-time payload", payload)
+	assert.Zero(t, viewsLeft)
+
+	_, _, err = client.OpenSend(ctx, link, dial)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, _, err = client.OpenSend(ctx, "https://bufnet/send/id", dial)
+	assert.ErrorContains(t, err, "invalid send link")
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrKeysExist is returned by InitKeys when the user already has sharing keys.
+var ErrKeysExist = errors.New("sharing keys already exist")
+
+// ErrNoKeys is returned when the user has no sharing keys to decrypt shared secrets and collection keys with.
+var ErrNoKeys = errors.New("no sharing keys found, generate them first")
+
+// InitKeys generates X25519 sharing keypair and uploads it to the server,
+// the private key is encrypted with the encryption key before upload.
+// Existing keys are replaced only if force is set, secrets shared earlier become unreadable then.
+func (c *Client) InitKeys(ctx context.Context, force bool) error {
+	key, err := c.encryptionKey()
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		_, err := c.keeper.GetUserKeys(ctx, &proto.GetUserKeysRequest{})
+		if err == nil {
+			return ErrKeysExist
+		}
+		if status.Code(err) != codes.NotFound {
+			return err
+		}
+	}
+
+	publicKey, privateKey, err := encryption.GenerateKeyPair()
+	if err != nil {
+		return fmt.Errorf("failed to generate keys: %w", err)
+	}
+	encryptedPrivateKey, err := encryption.EncryptWithKey(privateKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+
+	_, err = c.keeper.SetUserKeys(ctx, &proto.SetUserKeysRequest{
+		Keys: &proto.UserKeys{
+			PublicKey:           publicKey,
+			EncryptedPrivateKey: encryptedPrivateKey,
+		},
+	})
+
+	return err
+}
+
+// keyPair retrieves user's sharing keypair and decrypts the private key.
+func (c *Client) keyPair(ctx context.Context) (publicKey, privateKey string, err error) {
+	key, err := c.encryptionKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	resp, err := c.keeper.GetUserKeys(ctx, &proto.GetUserKeysRequest{})
+	if status.Code(err) == codes.NotFound {
+		return "", "", ErrNoKeys
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	privateKey, err = encryption.DecryptWithKey(resp.Keys.EncryptedPrivateKey, key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+
+	return resp.Keys.PublicKey, privateKey, nil
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateOrganization creates a new organization owned by the user, returns its id.
+func (c *Client) CreateOrganization(ctx context.Context, name string) (int64, error) {
+	resp, err := c.keeper.CreateOrganization(ctx, &proto.CreateOrganizationRequest{Name: name})
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.Id, nil
+}
+
+// ListOrganizations returns organizations the user is a member of with the user's roles.
+func (c *Client) ListOrganizations(ctx context.Context) ([]*proto.Organization, error) {
+	resp, err := c.keeper.ListOrganizations(ctx, &proto.ListOrganizationsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Organizations, nil
+}
+
+// ListMembers returns members of the organization.
+func (c *Client) ListMembers(ctx context.Context, orgID int64) ([]*proto.OrgMember, error) {
+	resp, err := c.keeper.ListMembers(ctx, &proto.ListMembersRequest{OrgId: orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Members, nil
+}
+
+// InviteMember invites the user to the organization with the role, returns the invitation id.
+// Keys of all organization collections the inviter has access to are wrapped with the invited user's public key.
+func (c *Client) InviteMember(ctx context.Context, orgID int64, username, role string) (int64, error) {
+	pubResp, err := c.keeper.GetPublicKey(ctx, &proto.GetPublicKeyRequest{Username: username})
+	if err != nil {
+		return 0, err
+	}
+
+	keysResp, err := c.keeper.GetCollectionKeys(ctx, &proto.GetCollectionKeysRequest{OrgId: orgID})
+	if err != nil {
+		return 0, err
+	}
+
+	// Re-wrap collection keys for the invited user.
+	var keys []*proto.CollectionKey
+	if len(keysResp.Keys) > 0 {
+		publicKey, privateKey, err := c.keyPair(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, k := range keysResp.Keys {
+			collectionKey, err := encryption.UnwrapKey(k.WrappedKey, publicKey, privateKey)
+			if err != nil {
+				return 0, fmt.Errorf("failed to unwrap collection key (id: %d): %w", k.CollectionId, err)
+			}
+			wrapped, err := encryption.WrapKey(collectionKey, pubResp.PublicKey)
+			if err != nil {
+				return 0, fmt.Errorf("failed to wrap collection key (id: %d): %w", k.CollectionId, err)
+			}
+			keys = append(keys, &proto.CollectionKey{
+				CollectionId: k.CollectionId,
+				Username:     username,
+				WrappedKey:   wrapped,
+			})
+		}
+	}
+
+	resp, err := c.keeper.InviteMember(ctx, &proto.InviteMemberRequest{
+		OrgId:    orgID,
+		Username: username,
+		Role:     role,
+		Keys:     keys,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.Id, nil
+}
+
+// ListInvitations returns pending invitations of the user.
+func (c *Client) ListInvitations(ctx context.Context) ([]*proto.Invitation, error) {
+	resp, err := c.keeper.ListInvitations(ctx, &proto.ListInvitationsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Invitations, nil
+}
+
+// AcceptInvitation accepts the invitation to an organization.
+func (c *Client) AcceptInvitation(ctx context.Context, id int64) error {
+	_, err := c.keeper.AcceptInvitation(ctx, &proto.AcceptInvitationRequest{Id: id})
+	return err
+}
+
+// RemoveMember removes the member from the organization.
+func (c *Client) RemoveMember(ctx context.Context, orgID int64, username string) error {
+	_, err := c.keeper.RemoveMember(ctx, &proto.RemoveMemberRequest{OrgId: orgID, Username: username})
+	return err
+}
+
+// SetMemberRole changes the role of the organization member.
+func (c *Client) SetMemberRole(ctx context.Context, orgID int64, username, role string) error {
+	_, err := c.keeper.SetMemberRole(ctx, &proto.SetMemberRoleRequest{OrgId: orgID, Username: username, Role: role})
+	return err
+}
+
+// CreateCollection creates a collection in the organization, returns its id.
+// A random collection key is wrapped for every member having sharing keys,
+// the members without them are returned in skipped and get no access to the collection.
+func (c *Client) CreateCollection(ctx context.Context, orgID int64, name string) (id int64, skipped []string, err error) {
+	members, err := c.ListMembers(ctx, orgID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	collectionKey, err := encryption.GenerateDataKey()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to generate collection key: %w", err)
+	}
+
+	var keys []*proto.CollectionKey
+	for _, m := range members {
+		if m.PublicKey == "" {
+			skipped = append(skipped, m.Username)
+			continue
+		}
+		wrapped, err := encryption.WrapKey(collectionKey, m.PublicKey)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to wrap collection key for %s: %w", m.Username, err)
+		}
+		keys = append(keys, &proto.CollectionKey{
+			Username:   m.Username,
+			WrappedKey: wrapped,
+		})
+	}
+
+	resp, err := c.keeper.CreateCollection(ctx, &proto.CreateCollectionRequest{
+		OrgId: orgID,
+		Name:  name,
+		Keys:  keys,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return resp.Id, skipped, nil
+}
+
+// ListCollections returns collections of the organization the user has access to, all organizations if orgID is zero.
+func (c *Client) ListCollections(ctx context.Context, orgID int64) ([]*proto.CollectionKey, error) {
+	resp, err := c.keeper.GetCollectionKeys(ctx, &proto.GetCollectionKeysRequest{OrgId: orgID})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Keys, nil
+}
+
+// CollectionSecrets returns decrypted secrets of the collection.
+// Secrets failed to decrypt are skipped and reported in DecryptError returned along with the rest.
+func (c *Client) CollectionSecrets(ctx context.Context, collectionID int64) ([]Secret, error) {
+	collectionKey, err := c.collectionKey(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.keeper.GetCollectionSecrets(ctx, &proto.GetCollectionSecretsRequest{CollectionId: collectionID})
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := decryptAll(resp.Secret, collectionKey)
+	for i := range secrets {
+		secrets[i].CollectionID = collectionID
+	}
+
+	return secrets, err
+}
+
+// collectionKey retrieves the collection key wrapped for the user and unwraps it with user's private key.
+func (c *Client) collectionKey(ctx context.Context, collectionID int64) (string, error) {
+	collections, err := c.ListCollections(ctx, 0)
+	if err != nil {
+		return "", err
+	}
+
+	for _, k := range collections {
+		if k.CollectionId != collectionID {
+			continue
+		}
+		publicKey, privateKey, err := c.keyPair(ctx)
+		if err != nil {
+			return "", err
+		}
+		collectionKey, err := encryption.UnwrapKey(k.WrappedKey, publicKey, privateKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to unwrap collection key: %w", err)
+		}
+		return collectionKey, nil
+	}
+
+	return "", status.Errorf(codes.PermissionDenied, "no access to collection (id: %d)", collectionID)
+}
@@ -0,0 +1,510 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// listPageSize is the number of secrets requested in one page.
+const listPageSize = 500
+
+// Fields of secrets indexed with blind indexes.
+const (
+	loginIndex  = "login"
+	folderIndex = "folder"
+	tagIndex    = "tag"
+)
+
+// Secret is a decrypted secret.
+type Secret struct {
+	// ID is the UUID of the secret, generated on creation if empty.
+	ID string
+	// Type is the secret type: card, credentials, text, bin or otp.
+	Type string
+	// Data is the plaintext of the secret: optional folder and tags fields followed by the typed fields,
+	// e.g. "folder:work;tags:a,b;login:user;password:pass".
+	Data string
+	// Note is the plaintext note stored in the secret meta.
+	Note string
+	// ExpiresAt is the time the secret is deleted at, nil if it never expires.
+	ExpiresAt *time.Time
+	// UpdatedAt is the time of the last change on the server, zero for secrets not saved yet.
+	UpdatedAt time.Time
+	// CollectionID is the organization collection of the secret, zero for personal secrets.
+	// Collection secrets are encrypted with the collection key.
+	CollectionID int64
+}
+
+// Filter selects secrets by type and the time of the last change, zero fields match every secret.
+type Filter struct {
+	Type         string
+	UpdatedAfter time.Time
+}
+
+// Match reports whether the secret matches the filter.
+func (f Filter) Match(s Secret) bool {
+	if f.Type != "" && s.Type != f.Type {
+		return false
+	}
+
+	return f.UpdatedAfter.IsZero() || s.UpdatedAt.After(f.UpdatedAfter)
+}
+
+// DecryptError is returned along with the successfully decrypted secrets
+// when some of the received secrets can't be decrypted.
+type DecryptError struct {
+	// Failed are the decryption errors by secret id.
+	Failed map[string]error
+}
+
+// Error implements error interface.
+func (e *DecryptError) Error() string {
+	ids := make([]string, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return fmt.Sprintf("failed to decrypt secrets: %s", strings.Join(ids, ", "))
+}
+
+// add records the failure to decrypt the secret.
+func (e *DecryptError) add(id string, err error) {
+	if e.Failed == nil {
+		e.Failed = make(map[string]error)
+	}
+	e.Failed[id] = err
+}
+
+// err returns the error if any secret failed to decrypt, nil otherwise.
+func (e *DecryptError) err() error {
+	if len(e.Failed) == 0 {
+		return nil
+	}
+	return e
+}
+
+// EncryptSecret encrypts the personal secret with the encryption key and computes its blind indexes.
+func (c *Client) EncryptSecret(s Secret) (*proto.Secret, error) {
+	key, err := c.encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := encryptSecret(s, key)
+	if err != nil {
+		return nil, err
+	}
+	encrypted.SearchTokens = secretSearchTokens(key, s.Data)
+
+	return encrypted, nil
+}
+
+// DecryptSecret decrypts the personal secret received from the server.
+func (c *Client) DecryptSecret(id string, s *proto.Secret) (Secret, error) {
+	key, err := c.encryptionKey()
+	if err != nil {
+		return Secret{}, err
+	}
+
+	return decryptSecret(id, s, key)
+}
+
+// encryptSecret encrypts the secret with the key.
+func encryptSecret(s Secret, key string) (*proto.Secret, error) {
+	data, err := encryption.EncryptWithKey(s.Data, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	meta, err := encryption.EncryptWithKey(s.Note, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt metadata: %w", err)
+	}
+
+	encrypted := &proto.Secret{
+		Data: data,
+		Meta: meta,
+		Type: s.Type,
+	}
+	if s.ExpiresAt != nil {
+		encrypted.ExpiresAt = timestamppb.New(*s.ExpiresAt)
+	}
+
+	return encrypted, nil
+}
+
+// decryptSecret decrypts the secret with the key.
+func decryptSecret(id string, s *proto.Secret, key string) (Secret, error) {
+	data, err := encryption.DecryptWithKey(s.GetData(), key)
+	if err != nil {
+		return Secret{}, err
+	}
+	meta, err := encryption.DecryptWithKey(s.GetMeta(), key)
+	if err != nil {
+		return Secret{}, err
+	}
+
+	return newSecret(id, s, data, meta), nil
+}
+
+// newSecret creates the secret with the data and note and other fields of the received one.
+func newSecret(id string, s *proto.Secret, data, note string) Secret {
+	secret := Secret{
+		ID:   id,
+		Type: s.GetType(),
+		Data: data,
+		Note: note,
+	}
+	if s.GetExpiresAt() != nil {
+		t := s.GetExpiresAt().AsTime()
+		secret.ExpiresAt = &t
+	}
+	if s.GetUpdatedAt() != nil {
+		secret.UpdatedAt = s.GetUpdatedAt().AsTime()
+	}
+
+	return secret
+}
+
+// decryptAll decrypts the secrets with the key, the ones failed to decrypt are reported in DecryptError.
+func decryptAll(secrets []*proto.CountedSecret, key string) ([]Secret, error) {
+	decrypted := make([]Secret, 0, len(secrets))
+	var decryptErr DecryptError
+	for _, s := range secrets {
+		secret, err := decryptSecret(s.Id, s.Secret, key)
+		if err != nil {
+			decryptErr.add(s.Id, err)
+			continue
+		}
+		decrypted = append(decrypted, secret)
+	}
+
+	return decrypted, decryptErr.err()
+}
+
+// SearchTokens computes blind index tokens of the secret fields with a key derived from the master key.
+// Every ancestor of the folder is indexed so that searching a folder finds secrets of its subfolders.
+func SearchTokens(masterKey, login, folder string, tags []string) []string {
+	var folders []string
+	if folder != "" {
+		parts := strings.Split(folder, "/")
+		for i := range parts {
+			folders = append(folders, strings.Join(parts[:i+1], "/"))
+		}
+	}
+
+	return blindIndexes(masterKey, login, folders, tags)
+}
+
+// blindIndexes computes blind index tokens of the non-empty login, folders and tags.
+func blindIndexes(masterKey, login string, folders, tags []string) []string {
+	key := encryption.BlindIndexKey(masterKey)
+
+	var tokens []string
+	if login != "" {
+		tokens = append(tokens, encryption.BlindIndex(key, loginIndex, login))
+	}
+	for _, folder := range folders {
+		tokens = append(tokens, encryption.BlindIndex(key, folderIndex, folder))
+	}
+	for _, tag := range tags {
+		tokens = append(tokens, encryption.BlindIndex(key, tagIndex, tag))
+	}
+
+	return tokens
+}
+
+// secretSearchTokens computes blind index tokens of the login, folder and tags of the secret plaintext.
+func secretSearchTokens(masterKey, data string) []string {
+	fields := vault.ParseFields(data, "")
+
+	var tags []string
+	if fields[vault.FieldTags] != "" {
+		tags = strings.Split(fields[vault.FieldTags], ",")
+	}
+
+	return SearchTokens(masterKey, fields["login"], fields[vault.FieldFolder], tags)
+}
+
+// sealSecret encrypts the secret with the key it belongs to: the collection key for collection secrets
+// or the encryption key for personal ones. Collection secrets are not indexed because the index key is personal.
+func (c *Client) sealSecret(ctx context.Context, s Secret) (*proto.Secret, error) {
+	if s.CollectionID == 0 {
+		return c.EncryptSecret(s)
+	}
+
+	collectionKey, err := c.collectionKey(ctx, s.CollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptSecret(s, collectionKey)
+}
+
+// CreateSecret encrypts and saves a new secret, returns its id.
+// The id is generated on the client if not set, so a retried request doesn't create a duplicate.
+func (c *Client) CreateSecret(ctx context.Context, s Secret) (string, error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+
+	encrypted, err := c.sealSecret(ctx, s)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.keeper.AddSecret(ctx, &proto.AddSecretRequest{
+		Id:           s.ID,
+		Secret:       encrypted,
+		CollectionId: s.CollectionID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Id, nil
+}
+
+// UpdateSecret encrypts and saves the new content of the secret.
+// The expiration time is kept if the secret has none unless clearExpiresAt is set.
+// Copies of the secret shared with other users are re-encrypted with the new content.
+func (c *Client) UpdateSecret(ctx context.Context, s Secret, clearExpiresAt bool) error {
+	encrypted, err := c.sealSecret(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	req := &proto.EditSecretRequest{
+		Id:             s.ID,
+		Secret:         encrypted,
+		ClearExpiresAt: clearExpiresAt,
+	}
+	// Copies of the personal secret shared with other users are re-encrypted along with the edit.
+	if s.CollectionID == 0 {
+		recipients, err := c.shareRecipients(ctx, s.ID)
+		if err != nil {
+			return err
+		}
+		for _, r := range recipients {
+			copies, err := sharedCopies(s, r)
+			if err != nil {
+				return err
+			}
+			req.Shares = append(req.Shares, copies...)
+		}
+	}
+
+	_, err = c.keeper.EditSecret(ctx, req)
+
+	return err
+}
+
+// listRequest converts the filter to the request of the first page.
+func (f Filter) listRequest() *proto.ListSecretsRequest {
+	req := &proto.ListSecretsRequest{PageSize: listPageSize, Type: f.Type}
+	if !f.UpdatedAfter.IsZero() {
+		req.UpdatedAfter = timestamppb.New(f.UpdatedAfter)
+	}
+
+	return req
+}
+
+// ListSecrets returns all personal secrets matching the filter walking all pages, every page has its own timeout.
+// Secrets failed to decrypt are skipped and reported in DecryptError returned along with the rest.
+func (c *Client) ListSecrets(ctx context.Context, f Filter) ([]Secret, error) {
+	key, err := c.encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []*proto.CountedSecret
+	req := f.listRequest()
+	for {
+		resp, err := c.keeper.ListSecrets(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, resp.Secret...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+
+	return decryptAll(secrets, key)
+}
+
+// StreamSecrets calls fn for every personal secret matching the filter as soon as it is received.
+// Secrets failed to decrypt are skipped and reported in DecryptError after the stream ends.
+// The stream is limited by the context only.
+func (c *Client) StreamSecrets(ctx context.Context, f Filter, fn func(Secret) error) error {
+	key, err := c.encryptionKey()
+	if err != nil {
+		return err
+	}
+
+	req := &proto.StreamSecretsRequest{Type: f.Type}
+	if !f.UpdatedAfter.IsZero() {
+		req.UpdatedAfter = timestamppb.New(f.UpdatedAfter)
+	}
+	stream, err := c.keeper.StreamSecrets(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	var decryptErr DecryptError
+	for {
+		s, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return decryptErr.err()
+		}
+		if err != nil {
+			return err
+		}
+
+		secret, err := decryptSecret(s.Id, s.Secret, key)
+		if err != nil {
+			decryptErr.add(s.Id, err)
+			continue
+		}
+		if err := fn(secret); err != nil {
+			return err
+		}
+	}
+}
+
+// SearchSecrets returns personal secrets having all the given non-empty login, folder (including subfolders) and tags.
+// The server matches keyed blind index tokens and never sees the searched values,
+// only secrets saved with blind indexes can be found.
+// Secrets have no site field, so search by site is not supported.
+func (c *Client) SearchSecrets(ctx context.Context, login, folder string, tags []string) ([]Secret, error) {
+	key, err := c.encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []string
+	if folder != "" {
+		folders = []string{folder}
+	}
+	tokens := blindIndexes(key, login, folders, tags)
+	if len(tokens) == 0 {
+		return nil, errors.New("at least one of login, folder or tags must be provided")
+	}
+
+	resp, err := c.keeper.SearchSecrets(ctx, &proto.SearchSecretsRequest{Tokens: tokens})
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptAll(resp.Secret, key)
+}
+
+// MutationOp is the operation of a secret mutation.
+type MutationOp int
+
+// Operations of secret mutations.
+const (
+	MutationCreate MutationOp = iota + 1
+	MutationUpdate
+	MutationDelete
+)
+
+// Mutation is a change of a personal secret applied in a batch.
+type Mutation struct {
+	Op MutationOp
+	// Secret is the new content of the secret, only its ID is used by deletion.
+	Secret Secret
+	// ClearExpiresAt removes the expiration time of the updated secret.
+	ClearExpiresAt bool
+}
+
+// MutationResult is the result of a mutation applied in a batch.
+type MutationResult struct {
+	ID string
+	// Err is the status error of the failed mutation, nil if it succeeded.
+	Err error
+}
+
+// MutateSecrets applies the mutations of personal secrets in one request.
+// The batch is atomic unless bestEffort is set: committed is false and no mutation is applied if any of them fails.
+// With bestEffort every mutation is applied independently.
+// Copies of the updated secrets shared with other users are re-encrypted with the new content.
+func (c *Client) MutateSecrets(ctx context.Context, mutations []Mutation, bestEffort bool) (results []MutationResult, committed bool, err error) {
+	// Copies of the updated secrets shared with other users are re-encrypted along with the update.
+	var recipients map[string][]*proto.ShareRecipient
+	if slices.ContainsFunc(mutations, func(m Mutation) bool { return m.Op == MutationUpdate }) {
+		if recipients, err = c.shareRecipients(ctx, ""); err != nil {
+			return nil, false, err
+		}
+	}
+
+	req := &proto.BatchMutateSecretsRequest{BestEffort: bestEffort}
+	for _, m := range mutations {
+		pm := &proto.SecretMutation{Id: m.Secret.ID, ClearExpiresAt: m.ClearExpiresAt}
+		switch m.Op {
+		case MutationCreate:
+			pm.Op = proto.MutationOp_MUTATION_OP_CREATE
+		case MutationUpdate:
+			pm.Op = proto.MutationOp_MUTATION_OP_UPDATE
+		case MutationDelete:
+			pm.Op = proto.MutationOp_MUTATION_OP_DELETE
+		default:
+			return nil, false, fmt.Errorf("unknown mutation operation %d of secret %s", m.Op, m.Secret.ID)
+		}
+		if m.Op != MutationDelete {
+			if pm.Secret, err = c.EncryptSecret(m.Secret); err != nil {
+				return nil, false, err
+			}
+		}
+		if m.Op == MutationUpdate {
+			if pm.Shares, err = sharedCopies(m.Secret, recipients[m.Secret.ID]); err != nil {
+				return nil, false, err
+			}
+		}
+		req.Mutations = append(req.Mutations, pm)
+	}
+
+	resp, err := c.keeper.BatchMutateSecrets(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	results = make([]MutationResult, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		result := MutationResult{ID: r.Id}
+		if code := codes.Code(r.Code); code != codes.OK {
+			result.Err = status.Error(code, r.Message)
+		}
+		results = append(results, result)
+	}
+
+	return results, resp.Committed, nil
+}
+
+// DeleteSecret deletes the personal secret.
+func (c *Client) DeleteSecret(ctx context.Context, id string) error {
+	results, _, err := c.MutateSecrets(ctx, []Mutation{{Op: MutationDelete, Secret: Secret{ID: id}}}, false)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no result of deleting secret %s", id)
+	}
+
+	return results[0].Err
+}
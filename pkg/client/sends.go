@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SendScheme is the URL scheme of send links.
+const SendScheme = "gophkeeper"
+
+// CreateSend creates an ephemeral send of the payload and returns its link.
+// The payload is encrypted with a random key which is kept in the link fragment and never sent to the server.
+func (c *Client) CreateSend(ctx context.Context, payload string, maxViews int32, expiresAt time.Time) (string, error) {
+	rawKey := make([]byte, 32)
+	if _, err := rand.Read(rawKey); err != nil {
+		return "", fmt.Errorf("failed to generate send key: %w", err)
+	}
+	key := base64.RawURLEncoding.EncodeToString(rawKey)
+	ciphertext, err := encryption.EncryptWithKey(payload, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+
+	resp, err := c.keeper.CreateSend(ctx, &proto.CreateSendRequest{
+		Ciphertext: ciphertext,
+		MaxViews:   maxViews,
+		ExpiresAt:  timestamppb.New(expiresAt),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	link := url.URL{
+		Scheme:   SendScheme,
+		Host:     c.address,
+		Path:     "/send/" + resp.Id,
+		Fragment: key,
+	}
+
+	return link.String(), nil
+}
+
+// OpenSend retrieves and decrypts the send by its link, connecting to the server from the link.
+// Opening doesn't require an account, every opening burns one view of the send.
+func OpenSend(ctx context.Context, link string, opts ...Option) (payload string, viewsLeft int32, err error) {
+	address, id, key, err := ParseSendLink(link)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid send link: %w", err)
+	}
+
+	c, err := New(address, opts...)
+	if err != nil {
+		return "", 0, err
+	}
+	defer c.Close()
+
+	resp, err := c.keeper.OpenSend(ctx, &proto.OpenSendRequest{Id: id})
+	if err != nil {
+		return "", 0, err
+	}
+
+	payload, err = encryption.DecryptWithKey(resp.Ciphertext, key)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to decrypt send: %w", err)
+	}
+
+	return payload, resp.ViewsLeft, nil
+}
+
+// ParseSendLink extracts server address, send id and key from the send link.
+func ParseSendLink(link string) (address, id, key string, err error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	id, ok := strings.CutPrefix(u.Path, "/send/")
+	if u.Scheme != SendScheme || u.Host == "" || !ok || id == "" || u.Fragment == "" {
+		return "", "", "", fmt.Errorf("expected %s://host:port/send/<id>#<key>", SendScheme)
+	}
+
+	return u.Host, id, u.Fragment, nil
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
+	"github.com/KirillZiborov/GophKeeper/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrSecretNotFound is returned when the secret to share is not found.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Share is a decrypted secret shared with the user read-only.
+type Share struct {
+	SecretID string
+	Owner    string
+	Data     string
+	Note     string
+}
+
+// ShareSecret shares the personal secret with another user read-only.
+// The secret is re-encrypted with a random data key wrapped with recipient's public key.
+// Sharing the secret again updates the recipient's copy, edits of the secret update the copies of all recipients.
+func (c *Client) ShareSecret(ctx context.Context, secretID, recipient string) error {
+	key, err := c.encryptionKey()
+	if err != nil {
+		return err
+	}
+
+	pubResp, err := c.keeper.GetPublicKey(ctx, &proto.GetPublicKeyRequest{Username: recipient})
+	if err != nil {
+		return err
+	}
+
+	// Only the shared secret is fetched, ids which can't be user's secrets are reported as not found.
+	resp, err := c.keeper.GetSecretById(ctx, &proto.GetSecretByIdRequest{Id: secretID})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound, codes.InvalidArgument, codes.PermissionDenied:
+		return fmt.Errorf("%w (id: %s)", ErrSecretNotFound, secretID)
+	default:
+		return err
+	}
+
+	secret, err := decryptSecret(resp.Secret.Id, resp.Secret.Secret, key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	wrappedKey, shared, err := encryptShare(secret, pubResp.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.keeper.ShareSecret(ctx, &proto.ShareSecretRequest{
+		SecretId:   secretID,
+		Recipient:  recipient,
+		WrappedKey: wrappedKey,
+		Secret:     shared,
+	})
+
+	return err
+}
+
+// encryptShare encrypts the data and note of the secret with a random data key,
+// returns the data key wrapped with the recipient's public key and the encrypted secret.
+func encryptShare(s Secret, publicKey string) (string, *proto.Secret, error) {
+	dataKey, err := encryption.GenerateDataKey()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	shared, err := encryptSecret(Secret{Data: s.Data, Note: s.Note}, dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+	wrappedKey, err := encryption.WrapKey(dataKey, publicKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return wrappedKey, shared, nil
+}
+
+// shareRecipients returns users the personal secrets are shared with by secret id,
+// only the recipients of the secret with secretID if it is set.
+func (c *Client) shareRecipients(ctx context.Context, secretID string) (map[string][]*proto.ShareRecipient, error) {
+	resp, err := c.keeper.ListShares(ctx, &proto.ListSharesRequest{SecretId: secretID})
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := make(map[string][]*proto.ShareRecipient)
+	for _, r := range resp.Shares {
+		recipients[r.SecretId] = append(recipients[r.SecretId], r)
+	}
+
+	return recipients, nil
+}
+
+// sharedCopies re-encrypts the edited secret for every recipient it is shared with.
+// The server replaces the copies of the recipients along with the edit.
+func sharedCopies(s Secret, recipients []*proto.ShareRecipient) ([]*proto.SharedCopy, error) {
+	copies := make([]*proto.SharedCopy, 0, len(recipients))
+	for _, r := range recipients {
+		wrappedKey, shared, err := encryptShare(s, r.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, &proto.SharedCopy{
+			Recipient:  r.Recipient,
+			WrappedKey: wrappedKey,
+			Secret:     shared,
+		})
+	}
+
+	return copies, nil
+}
+
+// ListSharedWithMe returns decrypted secrets shared with the user.
+// Secrets failed to decrypt are skipped and reported in DecryptError returned along with the rest.
+func (c *Client) ListSharedWithMe(ctx context.Context) ([]Share, error) {
+	resp, err := c.keeper.ListSharedWithMe(ctx, &proto.ListSharedWithMeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Shares) == 0 {
+		return nil, nil
+	}
+
+	publicKey, privateKey, err := c.keyPair(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]Share, 0, len(resp.Shares))
+	var decryptErr DecryptError
+	for _, sh := range resp.Shares {
+		dataKey, err := encryption.UnwrapKey(sh.WrappedKey, publicKey, privateKey)
+		if err != nil {
+			decryptErr.add(sh.SecretId, fmt.Errorf("failed to unwrap key: %w", err))
+			continue
+		}
+		secret, err := decryptSecret(sh.SecretId, sh.Secret, dataKey)
+		if err != nil {
+			decryptErr.add(sh.SecretId, err)
+			continue
+		}
+		shares = append(shares, Share{
+			SecretID: sh.SecretId,
+			Owner:    sh.Owner,
+			Data:     secret.Data,
+			Note:     secret.Note,
+		})
+	}
+
+	return shares, decryptErr.err()
+}
+
+// RevokeShare revokes access of the recipient to the shared secret.
+func (c *Client) RevokeShare(ctx context.Context, secretID, recipient string) error {
+	_, err := c.keeper.RevokeShare(ctx, &proto.RevokeShareRequest{
+		SecretId:  secretID,
+		Recipient: recipient,
+	})
+
+	return err
+}
@@ -0,0 +1,29 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// blindIndexContext separates the blind index key from other keys derived from the master key.
+const blindIndexContext = "gophkeeper blind index v1"
+
+// BlindIndexKey derives the key of blind indexes from the master encryption key.
+func BlindIndexKey(masterKey string) []byte {
+	mac := hmac.New(sha256.New, []byte(masterKey))
+	mac.Write([]byte(blindIndexContext))
+	return mac.Sum(nil)
+}
+
+// BlindIndex computes the hex encoded HMAC-SHA256 token of the field value.
+// Values are compared case-insensitively, the field name is a part of the token
+// so equal values of different fields have different tokens.
+func BlindIndex(indexKey []byte, field, value string) string {
+	mac := hmac.New(sha256.New, indexKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
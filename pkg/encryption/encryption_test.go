@@ -30,3 +30,37 @@ func TestHashUnhash(t *testing.T) {
 	err = encryption.CheckPasswordHash(password, hashed)
 	require.NoError(t, err)
 }
+
+func TestWrapUnwrapKey(t *testing.T) {
+	pub, priv, err := encryption.GenerateKeyPair()
+	require.NoError(t, err)
+
+	dataKey, err := encryption.GenerateDataKey()
+	require.NoError(t, err)
+
+	wrapped, err := encryption.WrapKey(dataKey, pub)
+	require.NoError(t, err)
+
+	unwrapped, err := encryption.UnwrapKey(wrapped, pub, priv)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped, "Unwrapped key should match the original")
+
+	// Other keypair can't unwrap the key.
+	otherPub, otherPriv, err := encryption.GenerateKeyPair()
+	require.NoError(t, err)
+	_, err = encryption.UnwrapKey(wrapped, otherPub, otherPriv)
+	require.Error(t, err)
+}
+
+func TestBlindIndex(t *testing.T) {
+	key := encryption.BlindIndexKey("master-key")
+
+	token := encryption.BlindIndex(key, "login", "User@Example.com")
+	assert.Len(t, token, 64)
+	assert.Equal(t, token, encryption.BlindIndex(key, "login", " user@example.com "), "Tokens should be case-insensitive")
+	assert.NotEqual(t, token, encryption.BlindIndex(key, "tag", "user@example.com"), "Tokens should depend on the field")
+
+	// Other master key gives other tokens.
+	otherKey := encryption.BlindIndexKey("other-key")
+	assert.NotEqual(t, token, encryption.BlindIndex(otherKey, "login", "user@example.com"))
+}
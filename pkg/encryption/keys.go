@@ -0,0 +1,86 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// ErrBadKey is returned when a key has a wrong encoding or length.
+var ErrBadKey = errors.New("bad key: expected base64 encoded 32 bytes")
+
+// GenerateKeyPair generates X25519 keypair used for sharing secrets between users.
+// Both keys are base64 encoded.
+func GenerateKeyPair() (publicKey, privateKey string, err error) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(pub[:]), base64.StdEncoding.EncodeToString(priv[:]), nil
+}
+
+// GenerateDataKey generates a random base64 encoded 32 bytes key
+// which can be used as encryption key for EncryptWithKey.
+func GenerateDataKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// WrapKey encrypts dataKey for the owner of publicKey using anonymous NaCl box
+// (X25519 with an ephemeral sender key and XSalsa20-Poly1305).
+func WrapKey(dataKey, publicKey string) (string, error) {
+	pub, err := decodeKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := box.SealAnonymous(nil, []byte(dataKey), pub, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+// UnwrapKey decrypts a key wrapped by WrapKey using recipient's keypair.
+func UnwrapKey(wrappedKey, publicKey, privateKey string) (string, error) {
+	pub, err := decodeKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	priv, err := decodeKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedKey)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, ok := box.OpenAnonymous(nil, wrapped, pub, priv)
+	if !ok {
+		return "", errors.New("failed to unwrap key")
+	}
+
+	return string(dataKey), nil
+}
+
+// decodeKey decodes base64 encoded 32 bytes key.
+func decodeKey(key string) (*[32]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(raw) != 32 {
+		return nil, ErrBadKey
+	}
+
+	var k [32]byte
+	copy(k[:], raw)
+	return &k, nil
+}
@@ -0,0 +1,184 @@
+// Package otp parses TOTP keys given as otpauth:// URIs or raw base32 seeds
+// and computes time-based one-time passwords as described in RFC 6238.
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HMAC algorithms of the codes.
+const (
+	SHA1   = "SHA1"
+	SHA256 = "SHA256"
+	SHA512 = "SHA512"
+)
+
+// Defaults of the key parameters omitted in URIs.
+const (
+	DefaultAlgorithm = SHA1
+	DefaultDigits    = 6
+	DefaultPeriod    = 30
+)
+
+// hashes are the hash functions of the algorithms.
+var hashes = map[string]func() hash.Hash{
+	SHA1:   sha1.New,
+	SHA256: sha256.New,
+	SHA512: sha512.New,
+}
+
+// Key is a TOTP key.
+type Key struct {
+	// Issuer is the provider of the account, optional.
+	Issuer string
+	// Account is the name of the account, optional.
+	Account string
+	// Secret is the shared seed.
+	Secret []byte
+	// Algorithm is the HMAC algorithm: SHA1, SHA256 or SHA512.
+	Algorithm string
+	// Digits is the number of digits of a code: 6 or 8.
+	Digits int
+	// Period is the number of seconds a code is valid for.
+	Period int
+}
+
+// Parse parses an otpauth://totp/ URI or a raw base32 seed, which gets the default parameters.
+func Parse(s string) (Key, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(strings.ToLower(s), "otpauth:") {
+		secret, err := decodeSecret(s)
+		if err != nil {
+			return Key{}, err
+		}
+		k := Key{Secret: secret, Algorithm: DefaultAlgorithm, Digits: DefaultDigits, Period: DefaultPeriod}
+		return k, k.Validate()
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return Key{}, fmt.Errorf("invalid otpauth URI: %w", err)
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return Key{}, fmt.Errorf("unsupported OTP type %q, only totp is supported", u.Host)
+	}
+
+	k := Key{Algorithm: DefaultAlgorithm, Digits: DefaultDigits, Period: DefaultPeriod}
+	// The label is "issuer:account" or just the account.
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		k.Issuer, k.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		k.Account = strings.TrimSpace(label)
+	}
+
+	q := u.Query()
+	if issuer := q.Get("issuer"); issuer != "" {
+		k.Issuer = issuer
+	}
+	if k.Secret, err = decodeSecret(q.Get("secret")); err != nil {
+		return Key{}, err
+	}
+	if algorithm := q.Get("algorithm"); algorithm != "" {
+		k.Algorithm = strings.ToUpper(algorithm)
+	}
+	if digits := q.Get("digits"); digits != "" {
+		if k.Digits, err = strconv.Atoi(digits); err != nil {
+			return Key{}, fmt.Errorf("invalid digits: %q", digits)
+		}
+	}
+	if period := q.Get("period"); period != "" {
+		if k.Period, err = strconv.Atoi(period); err != nil {
+			return Key{}, fmt.Errorf("invalid period: %q", period)
+		}
+	}
+
+	return k, k.Validate()
+}
+
+// decodeSecret decodes the base32 seed ignoring case, spaces and padding.
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.TrimRight(strings.ReplaceAll(s, " ", ""), "="))
+	if s == "" {
+		return nil, errors.New("secret is required")
+	}
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, errors.New("secret must be base32 encoded")
+	}
+
+	return secret, nil
+}
+
+// Validate checks the parameters of the key.
+func (k Key) Validate() error {
+	if len(k.Secret) == 0 {
+		return errors.New("secret is required")
+	}
+	if _, ok := hashes[k.Algorithm]; !ok {
+		return fmt.Errorf("unsupported algorithm %q, expected SHA1, SHA256 or SHA512", k.Algorithm)
+	}
+	if k.Digits != 6 && k.Digits != 8 {
+		return fmt.Errorf("digits must be 6 or 8, got %d", k.Digits)
+	}
+	if k.Period <= 0 {
+		return fmt.Errorf("period must be positive, got %d", k.Period)
+	}
+
+	return nil
+}
+
+// URI returns the otpauth://totp/ URI of the key with all its parameters.
+func (k Key) URI() string {
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+
+	q := url.Values{}
+	q.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret))
+	if k.Issuer != "" {
+		q.Set("issuer", k.Issuer)
+	}
+	q.Set("algorithm", k.Algorithm)
+	q.Set("digits", strconv.Itoa(k.Digits))
+	q.Set("period", strconv.Itoa(k.Period))
+
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
+
+// Code returns the code valid at the time and the time left until the next one.
+func (k Key) Code(t time.Time) (code string, remaining time.Duration) {
+	period := int64(k.Period)
+	counter := t.Unix() / period
+	next := time.Unix((counter+1)*period, 0)
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(hashes[k.Algorithm], k.Secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation of RFC 4226.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for range k.Digits {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", k.Digits, value%mod), next.Sub(t)
+}
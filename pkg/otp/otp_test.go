@@ -0,0 +1,75 @@
+package otp_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/otp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCode checks the test vectors of RFC 6238.
+func TestCode(t *testing.T) {
+	seeds := map[string]string{
+		otp.SHA1:   "12345678901234567890",
+		otp.SHA256: "12345678901234567890123456789012",
+		otp.SHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	tests := []struct {
+		time  int64
+		codes map[string]string
+	}{
+		{time: 59, codes: map[string]string{otp.SHA1: "94287082", otp.SHA256: "46119246", otp.SHA512: "90693936"}},
+		{time: 1111111109, codes: map[string]string{otp.SHA1: "07081804", otp.SHA256: "68084774", otp.SHA512: "25091201"}},
+		{time: 1234567890, codes: map[string]string{otp.SHA1: "89005924", otp.SHA256: "91819424", otp.SHA512: "93441116"}},
+		{time: 20000000000, codes: map[string]string{otp.SHA1: "65353130", otp.SHA256: "77737706", otp.SHA512: "47863826"}},
+	}
+
+	for _, tt := range tests {
+		for algorithm, want := range tt.codes {
+			k := otp.Key{Secret: []byte(seeds[algorithm]), Algorithm: algorithm, Digits: 8, Period: 30}
+			code, remaining := k.Code(time.Unix(tt.time, 0))
+			assert.Equal(t, want, code, "%s at %d", algorithm, tt.time)
+			assert.Equal(t, time.Duration(30-tt.time%30)*time.Second, remaining)
+		}
+	}
+
+	// The first minute of a 60 second period is the counter 0 of RFC 4226.
+	k := otp.Key{Secret: []byte(seeds[otp.SHA1]), Algorithm: otp.SHA1, Digits: 6, Period: 60}
+	code, remaining := k.Code(time.Unix(59, 0))
+	assert.Equal(t, "755224", code)
+	assert.Equal(t, time.Second, remaining)
+}
+
+func TestParse(t *testing.T) {
+	seed := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	k, err := otp.Parse("otpauth://totp/ACME%20Co:alice@example.com?secret=" + seed + "&issuer=ACME%20Co&algorithm=sha256&digits=8&period=60")
+	require.NoError(t, err)
+	assert.Equal(t, otp.Key{Issuer: "ACME Co", Account: "alice@example.com", Secret: []byte("12345678901234567890"), Algorithm: otp.SHA256, Digits: 8, Period: 60}, k)
+
+	// The normalized URI is parsed to the same key.
+	again, err := otp.Parse(k.URI())
+	require.NoError(t, err)
+	assert.Equal(t, k, again)
+
+	// Raw seeds get the default parameters, case, spaces and padding are ignored.
+	k, err = otp.Parse("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	require.NoError(t, err)
+	assert.Equal(t, otp.Key{Secret: []byte("12345678901234567890"), Algorithm: otp.SHA1, Digits: 6, Period: 30}, k)
+
+	for input, msg := range map[string]string{
+		"":                                "secret is required",
+		"not base32!":                     "base32",
+		"otpauth://hotp/x?secret=" + seed: "only totp",
+		"otpauth://totp/x?secret=" + seed + "&algorithm=md5": "unsupported algorithm",
+		"otpauth://totp/x?secret=" + seed + "&digits=7":      "digits must be 6 or 8",
+		"otpauth://totp/x?secret=" + seed + "&period=0":      "period must be positive",
+		"otpauth://totp/x?secret=" + seed + "&period=abc":    "invalid period",
+	} {
+		_, err := otp.Parse(input)
+		assert.ErrorContains(t, err, msg, input)
+	}
+}
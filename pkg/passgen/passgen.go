@@ -0,0 +1,225 @@
+// Package passgen generates random passwords and diceware-style passphrases
+// according to a policy. All randomness comes from crypto/rand.
+package passgen
+
+import (
+	"bufio"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Character classes of passwords.
+const (
+	ClassLower   = "lower"
+	ClassUpper   = "upper"
+	ClassDigits  = "digits"
+	ClassSymbols = "symbols"
+)
+
+// Classes are the names of all character classes in the order of their characters in a password alphabet.
+var Classes = []string{ClassLower, ClassUpper, ClassDigits, ClassSymbols}
+
+// charsets are the characters of the classes.
+var charsets = map[string]string{
+	ClassLower:   "abcdefghijklmnopqrstuvwxyz",
+	ClassUpper:   "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	ClassDigits:  "0123456789",
+	ClassSymbols: "!#$%&()*+,-./:<=>?@[]^_{}~",
+}
+
+// ambiguous are the characters easily confused with each other when read.
+const ambiguous = "Il1O0o"
+
+// Limits of the policy values.
+const (
+	MaxLength = 1024
+	MaxWords  = 64
+)
+
+// wordlistFile is the EFF large wordlist: 7776 words keyed by five dice rolls.
+//
+//go:embed eff_large_wordlist.txt
+var wordlistFile string
+
+// wordlist returns the words of the embedded wordlist parsed on the first use.
+var wordlist = sync.OnceValue(func() []string {
+	words := make([]string, 0, 7776)
+	scanner := bufio.NewScanner(strings.NewReader(wordlistFile))
+	for scanner.Scan() {
+		_, word, ok := strings.Cut(scanner.Text(), "\t")
+		if ok && word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+})
+
+// Policy describes generated secrets. The mapstructure tags are the keys of the policy in the client config.
+type Policy struct {
+	// Length is the number of characters of a password.
+	Length int `mapstructure:"length"`
+	// Classes are the character classes a password is made of.
+	Classes []string `mapstructure:"classes"`
+	// Require are the classes present in a password at least once, a subset of Classes.
+	Require []string `mapstructure:"require"`
+	// ExcludeAmbiguous leaves out characters such as I, l, 1, O and 0.
+	ExcludeAmbiguous bool `mapstructure:"exclude_ambiguous"`
+
+	// Passphrase generates words of the wordlist instead of a password.
+	Passphrase bool `mapstructure:"passphrase"`
+	// Words is the number of words of a passphrase.
+	Words int `mapstructure:"words"`
+	// Separator joins the words of a passphrase.
+	Separator string `mapstructure:"separator"`
+}
+
+// DefaultPolicy returns the policy of 20-character passwords with characters of every class
+// and 6-word passphrases separated by dashes.
+func DefaultPolicy() Policy {
+	return Policy{
+		Length:    20,
+		Classes:   slices.Clone(Classes),
+		Require:   slices.Clone(Classes),
+		Words:     6,
+		Separator: "-",
+	}
+}
+
+// Validate checks that the policy can generate a secret.
+func (p Policy) Validate() error {
+	if p.Passphrase {
+		if p.Words < 1 || p.Words > MaxWords {
+			return fmt.Errorf("number of words must be between 1 and %d", MaxWords)
+		}
+		return nil
+	}
+
+	if p.Length < 1 || p.Length > MaxLength {
+		return fmt.Errorf("length must be between 1 and %d", MaxLength)
+	}
+	if len(p.Classes) == 0 {
+		return errors.New("at least one character class is required")
+	}
+	for _, class := range p.Classes {
+		if _, ok := charsets[class]; !ok {
+			return fmt.Errorf("unknown character class %q, expected one of %s", class, strings.Join(Classes, ", "))
+		}
+	}
+	for _, class := range p.Require {
+		if !slices.Contains(p.Classes, class) {
+			return fmt.Errorf("required class %q is not one of the password classes", class)
+		}
+	}
+	if required := len(unique(p.Require)); required > p.Length {
+		return fmt.Errorf("length %d is too short for %d required classes", p.Length, required)
+	}
+
+	return nil
+}
+
+// Generate returns a random password or passphrase of the policy.
+func Generate(p Policy) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	if p.Passphrase {
+		return passphrase(p.Words, p.Separator)
+	}
+	return password(p)
+}
+
+// password returns a password with a character of every required class, the rest is taken from all the classes.
+func password(p Policy) (string, error) {
+	var alphabet []rune
+	for _, class := range unique(p.Classes) {
+		alphabet = append(alphabet, p.charset(class)...)
+	}
+
+	chars := make([]rune, 0, p.Length)
+	for _, class := range unique(p.Require) {
+		c, err := pick(p.charset(class))
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, c)
+	}
+	for len(chars) < p.Length {
+		c, err := pick(alphabet)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, c)
+	}
+
+	// The required characters are moved to random positions.
+	for i := len(chars) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+
+	return string(chars), nil
+}
+
+// passphrase returns the number of random words of the wordlist joined with the separator.
+func passphrase(words int, separator string) (string, error) {
+	list := wordlist()
+	chosen := make([]string, words)
+	for i := range chosen {
+		n, err := randInt(len(list))
+		if err != nil {
+			return "", err
+		}
+		chosen[i] = list[n]
+	}
+
+	return strings.Join(chosen, separator), nil
+}
+
+// charset returns the characters of the class allowed by the policy.
+func (p Policy) charset(class string) []rune {
+	chars := []rune(charsets[class])
+	if p.ExcludeAmbiguous {
+		chars = slices.DeleteFunc(chars, func(c rune) bool {
+			return strings.ContainsRune(ambiguous, c)
+		})
+	}
+	return chars
+}
+
+// pick returns a random character of the set.
+func pick(set []rune) (rune, error) {
+	n, err := randInt(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return set[n], nil
+}
+
+// randInt returns a uniform random number in [0, n).
+func randInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return int(v.Int64()), nil
+}
+
+// unique returns the values without duplicates keeping their order.
+func unique(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if !slices.Contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
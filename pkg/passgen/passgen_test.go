@@ -0,0 +1,78 @@
+package passgen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/passgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	p := passgen.DefaultPolicy()
+	seen := make(map[string]bool)
+	for range 100 {
+		password, err := passgen.Generate(p)
+		require.NoError(t, err)
+		assert.Len(t, password, 20)
+		assert.True(t, strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz"))
+		assert.True(t, strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+		assert.True(t, strings.ContainsAny(password, "0123456789"))
+		assert.True(t, strings.ContainsAny(password, "!#$%&()*+,-./:<=>?@[]^_{}~"))
+		seen[password] = true
+	}
+	assert.Len(t, seen, 100)
+
+	// Every required class fits a password of their number.
+	p = passgen.Policy{Length: 2, Classes: []string{passgen.ClassDigits, passgen.ClassUpper}, Require: []string{passgen.ClassUpper, passgen.ClassDigits}, ExcludeAmbiguous: true}
+	for range 100 {
+		password, err := passgen.Generate(p)
+		require.NoError(t, err)
+		require.Len(t, password, 2)
+		assert.True(t, strings.ContainsAny(password, "ABCDEFGHJKLMNPQRSTUVWXYZ"))
+		assert.True(t, strings.ContainsAny(password, "23456789"))
+		assert.False(t, strings.ContainsAny(password, "IO01"))
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	p := passgen.DefaultPolicy()
+	p.Passphrase = true
+	p.Words, p.Separator = 8, " "
+
+	phrase, err := passgen.Generate(p)
+	require.NoError(t, err)
+	words := strings.Split(phrase, " ")
+	assert.Len(t, words, 8)
+	for _, word := range words {
+		assert.NotEmpty(t, word)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy passgen.Policy
+		err    string
+	}{
+		{name: "zero length", policy: passgen.Policy{Classes: []string{passgen.ClassLower}}, err: "length must be between"},
+		{name: "no classes", policy: passgen.Policy{Length: 8}, err: "at least one character class"},
+		{name: "unknown class", policy: passgen.Policy{Length: 8, Classes: []string{"emoji"}}, err: `unknown character class "emoji"`},
+		{name: "required class not used", policy: passgen.Policy{Length: 8, Classes: []string{passgen.ClassLower}, Require: []string{passgen.ClassDigits}}, err: `required class "digits"`},
+		{name: "too short for required", policy: passgen.Policy{Length: 1, Classes: passgen.Classes, Require: []string{passgen.ClassLower, passgen.ClassDigits}}, err: "too short"},
+		{name: "no words", policy: passgen.Policy{Passphrase: true}, err: "number of words"},
+		{name: "valid", policy: passgen.DefaultPolicy()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
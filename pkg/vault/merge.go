@@ -0,0 +1,183 @@
+package vault
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Names of the plaintext fields besides the typed ones.
+const (
+	FieldFolder = "folder"
+	FieldTags   = "tags"
+	FieldNote   = "note"
+	// FieldData holds the whole plaintext of a secret of unknown layout.
+	FieldData = "data"
+)
+
+// layouts are the typed fields of the secret plaintexts in their order, by the name of the first field.
+// Every field but the last ends with ';', the last one takes the rest of the plaintext.
+var layouts = map[string][]string{
+	"number": {"number", "date", "holder", "code"},
+	"login":  {"login", "password"},
+	"text":   {"text"},
+	"bin":    {"bin"},
+	"otp":    {"otp"},
+}
+
+// Fields are the decrypted fields of a secret by name: folder, tags, the typed fields
+// such as login and password, and the note stored in the secret meta. Empty fields are omitted.
+type Fields map[string]string
+
+// ParseFields splits the decrypted secret plaintext and meta into fields.
+// The plaintext of an unknown layout is kept whole in FieldData.
+func ParseFields(data, meta string) Fields {
+	f := make(Fields)
+	for _, name := range []string{FieldFolder, FieldTags} {
+		if tail, ok := strings.CutPrefix(data, name+":"); ok {
+			if value, rest, ok := strings.Cut(tail, ";"); ok {
+				f.set(name, value)
+				data = rest
+			}
+		}
+	}
+	f.set(FieldNote, meta)
+
+	typed, ok := parseTyped(data)
+	if !ok {
+		f.set(FieldData, data)
+		return f
+	}
+	// Typed fields are kept even if empty as they define the layout.
+	for name, value := range typed {
+		f[name] = value
+	}
+
+	return f
+}
+
+// parseTyped splits the plaintext into the fields of its layout.
+func parseTyped(data string) (map[string]string, bool) {
+	first, _, _ := strings.Cut(data, ":")
+	layout, ok := layouts[first]
+	if !ok {
+		return nil, false
+	}
+
+	typed := make(map[string]string, len(layout))
+	for i, name := range layout {
+		tail, ok := strings.CutPrefix(data, name+":")
+		if !ok {
+			return nil, false
+		}
+		if i == len(layout)-1 {
+			typed[name] = tail
+			break
+		}
+		typed[name], data, ok = strings.Cut(tail, ";")
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return typed, true
+}
+
+// set saves non-empty field value.
+func (f Fields) set(name, value string) {
+	if value != "" {
+		f[name] = value
+	}
+}
+
+// layout returns the typed fields of the secret, nil if its layout is unknown.
+func (f Fields) layout() []string {
+	for first, layout := range layouts {
+		if _, ok := f[first]; ok {
+			return layout
+		}
+	}
+
+	return nil
+}
+
+// Format joins the fields back into the secret plaintext and meta.
+func (f Fields) Format() (data, meta string) {
+	var sb strings.Builder
+	for _, name := range []string{FieldFolder, FieldTags} {
+		if f[name] != "" {
+			sb.WriteString(name + ":" + f[name] + ";")
+		}
+	}
+
+	layout := f.layout()
+	if layout == nil {
+		sb.WriteString(f[FieldData])
+	}
+	for i, name := range layout {
+		if i > 0 {
+			sb.WriteString(";")
+		}
+		sb.WriteString(name + ":" + f[name])
+	}
+
+	return sb.String(), f[FieldNote]
+}
+
+// Merge combines the local and remote versions of a secret changed since the base version field by field.
+// A field changed on one side only takes the changed value. Fields changed differently on both sides
+// are conflicts: they keep the remote values in merged and the local values in mergedLocal.
+// Versions of different layouts can't be merged field by field, a layout changed on one side wins
+// and a layout changed on both sides makes every differing field a conflict.
+func Merge(base, local, remote Fields) (merged, mergedLocal Fields, conflicts []string) {
+	localLayout, remoteLayout := local.layout(), remote.layout()
+	if !slices.Equal(localLayout, remoteLayout) {
+		switch {
+		case slices.Equal(localLayout, base.layout()):
+			return maps.Clone(remote), maps.Clone(remote), nil
+		case slices.Equal(remoteLayout, base.layout()):
+			return maps.Clone(local), maps.Clone(local), nil
+		}
+		for name := range fieldNames(local, remote) {
+			if local[name] != remote[name] {
+				conflicts = append(conflicts, name)
+			}
+		}
+		slices.Sort(conflicts)
+		return maps.Clone(remote), maps.Clone(local), conflicts
+	}
+
+	merged, mergedLocal = make(Fields), make(Fields)
+	for name := range fieldNames(base, local, remote) {
+		value, localValue := remote[name], remote[name]
+		switch b, l, r := base[name], local[name], remote[name]; {
+		case l == r || l == b:
+		case r == b:
+			value, localValue = l, l
+		default:
+			localValue = l
+			conflicts = append(conflicts, name)
+		}
+		if value != "" || slices.Contains(localLayout, name) {
+			merged[name] = value
+		}
+		if localValue != "" || slices.Contains(localLayout, name) {
+			mergedLocal[name] = localValue
+		}
+	}
+	slices.Sort(conflicts)
+
+	return merged, mergedLocal, conflicts
+}
+
+// fieldNames returns names of the fields present in any of the versions.
+func fieldNames(versions ...Fields) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, f := range versions {
+		for name := range f {
+			names[name] = struct{}{}
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,115 @@
+package vault_test
+
+import (
+	"testing"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormatFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		meta   string
+		fields vault.Fields
+	}{
+		{
+			name:   "credentials in folder",
+			data:   "folder:work/aws;tags:prod,ci;login:admin;password:p;ss",
+			meta:   "aws",
+			fields: vault.Fields{"folder": "work/aws", "tags": "prod,ci", "login": "admin", "password": "p;ss", "note": "aws"},
+		},
+		{
+			name:   "card with empty fields",
+			data:   "number:;date:02/25;holder:Name;code:",
+			fields: vault.Fields{"number": "", "date": "02/25", "holder": "Name", "code": ""},
+		},
+		{
+			name:   "text",
+			data:   "text:a;b:c",
+			fields: vault.Fields{"text": "a;b:c"},
+		},
+		{
+			name:   "otp",
+			data:   "folder:ci;otp:otpauth://totp/ACME:bot?secret=ABC&period=30",
+			fields: vault.Fields{"folder": "ci", "otp": "otpauth://totp/ACME:bot?secret=ABC&period=30"},
+		},
+		{
+			name:   "unknown layout",
+			data:   "tags:x;something else",
+			fields: vault.Fields{"tags": "x", "data": "something else"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := vault.ParseFields(tt.data, tt.meta)
+			assert.Equal(t, tt.fields, fields)
+
+			data, meta := fields.Format()
+			assert.Equal(t, tt.data, data)
+			assert.Equal(t, tt.meta, meta)
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := vault.Fields{"login": "user", "password": "old", "note": "note"}
+
+	tests := []struct {
+		name        string
+		local       vault.Fields
+		remote      vault.Fields
+		merged      vault.Fields
+		mergedLocal vault.Fields
+		conflicts   []string
+	}{
+		{
+			name:        "different fields",
+			local:       vault.Fields{"login": "user", "password": "local", "note": "note"},
+			remote:      vault.Fields{"login": "user", "password": "old", "note": "remote note", "folder": "work"},
+			merged:      vault.Fields{"login": "user", "password": "local", "note": "remote note", "folder": "work"},
+			mergedLocal: vault.Fields{"login": "user", "password": "local", "note": "remote note", "folder": "work"},
+		},
+		{
+			name:        "same change",
+			local:       vault.Fields{"login": "user", "password": "new"},
+			remote:      vault.Fields{"login": "user", "password": "new"},
+			merged:      vault.Fields{"login": "user", "password": "new"},
+			mergedLocal: vault.Fields{"login": "user", "password": "new"},
+		},
+		{
+			name:        "conflicting field",
+			local:       vault.Fields{"login": "local", "password": "local", "note": "note"},
+			remote:      vault.Fields{"login": "user", "password": "remote", "note": ""},
+			merged:      vault.Fields{"login": "local", "password": "remote"},
+			mergedLocal: vault.Fields{"login": "local", "password": "local"},
+			conflicts:   []string{"password"},
+		},
+		{
+			name:        "layout changed locally",
+			local:       vault.Fields{"text": "text"},
+			remote:      vault.Fields{"login": "user", "password": "remote"},
+			merged:      vault.Fields{"text": "text"},
+			mergedLocal: vault.Fields{"text": "text"},
+		},
+		{
+			name:        "layout changed on both sides",
+			local:       vault.Fields{"text": "text"},
+			remote:      vault.Fields{"bin": "00", "note": "note"},
+			merged:      vault.Fields{"bin": "00", "note": "note"},
+			mergedLocal: vault.Fields{"text": "text"},
+			conflicts:   []string{"bin", "note", "text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, mergedLocal, conflicts := vault.Merge(base, tt.local, tt.remote)
+			assert.Equal(t, tt.merged, merged)
+			assert.Equal(t, tt.mergedLocal, mergedLocal)
+			assert.Equal(t, tt.conflicts, conflicts)
+		})
+	}
+}
@@ -0,0 +1,239 @@
+// Package vault provides the local encrypted copy of user's vault used by the client offline.
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/encryption"
+)
+
+// ErrWrongKey is returned when the vault file is encrypted with another encryption key.
+var ErrWrongKey = errors.New("vault is encrypted with another key")
+
+// Operations of the queued changes.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+)
+
+// Secret is a personal secret with decrypted data and meta.
+type Secret struct {
+	ID        string     `json:"id"`
+	Data      string     `json:"data"`
+	Meta      string     `json:"meta"`
+	Type      string     `json:"type,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Change is a create or update of a secret made offline and waiting in the outbox to be sent to the server.
+type Change struct {
+	Op     string `json:"op"`
+	Secret Secret `json:"secret"`
+	// Remove the expiration time of the updated secret, unset ExpiresAt keeps the current one.
+	ClearExpiresAt bool      `json:"clear_expires_at,omitempty"`
+	QueuedAt       time.Time `json:"queued_at"`
+	// Last synced version of the updated secret the change is merged against if the secret was changed on the server.
+	Base *Secret `json:"base,omitempty"`
+}
+
+// Conflict is a secret changed both locally and on the server in the same fields.
+// The secret keeps the server values of the fields and its copy keeps the local ones.
+type Conflict struct {
+	SecretID   string    `json:"secret_id"`
+	CopyID     string    `json:"copy_id"`
+	Fields     []string  `json:"fields"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// state is the content of the vault file.
+type state struct {
+	SyncedAt  time.Time  `json:"synced_at"`
+	Secrets   []Secret   `json:"secrets"`
+	Outbox    []Change   `json:"outbox"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// Vault is the last copy of secrets received from the server together with the outbox of changes made offline.
+// The vault is stored in a single file encrypted with user's encryption key.
+type Vault struct {
+	path  string
+	key   string
+	state state
+}
+
+// Open reads the vault file decrypting it with the key. Missing file opens an empty vault.
+func Open(path, key string) (*Vault, error) {
+	v := &Vault{path: path, key: key}
+
+	ciphertext, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	plaintext, err := encryption.DecryptWithKey(string(ciphertext), key)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	if err := json.Unmarshal([]byte(plaintext), &v.state); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %w", err)
+	}
+
+	return v, nil
+}
+
+// Save encrypts and writes the vault to its file.
+// The file is replaced atomically so that an interrupted write doesn't lose the outbox.
+func (v *Vault) Save() error {
+	plaintext, err := json.Marshal(v.state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+	ciphertext, err := encryption.EncryptWithKey(string(plaintext), v.key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt vault: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create vault directory: %w", err)
+	}
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(ciphertext), 0o600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	if err := os.Rename(tmp, v.path); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+
+	return nil
+}
+
+// Refresh replaces the cached secrets with the ones received from the server at the time.
+// Queued changes are kept until they are sent.
+func (v *Vault) Refresh(secrets []Secret, syncedAt time.Time) {
+	v.state.Secrets = secrets
+	v.state.SyncedAt = syncedAt
+}
+
+// SyncedAt returns the time the cached secrets were received from the server, zero if they never were.
+func (v *Vault) SyncedAt() time.Time {
+	return v.state.SyncedAt
+}
+
+// Secrets returns the cached secrets with the queued changes applied, ordered by id.
+func (v *Vault) Secrets() []Secret {
+	secrets := make(map[string]Secret, len(v.state.Secrets))
+	for _, s := range v.state.Secrets {
+		secrets[s.ID] = s
+	}
+	for _, c := range v.state.Outbox {
+		if c.Op == OpCreate {
+			secrets[c.Secret.ID] = c.Secret
+			continue
+		}
+		// Updates of secrets missing in the cache, e.g. selected by legacy ids, are shown after sync.
+		if current, ok := secrets[c.Secret.ID]; ok {
+			secrets[c.Secret.ID] = c.Apply(current)
+		}
+	}
+
+	result := make([]Secret, 0, len(secrets))
+	for _, s := range secrets {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result
+}
+
+// Apply returns the secret updated by the change.
+// Empty type and unset expiration time keep the current ones like the server does.
+func (c Change) Apply(secret Secret) Secret {
+	secret.Data = c.Secret.Data
+	secret.Meta = c.Secret.Meta
+	if c.Secret.Type != "" {
+		secret.Type = c.Secret.Type
+	}
+	if c.Secret.ExpiresAt != nil || c.ClearExpiresAt {
+		secret.ExpiresAt = c.Secret.ExpiresAt
+	}
+	secret.UpdatedAt = c.Secret.UpdatedAt
+
+	return secret
+}
+
+// Enqueue adds the change to the outbox. Updates of a secret already in the outbox are combined with
+// the queued change, so that every secret is sent once and merged against the version it was changed from.
+func (v *Vault) Enqueue(c Change) {
+	for i, queued := range v.state.Outbox {
+		if queued.Secret.ID != c.Secret.ID || c.Op != OpUpdate {
+			continue
+		}
+		queued.Secret = c.Apply(queued.Secret)
+		if c.Secret.ExpiresAt != nil || c.ClearExpiresAt {
+			queued.ClearExpiresAt = c.ClearExpiresAt
+		}
+		queued.QueuedAt = c.QueuedAt
+		v.state.Outbox[i] = queued
+		return
+	}
+
+	if c.Op == OpUpdate && c.Base == nil {
+		for _, s := range v.state.Secrets {
+			if s.ID == c.Secret.ID {
+				c.Base = &s
+				break
+			}
+		}
+	}
+	v.state.Outbox = append(v.state.Outbox, c)
+}
+
+// Outbox returns the queued changes in the order they were made.
+func (v *Vault) Outbox() []Change {
+	return v.state.Outbox
+}
+
+// Dequeue removes the changes at the given positions of the outbox after the server applied them.
+// The rest of the changes stay queued in the same order.
+func (v *Vault) Dequeue(applied []int) {
+	outbox := make([]Change, 0, len(v.state.Outbox))
+	for i, c := range v.state.Outbox {
+		if !slices.Contains(applied, i) {
+			outbox = append(outbox, c)
+		}
+	}
+	v.state.Outbox = outbox
+}
+
+// AddConflict saves the conflict to be resolved by the user.
+func (v *Vault) AddConflict(c Conflict) {
+	v.state.Conflicts = append(v.state.Conflicts, c)
+}
+
+// Conflicts returns the unresolved conflicts in the order they were detected.
+func (v *Vault) Conflicts() []Conflict {
+	return v.state.Conflicts
+}
+
+// ResolveConflict removes and returns the conflict of the secret, it reports whether there was one.
+func (v *Vault) ResolveConflict(secretID string) (Conflict, bool) {
+	for i, c := range v.state.Conflicts {
+		if c.SecretID == secretID {
+			v.state.Conflicts = append(v.state.Conflicts[:i], v.state.Conflicts[i+1:]...)
+			return c, true
+		}
+	}
+
+	return Conflict{}, false
+}
@@ -0,0 +1,98 @@
+package vault_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KirillZiborov/GophKeeper/pkg/vault"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gophkeeper", "vault")
+	key := "my-secret-key"
+
+	// Missing file opens an empty vault.
+	v, err := vault.Open(path, key)
+	require.NoError(t, err)
+	assert.Empty(t, v.Secrets())
+	assert.True(t, v.SyncedAt().IsZero())
+
+	syncedAt := time.Now().UTC().Truncate(time.Second)
+	expiresAt := syncedAt.Add(time.Hour)
+	v.Refresh([]vault.Secret{
+		{ID: "b", Data: "data b", Meta: "meta b", Type: "text", ExpiresAt: &expiresAt},
+		{ID: "c", Data: "data c", Meta: "meta c", Type: "text"},
+	}, syncedAt)
+	v.Enqueue(vault.Change{Op: vault.OpCreate, Secret: vault.Secret{ID: "a", Data: "data a", Type: "text"}})
+	v.Enqueue(vault.Change{Op: vault.OpUpdate, Secret: vault.Secret{ID: "b", Data: "new b", Meta: "new meta b"}})
+	v.Enqueue(vault.Change{Op: vault.OpUpdate, Secret: vault.Secret{ID: "c", Data: "new c", ExpiresAt: &expiresAt}})
+	v.Enqueue(vault.Change{Op: vault.OpUpdate, Secret: vault.Secret{ID: "42", Data: "legacy"}})
+	require.NoError(t, v.Save())
+
+	v, err = vault.Open(path, key)
+	require.NoError(t, err)
+	assert.Equal(t, syncedAt, v.SyncedAt())
+	require.Len(t, v.Outbox(), 4)
+	assert.Equal(t, vault.OpCreate, v.Outbox()[0].Op)
+
+	// Queued changes are applied over the cached secrets.
+	secrets := v.Secrets()
+	require.Len(t, secrets, 3)
+	assert.Equal(t, "a", secrets[0].ID)
+	assert.Equal(t, vault.Secret{ID: "b", Data: "new b", Meta: "new meta b", Type: "text", ExpiresAt: &expiresAt}, secrets[1])
+	assert.Equal(t, "new c", secrets[2].Data)
+	assert.Equal(t, &expiresAt, secrets[2].ExpiresAt)
+
+	// Refresh keeps the outbox until the changes are sent, updates of missing secrets aren't shown.
+	v.Refresh([]vault.Secret{{ID: "a", Data: "data a", Type: "text"}}, syncedAt)
+	assert.Len(t, v.Secrets(), 1)
+	require.Len(t, v.Outbox(), 4)
+	v.Dequeue([]int{0, 2})
+	require.Len(t, v.Outbox(), 2)
+	assert.Equal(t, "b", v.Outbox()[0].Secret.ID)
+	assert.Equal(t, "42", v.Outbox()[1].Secret.ID)
+	v.Dequeue([]int{0, 1, 5})
+	assert.Empty(t, v.Outbox())
+	assert.Len(t, v.Secrets(), 1)
+
+	_, err = vault.Open(path, "another-key")
+	require.ErrorIs(t, err, vault.ErrWrongKey)
+}
+
+func TestVaultOutboxAndConflicts(t *testing.T) {
+	v, err := vault.Open(filepath.Join(t.TempDir(), "vault"), "my-secret-key")
+	require.NoError(t, err)
+
+	synced := vault.Secret{ID: "a", Data: "data a", Type: "text", UpdatedAt: time.Now().UTC()}
+	v.Refresh([]vault.Secret{synced}, time.Now())
+
+	// Updates of the same secret are combined and keep the synced version as the base.
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	v.Enqueue(vault.Change{Op: vault.OpUpdate, Secret: vault.Secret{ID: "a", Data: "first", ExpiresAt: &expiresAt}})
+	v.Enqueue(vault.Change{Op: vault.OpCreate, Secret: vault.Secret{ID: "b", Data: "data b"}})
+	v.Enqueue(vault.Change{Op: vault.OpUpdate, Secret: vault.Secret{ID: "a", Data: "second"}, ClearExpiresAt: true})
+	v.Enqueue(vault.Change{Op: vault.OpUpdate, Secret: vault.Secret{ID: "b", Data: "new b"}})
+
+	outbox := v.Outbox()
+	require.Len(t, outbox, 2)
+	assert.Equal(t, vault.OpUpdate, outbox[0].Op)
+	assert.Equal(t, "second", outbox[0].Secret.Data)
+	assert.Nil(t, outbox[0].Secret.ExpiresAt)
+	assert.True(t, outbox[0].ClearExpiresAt)
+	assert.Equal(t, &synced, outbox[0].Base)
+	assert.Equal(t, vault.OpCreate, outbox[1].Op)
+	assert.Equal(t, "new b", outbox[1].Secret.Data)
+	assert.Nil(t, outbox[1].Base)
+
+	v.AddConflict(vault.Conflict{SecretID: "a", CopyID: "c", Fields: []string{"text"}})
+	require.Len(t, v.Conflicts(), 1)
+	_, ok := v.ResolveConflict("c")
+	assert.False(t, ok)
+	conflict, ok := v.ResolveConflict("a")
+	assert.True(t, ok)
+	assert.Equal(t, "c", conflict.CopyID)
+	assert.Empty(t, v.Conflicts())
+}
@@ -0,0 +1,22 @@
+package proto
+
+// IdempotencyKeyHeader is the name of metadata with a client-generated idempotency key.
+const IdempotencyKeyHeader = "idempotency-key"
+
+// IdempotentMethods lists mutating methods safe to retry with the same idempotency key:
+// the client sends the key with them and the server replays the saved response instead of applying them again.
+var IdempotentMethods = map[string]bool{
+	Keeper_AddSecret_FullMethodName:          true,
+	Keeper_EditSecret_FullMethodName:         true,
+	Keeper_BatchMutateSecrets_FullMethodName: true,
+	Keeper_SetUserKeys_FullMethodName:        true,
+	Keeper_ShareSecret_FullMethodName:        true,
+	Keeper_RevokeShare_FullMethodName:        true,
+	Keeper_CreateOrganization_FullMethodName: true,
+	Keeper_InviteMember_FullMethodName:       true,
+	Keeper_AcceptInvitation_FullMethodName:   true,
+	Keeper_RemoveMember_FullMethodName:       true,
+	Keeper_SetMemberRole_FullMethodName:      true,
+	Keeper_CreateCollection_FullMethodName:   true,
+	Keeper_CreateSend_FullMethodName:         true,
+}